@@ -2,22 +2,36 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Server ServerConfig
-	DB     DatabaseConfig
-	JWT    JWTConfig
-	CORS   CORSConfig
+	Server       ServerConfig
+	DB           DatabaseConfig
+	JWT          JWTConfig
+	CORS         CORSConfig
+	Storage      StorageConfig
+	Mail         MailConfig
+	Auth         AuthConfig
+	OIDC         OIDCConfig
+	LDAP         LDAPConfig
+	Task         TaskConfig
+	Notification NotificationConfig
 }
 
 type ServerConfig struct {
-	Port string
-	Mode string
+	Port        string
+	Mode        string
+	FrontendURL string // 前端访问地址，用于生成邮件中的链接
+	Timezone    string // 用户未设置时区时使用的默认时区（IANA名称）
+	// 可信反向代理的 IP 或 CIDR，只有来自这些地址的请求才使用 X-Forwarded-For 作为客户端 IP
+	TrustedProxies []string
 }
 
 type DatabaseConfig struct {
@@ -27,17 +41,80 @@ type DatabaseConfig struct {
 	Name     string
 	User     string
 	Password string
+	Timezone string // MySQL 连接的 loc 参数，决定 DATETIME 字段按哪个时区读写
 }
 
 type JWTConfig struct {
-	Secret      string
-	ExpireHours int
+	Secret              string // HS256 签名密钥；非对称模式下用于加密数据库中的私钥
+	Algorithm           string // 签名算法：EdDSA、RS256 或 HS256
+	KeyRotationDays     int    // 非对称签名密钥轮换周期（天）
+	AccessExpireMinutes int    // 访问令牌有效期（分钟）
+	RefreshExpireDays   int    // 刷新令牌有效期（天）
 }
 
+// JWT 签名算法
+const (
+	JWTAlgorithmHS256 = "HS256"
+	JWTAlgorithmRS256 = "RS256"
+	JWTAlgorithmEdDSA = "EdDSA"
+)
+
+// DefaultJWTSecret 未配置 JWT_SECRET 时使用的默认值，仅允许在开发模式下使用
+const DefaultJWTSecret = "default-secret-key"
+
 type CORSConfig struct {
 	AllowOrigins string
 }
 
+// AuthConfig 账号安全策略配置
+type AuthConfig struct {
+	RequireEmailVerification bool // 邮箱验证通过后才允许登录
+	RequireAdminMFA          bool // 系统管理员必须启用两步验证
+	LoginMaxAttempts         int  // 同一账号连续登录失败多少次后锁定
+	LoginIPMaxAttempts       int  // 同一IP连续登录失败多少次后锁定
+	LoginLockoutMinutes      int  // 首次锁定时长（分钟），之后每次失败翻倍
+	LoginMaxLockoutMinutes   int  // 单次锁定时长上限（分钟）
+	ImpersonationMinutes     int  // 管理员模拟登录令牌有效期（分钟），不可刷新
+}
+
+// TaskConfig 任务规则配置
+type TaskConfig struct {
+	BlockedMovePolicy string // 被阻塞的任务移入完成列时的处理方式：reject 拒绝，warn 允许但返回警告
+}
+
+// 被阻塞任务的移动策略
+const (
+	BlockedMoveReject = "reject"
+	BlockedMoveWarn   = "warn"
+)
+
+// NotificationConfig 任务通知推送配置
+type NotificationConfig struct {
+	URL string // 通知服务地址，通知 POST 到 {URL}/api/notifications
+}
+
+// MailConfig 邮件发送配置，Host 为空时邮件仅输出到日志
+type MailConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// StorageConfig 附件存储配置
+// Driver: "local"（本地文件系统）或 "s3"（S3兼容对象存储，如MinIO）
+type StorageConfig struct {
+	Driver        string
+	LocalDir      string
+	MaxUploadSize int64
+	S3Endpoint    string
+	S3Region      string
+	S3Bucket      string
+	S3AccessKey   string
+	S3SecretKey   string
+}
+
 func Load() *Config {
 	if err := godotenv.Load("config.env"); err != nil {
 		fmt.Println("Warning: config.env not found, using system env")
@@ -47,8 +124,11 @@ func Load() *Config {
 
 	return &Config{
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
-			Mode: getEnv("SERVER_MODE", "debug"),
+			Port:           getEnv("SERVER_PORT", "8080"),
+			Mode:           getEnv("SERVER_MODE", "debug"),
+			FrontendURL:    getEnv("FRONTEND_URL", "http://localhost:5173"),
+			Timezone:       getEnv("SERVER_TIMEZONE", "Asia/Shanghai"),
+			TrustedProxies: splitList(getEnv("SERVER_TRUSTED_PROXIES", "")),
 		},
 		DB: DatabaseConfig{
 			Type:     getEnv("DB_TYPE", "mysql"),
@@ -57,15 +137,230 @@ func Load() *Config {
 			Name:     getEnv("DB_NAME", "progress_wall"),
 			User:     getEnv("DB_USER", "root"),
 			Password: getEnv("DB_PASSWORD", ""),
+			Timezone: getEnv("DB_TIMEZONE", "Local"),
 		},
 		JWT: JWTConfig{
-			Secret:      getEnv("JWT_SECRET", "default-secret-key"),
-			ExpireHours: getEnvAsInt("JWT_EXPIRE_HOURS", 24),
+			Secret:              getEnv("JWT_SECRET", DefaultJWTSecret),
+			Algorithm:           getEnv("JWT_ALGORITHM", JWTAlgorithmEdDSA),
+			KeyRotationDays:     getEnvAsInt("JWT_KEY_ROTATION_DAYS", 30),
+			AccessExpireMinutes: getEnvAsInt("JWT_ACCESS_EXPIRE_MINUTES", 15),
+			RefreshExpireDays:   getEnvAsInt("JWT_REFRESH_EXPIRE_DAYS", 30),
 		},
 		CORS: CORSConfig{
 			AllowOrigins: getEnv("CORS_ALLOW_ORIGINS", "http://localhost:3000,http://localhost:5173"),
 		},
+		Storage: StorageConfig{
+			Driver:        getEnv("STORAGE_DRIVER", "local"),
+			LocalDir:      getEnv("STORAGE_LOCAL_DIR", "./data"),
+			MaxUploadSize: int64(getEnvAsInt("STORAGE_MAX_UPLOAD_MB", 20)) * 1024 * 1024,
+			S3Endpoint:    getEnv("S3_ENDPOINT", "http://localhost:9000"),
+			S3Region:      getEnv("S3_REGION", "us-east-1"),
+			S3Bucket:      getEnv("S3_BUCKET", "progress-wall"),
+			S3AccessKey:   getEnv("S3_ACCESS_KEY", ""),
+			S3SecretKey:   getEnv("S3_SECRET_KEY", ""),
+		},
+		Auth: AuthConfig{
+			RequireEmailVerification: getEnvAsBool("AUTH_REQUIRE_EMAIL_VERIFICATION", false),
+			RequireAdminMFA:          getEnvAsBool("AUTH_REQUIRE_ADMIN_MFA", false),
+			LoginMaxAttempts:         getEnvAsInt("AUTH_LOGIN_MAX_ATTEMPTS", 5),
+			LoginIPMaxAttempts:       getEnvAsInt("AUTH_LOGIN_IP_MAX_ATTEMPTS", 20),
+			LoginLockoutMinutes:      getEnvAsInt("AUTH_LOGIN_LOCKOUT_MINUTES", 1),
+			LoginMaxLockoutMinutes:   getEnvAsInt("AUTH_LOGIN_MAX_LOCKOUT_MINUTES", 60),
+			ImpersonationMinutes:     getEnvAsInt("AUTH_IMPERSONATION_MINUTES", 30),
+		},
+		Mail: MailConfig{
+			Host:     getEnv("SMTP_HOST", ""),
+			Port:     getEnv("SMTP_PORT", "25"),
+			Username: getEnv("SMTP_USERNAME", ""),
+			Password: getEnv("SMTP_PASSWORD", ""),
+			From:     getEnv("SMTP_FROM", "Progress Wall <noreply@localhost>"),
+		},
+		OIDC: loadOIDCConfig(),
+		LDAP: LDAPConfig{
+			URL:                 getEnv("LDAP_URL", ""),
+			StartTLS:            getEnvAsBool("LDAP_START_TLS", false),
+			InsecureSkipVerify:  getEnvAsBool("LDAP_INSECURE_SKIP_VERIFY", false),
+			BindDN:              getEnv("LDAP_BIND_DN", ""),
+			BindPassword:        getEnv("LDAP_BIND_PASSWORD", ""),
+			BaseDN:              getEnv("LDAP_BASE_DN", ""),
+			UserFilter:          getEnv("LDAP_USER_FILTER", "(&(objectClass=person)(|(uid={username})(mail={username})))"),
+			SyncFilter:          getEnv("LDAP_SYNC_FILTER", "(objectClass=person)"),
+			UsernameAttr:        getEnv("LDAP_USERNAME_ATTR", "uid"),
+			EmailAttr:           getEnv("LDAP_EMAIL_ATTR", "mail"),
+			NameAttr:            getEnv("LDAP_NAME_ATTR", "cn"),
+			GroupAttr:           getEnv("LDAP_GROUP_ATTR", "memberOf"),
+			AdminGroups:         splitList(getEnv("LDAP_ADMIN_GROUPS", "")),
+			TeamGroups:          getEnv("LDAP_TEAM_GROUPS", ""),
+			SyncIntervalMinutes: getEnvAsInt("LDAP_SYNC_INTERVAL_MINUTES", 60),
+		},
+		Task: TaskConfig{
+			BlockedMovePolicy: getEnv("TASK_BLOCKED_MOVE_POLICY", BlockedMoveReject),
+		},
+		Notification: NotificationConfig{
+			URL: strings.TrimRight(getEnv("NOTIFICATION_URL", "http://localhost:8080"), "/"),
+		},
+	}
+}
+
+// Validate 检查配置能否安全启动
+func (c *Config) Validate() error {
+	switch c.JWT.Algorithm {
+	case JWTAlgorithmHS256, JWTAlgorithmRS256, JWTAlgorithmEdDSA:
+	default:
+		return fmt.Errorf("不支持的JWT签名算法: %s", c.JWT.Algorithm)
+	}
+	if c.Server.Mode == "release" && c.JWT.Secret == DefaultJWTSecret {
+		return fmt.Errorf("release 模式下必须通过 JWT_SECRET 设置自己的密钥")
+	}
+	if _, err := time.LoadLocation(c.Server.Timezone); err != nil {
+		return fmt.Errorf("无效的 SERVER_TIMEZONE: %s", c.Server.Timezone)
+	}
+	if _, err := time.LoadLocation(c.DB.Timezone); err != nil {
+		return fmt.Errorf("无效的 DB_TIMEZONE: %s", c.DB.Timezone)
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return fmt.Errorf("无效的 SERVER_TRUSTED_PROXIES: %s", proxy)
+		}
+	}
+	if c.Auth.ImpersonationMinutes <= 0 {
+		return fmt.Errorf("AUTH_IMPERSONATION_MINUTES 必须大于0")
+	}
+	if c.Task.BlockedMovePolicy != BlockedMoveReject && c.Task.BlockedMovePolicy != BlockedMoveWarn {
+		return fmt.Errorf("TASK_BLOCKED_MOVE_POLICY 只能是 reject 或 warn")
+	}
+	for _, p := range c.OIDC.Providers {
+		if p.Name == "ldap" {
+			return fmt.Errorf("OIDC 提供方不能命名为 ldap")
+		}
+		if p.Issuer == "" || p.ClientID == "" {
+			return fmt.Errorf("OIDC 提供方 %s 缺少 ISSUER 或 CLIENT_ID", p.Name)
+		}
+		if _, err := ParseTeamGroups(p.TeamGroups); err != nil {
+			return fmt.Errorf("OIDC 提供方 %s: %v", p.Name, err)
+		}
+	}
+	if c.LDAP.URL != "" {
+		if c.LDAP.BaseDN == "" {
+			return fmt.Errorf("启用 LDAP 时必须设置 LDAP_BASE_DN")
+		}
+		if !strings.Contains(c.LDAP.UserFilter, "{username}") {
+			return fmt.Errorf("LDAP_USER_FILTER 必须包含 {username} 占位符")
+		}
+		if _, err := ParseTeamGroups(c.LDAP.TeamGroups); err != nil {
+			return fmt.Errorf("LDAP: %v", err)
+		}
+	}
+	return nil
+}
+
+// OIDCConfig OpenID Connect 单点登录配置
+type OIDCConfig struct {
+	RedirectBaseURL string // 后端对外访问地址，回调地址为 {RedirectBaseURL}/api/auth/oidc/{name}/callback
+	Providers       []OIDCProviderConfig
+}
+
+// OIDCProviderConfig 单个身份提供方配置，环境变量前缀为 OIDC_{NAME}_
+type OIDCProviderConfig struct {
+	Name         string   // 提供方标识，用于URL
+	DisplayName  string   // 登录页显示名称
+	Issuer       string   // 颁发者地址，通过 {Issuer}/.well-known/openid-configuration 发现端点
+	ClientID     string   // 客户端ID
+	ClientSecret string   // 客户端密钥，公共客户端可为空（仅使用PKCE）
+	Scopes       []string // 请求的权限范围
+	GroupsClaim  string   // ID令牌或用户信息中的用户组字段
+	AdminGroups  []string // 属于这些组的用户为系统管理员；为空时不同步系统角色
+	TeamGroups   string   // 用户组到团队的映射，如 "engineering=1,eng-leads=1:admin"
+	AllowSignup  bool     // 邮箱没有对应用户时是否自动创建账号
+}
+
+// LDAPConfig LDAP 目录认证与用户同步配置，URL 为空时不启用
+type LDAPConfig struct {
+	URL                 string // 如 ldap://ldap.example.com:389 或 ldaps://ldap.example.com:636
+	StartTLS            bool   // ldap:// 连接建立后升级为TLS
+	InsecureSkipVerify  bool   // 跳过服务器证书校验，仅用于测试环境
+	BindDN              string // 查找和同步用户使用的服务账号，为空时匿名绑定
+	BindPassword        string
+	BaseDN              string   // 用户搜索根
+	UserFilter          string   // 登录时查找用户的过滤器，{username} 替换为转义后的登录名
+	SyncFilter          string   // 同步时搜索全部用户的过滤器
+	UsernameAttr        string   // 用户名属性，同时作为外部身份的唯一标识
+	EmailAttr           string   // 邮箱属性
+	NameAttr            string   // 昵称属性
+	GroupAttr           string   // 用户所属组属性（值为组DN）
+	AdminGroups         []string // 属于这些组（组DN或CN）的用户为系统管理员；为空时不同步系统角色
+	TeamGroups          string   // 组到团队的映射，格式同 OIDC
+	SyncIntervalMinutes int      // 用户同步周期（分钟），0 表示不自动同步
+}
+
+// Enabled 是否启用 LDAP
+func (c LDAPConfig) Enabled() bool {
+	return c.URL != ""
+}
+
+// TeamGroupMapping 用户组到团队成员身份的映射，OIDC 和 LDAP 共用
+type TeamGroupMapping struct {
+	Group  string
+	TeamID uint
+	Admin  bool // 为 true 时以团队管理员身份加入
+}
+
+// ParseTeamGroups 解析用户组到团队的映射配置
+func ParseTeamGroups(raw string) ([]TeamGroupMapping, error) {
+	var mappings []TeamGroupMapping
+	for _, item := range splitList(raw) {
+		group, target, ok := strings.Cut(item, "=")
+		if !ok || strings.TrimSpace(group) == "" {
+			return nil, fmt.Errorf("无效的用户组映射: %s", item)
+		}
+		teamPart, role, _ := strings.Cut(strings.TrimSpace(target), ":")
+		teamID, err := strconv.ParseUint(teamPart, 10, 32)
+		if err != nil || teamID == 0 {
+			return nil, fmt.Errorf("无效的用户组映射: %s", item)
+		}
+		if role != "" && role != "admin" && role != "member" {
+			return nil, fmt.Errorf("无效的团队角色: %s", item)
+		}
+		mappings = append(mappings, TeamGroupMapping{
+			Group:  strings.TrimSpace(group),
+			TeamID: uint(teamID),
+			Admin:  role == "admin",
+		})
+	}
+	return mappings, nil
+}
+
+func loadOIDCConfig() OIDCConfig {
+	cfg := OIDCConfig{
+		RedirectBaseURL: strings.TrimRight(getEnv("OIDC_REDIRECT_BASE_URL", "http://localhost:8080"), "/"),
+	}
+	for _, name := range splitList(getEnv("OIDC_PROVIDERS", "")) {
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		cfg.Providers = append(cfg.Providers, OIDCProviderConfig{
+			Name:         name,
+			DisplayName:  getEnv(prefix+"DISPLAY_NAME", name),
+			Issuer:       strings.TrimRight(getEnv(prefix+"ISSUER", ""), "/"),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			Scopes:       strings.Fields(getEnv(prefix+"SCOPES", "openid email profile")),
+			GroupsClaim:  getEnv(prefix+"GROUPS_CLAIM", "groups"),
+			AdminGroups:  splitList(getEnv(prefix+"ADMIN_GROUPS", "")),
+			TeamGroups:   getEnv(prefix+"TEAM_GROUPS", ""),
+			AllowSignup:  getEnvAsBool(prefix+"ALLOW_SIGNUP", true),
+		})
+	}
+	return cfg
+}
+
+// splitList 按逗号拆分配置项并去除空白
+func splitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
 
 func getEnv(key, defaultValue string) string {
@@ -83,3 +378,12 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"fmt"
 	"log"
+	"net/url"
 
 	"progress-wall-backend/config"
 
@@ -20,12 +21,14 @@ func InitDB(cfg *config.Config) (*gorm.DB, error) {
 	// 配置GORM日志
 	dbConfig := &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
+		// 将唯一索引冲突等数据库错误转换为 gorm.ErrDuplicatedKey 等通用错误
+		TranslateError: true,
 	}
 
 	switch cfg.DB.Type {
 	case "mysql":
-		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-			cfg.DB.User, cfg.DB.Password, cfg.DB.Host, cfg.DB.Port, cfg.DB.Name)
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=%s",
+			cfg.DB.User, cfg.DB.Password, cfg.DB.Host, cfg.DB.Port, cfg.DB.Name, url.QueryEscape(cfg.DB.Timezone))
 		db, err = gorm.Open(mysql.Open(dsn), dbConfig)
 		if err != nil {
 			return nil, fmt.Errorf("MySQL数据库连接失败: %w", err)
@@ -1,7 +1,9 @@
 package database
 
 import (
+	"fmt"
 	"log"
+	"time"
 
 	"progress-wall-backend/models"
 
@@ -12,10 +14,20 @@ import (
 func Migrate(db *gorm.DB) error {
 	log.Println("开始执行数据库迁移...")
 
+	// 需在 AutoMigrate 创建唯一索引之前为已有数据分配项目 key 和任务编号
+	if err := migrateTaskNumbers(db); err != nil {
+		log.Printf("迁移任务编号失败: %v", err)
+		return err
+	}
+
+	// 升级前的列没有 is_done，需在 AutoMigrate 添加该列后回填
+	backfillDoneColumns := db.Migrator().HasTable(&models.Column{}) && !db.Migrator().HasColumn(&models.Column{}, "is_done")
+
 	// 迁移所有模型
 	err := db.AutoMigrate(
 		// 用户相关
 		&models.User{},
+		&models.UserPreferences{},
 
 		// 团队相关
 		&models.Team{},
@@ -33,15 +45,159 @@ func Migrate(db *gorm.DB) error {
 		&models.Attachment{},
 		&models.Label{},
 		&models.TaskLabel{},
+		&models.ChecklistItem{},
+		&models.TaskLink{},
+		&models.TaskAssignee{},
+		&models.TaskWatcher{},
+		&models.CustomField{},
+		&models.CustomFieldValue{},
 
 		// 活动日志
 		&models.ActivityLog{},
+
+		// 邀请
+		&models.Invitation{},
+
+		// 登录会话
+		&models.Session{},
+		&models.UserToken{},
+		&models.MFARecoveryCode{},
+		&models.LoginThrottle{},
+		&models.AuditLog{},
+		&models.PersonalAccessToken{},
+		&models.SigningKey{},
+		&models.UserIdentity{},
+		&models.OIDCLoginState{},
 	)
 	if err != nil {
 		log.Printf("数据库迁移失败: %v", err)
 		return err
 	}
 
+	if err := migrateTaskAssignees(db); err != nil {
+		log.Printf("迁移任务负责人失败: %v", err)
+		return err
+	}
+
+	if backfillDoneColumns {
+		if err := migrateDoneColumns(db); err != nil {
+			log.Printf("迁移完成列失败: %v", err)
+			return err
+		}
+	}
+
 	log.Println("数据库迁移完成")
 	return nil
 }
+
+// migrateTaskAssignees 将旧版 tasks.assignee_id 中的负责人写入 task_assignees，然后删除该列
+func migrateTaskAssignees(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasColumn(&models.Task{}, "assignee_id") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec(`INSERT INTO task_assignees (task_id, user_id, created_at)
+			SELECT id, assignee_id, ? FROM tasks
+			WHERE assignee_id IS NOT NULL AND NOT EXISTS (
+				SELECT 1 FROM task_assignees WHERE task_assignees.task_id = tasks.id AND task_assignees.user_id = tasks.assignee_id
+			)`, time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		log.Printf("已迁移 %d 条任务负责人记录", result.RowsAffected)
+
+		// 旧版由 User.AssignedTasks 或 Task.Assignee 生成的外键
+		migrator := tx.Migrator()
+		for _, constraint := range []string{"fk_users_assigned_tasks", "fk_tasks_assignee"} {
+			if migrator.HasConstraint(&models.Task{}, constraint) {
+				if err := migrator.DropConstraint(&models.Task{}, constraint); err != nil {
+					return err
+				}
+			}
+		}
+		if migrator.HasIndex(&models.Task{}, "idx_tasks_assignee_id") {
+			if err := migrator.DropIndex(&models.Task{}, "idx_tasks_assignee_id"); err != nil {
+				return err
+			}
+		}
+		return migrator.DropColumn(&models.Task{}, "assignee_id")
+	})
+}
+
+// migrateDoneColumns 将已有看板的最后一列标记为完成列，与新建看板的默认列一致
+func migrateDoneColumns(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var boardIDs []uint
+		if err := tx.Model(&models.Column{}).Distinct().Pluck("board_id", &boardIDs).Error; err != nil {
+			return err
+		}
+		for _, boardID := range boardIDs {
+			var column models.Column
+			if err := tx.Where("board_id = ?", boardID).Order("position DESC, id DESC").
+				First(&column).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&column).UpdateColumn("is_done", true).Error; err != nil {
+				return err
+			}
+		}
+		log.Printf("已为 %d 个看板设置完成列", len(boardIDs))
+		return nil
+	})
+}
+
+// migrateTaskNumbers 为升级前创建的项目生成 key，并按创建顺序为项目内的任务（含已删除的）编号
+func migrateTaskNumbers(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&models.Project{}) || migrator.HasColumn(&models.Project{}, "project_key") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		for _, field := range []string{"Key", "TaskSeq"} {
+			if err := migrator.AddColumn(&models.Project{}, field); err != nil {
+				return err
+			}
+		}
+		if migrator.HasTable(&models.Task{}) && !migrator.HasColumn(&models.Task{}, "number") {
+			if err := migrator.AddColumn(&models.Task{}, "Number"); err != nil {
+				return err
+			}
+		}
+
+		var projects []models.Project
+		if err := tx.Unscoped().Select("id", "name").Order("id ASC").Find(&projects).Error; err != nil {
+			return err
+		}
+		used := make(map[string]bool, len(projects))
+		for _, project := range projects {
+			base := models.DefaultProjectKey(project.Name)
+			key := base
+			for n := 2; used[key]; n++ {
+				key = fmt.Sprintf("%s%d", base, n)
+			}
+			used[key] = true
+
+			var taskIDs []uint
+			if err := tx.Unscoped().Model(&models.Task{}).Where("project_id = ?", project.ID).
+				Order("id ASC").Pluck("id", &taskIDs).Error; err != nil {
+				return err
+			}
+			for i, taskID := range taskIDs {
+				if err := tx.Unscoped().Model(&models.Task{}).Where("id = ?", taskID).
+					UpdateColumn("number", i+1).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Unscoped().Model(&models.Project{}).Where("id = ?", project.ID).
+				UpdateColumns(map[string]interface{}{"project_key": key, "task_seq": len(taskIDs)}).Error; err != nil {
+				return err
+			}
+		}
+		log.Printf("已为 %d 个项目生成项目 key", len(projects))
+		return nil
+	})
+}
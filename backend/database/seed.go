@@ -9,6 +9,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// defaultAdminPassword 默认管理员初始密码
+const defaultAdminPassword = "admin123"
+
 // Seed 初始化基础数据
 func Seed(db *gorm.DB) error {
 	log.Println("开始初始化基础数据...")
@@ -24,23 +27,31 @@ func Seed(db *gorm.DB) error {
 
 // createAdminUser 创建默认管理员用户
 // 该函数负责在系统初始化时创建默认的管理员用户（username: admin, password: admin123）
-// 如果管理员用户已存在，则跳过创建
+// 默认密码是公开的，管理员首次登录后必须修改密码
+// 如果管理员用户已存在，则跳过创建；若其仍在使用默认密码，则标记为需要修改密码
 // 参数: db - 数据库连接实例
 // 返回: error - 如果创建失败则返回错误，否则返回nil
 func createAdminUser(db *gorm.DB) error {
 	// 检查admin用户是否已存在
-	var count int64
-	if err := db.Model(&models.User{}).Where("username = ?", "admin").Count(&count).Error; err != nil {
-		return fmt.Errorf("检查admin用户是否存在时出错: %w", err)
+	var existing models.User
+	result := db.Where("username = ?", "admin").Limit(1).Find(&existing)
+	if result.Error != nil {
+		return fmt.Errorf("检查admin用户是否存在时出错: %w", result.Error)
 	}
-	if count > 0 {
+	if result.RowsAffected > 0 {
+		if !existing.MustChangePassword && utils.CheckPasswordHash(defaultAdminPassword, existing.Password) {
+			if err := db.Model(&existing).Update("must_change_password", true).Error; err != nil {
+				return fmt.Errorf("标记admin用户修改密码失败: %w", err)
+			}
+			log.Println("admin用户仍在使用默认密码，已要求其登录后修改密码")
+		}
 		log.Println("admin用户已存在，跳过创建")
 		return nil
 	}
 
 	log.Println("未找到admin用户，正在创建...")
 	// 加密管理员密码
-	adminPasswordHash, err := utils.HashPassword("admin123")
+	adminPasswordHash, err := utils.HashPassword(defaultAdminPassword)
 	if err != nil {
 		return fmt.Errorf("加密管理员密码失败: %w", err)
 	}
@@ -49,10 +60,12 @@ func createAdminUser(db *gorm.DB) error {
 	adminUser := models.User{
 		Username: "admin",
 		Email:    "admin@example.com",
+		EmailVerified: true,
 		Password: adminPasswordHash,
 		Nickname: "系统管理员",
 		Status:   models.UserStatusEnabled,
 		SystemRole: models.SystemRoleAdmin,
+		MustChangePassword: true,
 	}
 
 	if err := db.Create(&adminUser).Error; err != nil {
@@ -0,0 +1,14 @@
+package dto
+
+import "time"
+
+// AccessTokenResponse 个人访问令牌信息（不含令牌明文）
+type AccessTokenResponse struct {
+	ID          uint       `json:"id"`
+	Name        string     `json:"name"`
+	TokenPrefix string     `json:"token_prefix"`
+	Scopes      []string   `json:"scopes"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
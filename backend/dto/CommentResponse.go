@@ -0,0 +1,28 @@
+package dto
+
+import "time"
+
+// CommentResponse 评论响应（包含嵌套回复）
+type CommentResponse struct {
+	ID        uint              `json:"id"`
+	TaskID    uint              `json:"task_id"`
+	ParentID  *uint             `json:"parent_id"`
+	UserID    uint              `json:"user_id"`
+	Username  string            `json:"username"`
+	Nickname  string            `json:"nickname"`
+	Avatar    string            `json:"avatar"`
+	Content   string            `json:"content"`
+	Status    int               `json:"status"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Replies   []CommentResponse `json:"replies"`
+}
+
+// CommentListResponse 评论列表响应
+type CommentListResponse struct {
+	Data       []CommentResponse `json:"data"`
+	Total      int64             `json:"total"`
+	Page       int               `json:"page"`
+	PageSize   int               `json:"page_size"`
+	TotalPages int               `json:"total_pages"`
+}
@@ -0,0 +1,16 @@
+package dto
+
+import "time"
+
+// SessionResponse 登录会话（设备）信息
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+	// Impersonated 为true表示该会话是管理员模拟登录
+	Impersonated bool `json:"impersonated,omitempty"`
+}
@@ -0,0 +1,16 @@
+package dto
+
+import "time"
+
+// TaskLinkResponse 从当前任务角度描述的任务关联
+type TaskLinkResponse struct {
+	ID         uint      `json:"id"`
+	Type       string    `json:"type"` // blocks、blocked_by、relates_to、duplicates 或 duplicated_by
+	TaskID     uint      `json:"task_id"`
+	TaskKey    string    `json:"task_key"`
+	TaskTitle  string    `json:"task_title"`
+	TaskStatus int       `json:"task_status"`
+	ColumnID   uint      `json:"column_id"`
+	Open       bool      `json:"open"` // 关联任务未完成（状态未关闭且不在完成列）
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,66 @@
+package admin
+
+import (
+	"math"
+	"net/http"
+
+	"progress-wall-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AuditHandler 审计日志处理器
+type AuditHandler struct {
+	auditService *services.AuditService
+}
+
+// NewAuditHandler 创建审计日志处理器
+func NewAuditHandler(db *gorm.DB) *AuditHandler {
+	return &AuditHandler{
+		auditService: services.NewAuditService(db),
+	}
+}
+
+// auditLogQuery 审计日志查询参数
+type auditLogQuery struct {
+	Action   string `form:"action"`
+	UserID   uint   `form:"user_id"`
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PageSize int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// GetAuditLogs 分页获取审计日志
+// GET /api/admin/audit-logs
+func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
+	var query auditLogQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的查询参数"})
+		return
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = 20
+	}
+
+	logs, total, err := h.auditService.ListAuditLogs(services.AuditLogQuery{
+		Action:       query.Action,
+		TargetUserID: query.UserID,
+		Page:         query.Page,
+		PageSize:     query.PageSize,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        logs,
+		"total":       total,
+		"page":        query.Page,
+		"page_size":   query.PageSize,
+		"total_pages": int(math.Ceil(float64(total) / float64(query.PageSize))),
+	})
+}
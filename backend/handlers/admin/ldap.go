@@ -0,0 +1,40 @@
+package admin
+
+import (
+	"net/http"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// LDAPHandler LDAP 目录管理处理器
+type LDAPHandler struct {
+	ldapService *services.LDAPService
+}
+
+// NewLDAPHandler 创建目录管理处理器
+func NewLDAPHandler(db *gorm.DB, cfg *config.Config) *LDAPHandler {
+	return &LDAPHandler{
+		ldapService: services.NewLDAPService(db, cfg),
+	}
+}
+
+// SyncUsers 立即从目录同步用户，不必等待定时同步
+// POST /api/admin/ldap/sync
+func (h *LDAPHandler) SyncUsers(c *gin.Context) {
+	result, err := h.ldapService.Sync()
+	if err != nil {
+		switch err {
+		case services.ErrLDAPDisabled:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
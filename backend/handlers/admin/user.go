@@ -0,0 +1,255 @@
+package admin
+
+import (
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/mailer"
+	"progress-wall-backend/models"
+	"progress-wall-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// UserHandler 系统管理员用户管理处理器
+type UserHandler struct {
+	throttleService  *services.LoginThrottleService
+	adminUserService *services.AdminUserService
+}
+
+// NewUserHandler 创建用户管理处理器
+func NewUserHandler(db *gorm.DB, mail mailer.Mailer, cfg *config.Config) *UserHandler {
+	return &UserHandler{
+		throttleService:  services.NewLoginThrottleService(db, cfg),
+		adminUserService: services.NewAdminUserService(db, mail, cfg),
+	}
+}
+
+// userQuery 用户列表查询参数
+type userQuery struct {
+	Search     string            `form:"search"`
+	Status     models.UserStatus `form:"status" binding:"omitempty,min=1,max=3"`
+	SystemRole models.SystemRole `form:"system_role" binding:"omitempty,min=1,max=2"`
+	Page       int               `form:"page" binding:"omitempty,min=1"`
+	PageSize   int               `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// GetUsers 分页获取用户列表，支持按用户名、邮箱、昵称搜索及按状态、系统角色筛选
+// GET /api/admin/users
+func (h *UserHandler) GetUsers(c *gin.Context) {
+	var query userQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的查询参数"})
+		return
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = 20
+	}
+
+	users, total, err := h.adminUserService.ListUsers(services.AdminUserQuery{
+		Search:     query.Search,
+		Status:     query.Status,
+		SystemRole: query.SystemRole,
+		Page:       query.Page,
+		PageSize:   query.PageSize,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        users,
+		"total":       total,
+		"page":        query.Page,
+		"page_size":   query.PageSize,
+		"total_pages": int(math.Ceil(float64(total) / float64(query.PageSize))),
+	})
+}
+
+// GetUser 获取单个用户
+// GET /api/admin/users/:userId
+func (h *UserHandler) GetUser(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.adminUserService.GetUser(userID)
+	if err != nil {
+		handleAdminUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateUserStatus 启用或禁用用户
+// PUT /api/admin/users/:userId/status
+func (h *UserHandler) UpdateUserStatus(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Status models.UserStatus `json:"status" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	user, err := h.adminUserService.SetStatus(userID, req.Status, c.GetUint("user_id"), clientInfo(c))
+	if err != nil {
+		handleAdminUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateUserRole 设置用户的系统角色
+// PUT /api/admin/users/:userId/role
+func (h *UserHandler) UpdateUserRole(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		SystemRole models.SystemRole `json:"system_role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	user, err := h.adminUserService.SetSystemRole(userID, req.SystemRole, c.GetUint("user_id"), clientInfo(c))
+	if err != nil {
+		handleAdminUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ResetUserPassword 强制用户重置密码
+// POST /api/admin/users/:userId/password-reset
+func (h *UserHandler) ResetUserPassword(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	if _, err := h.adminUserService.ForcePasswordReset(userID, c.GetUint("user_id"), clientInfo(c)); err != nil {
+		handleAdminUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已要求用户重置密码，找回密码邮件已发送"})
+}
+
+// DeleteUser 删除用户（软删除）
+// DELETE /api/admin/users/:userId
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.adminUserService.DeleteUser(userID, c.GetUint("user_id"), clientInfo(c)); err != nil {
+		handleAdminUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "用户已删除"})
+}
+
+// UnlockUser 解除用户的登录锁定
+// POST /api/admin/users/:userId/unlock
+func (h *UserHandler) UnlockUser(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.throttleService.UnlockUser(userID, c.GetUint("user_id"), clientInfo(c)); err != nil {
+		handleAdminUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已解除登录锁定"})
+}
+
+// impersonateRequest 模拟登录请求，原因写入审计日志
+type impersonateRequest struct {
+	Reason string `json:"reason" binding:"max=200"`
+}
+
+// ImpersonateUser 以指定用户身份签发有时限的访问令牌，用于排查用户看到的页面
+// 期间写入的活动日志都会记录实际操作的管理员
+// POST /api/admin/users/:userId/impersonate
+func (h *UserHandler) ImpersonateUser(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var req impersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	result, err := h.adminUserService.Impersonate(userID, c.GetUint("user_id"), req.Reason, clientInfo(c))
+	if err != nil {
+		handleAdminUserError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"accessToken": result.AccessToken,
+		"expiresIn":   result.ExpiresIn,
+		"user":        result.User,
+	})
+}
+
+// parseUserID 解析路径中的用户ID
+func parseUserID(c *gin.Context) (uint, bool) {
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return 0, false
+	}
+	return uint(userID), true
+}
+
+// clientInfo 获取写入审计日志的客户端信息
+func clientInfo(c *gin.Context) services.ClientInfo {
+	return services.ClientInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
+
+// handleAdminUserError 将用户管理相关的业务错误映射为HTTP状态码
+func handleAdminUserError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrInvalidUserStatus, services.ErrInvalidSystemRole:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case services.ErrModifySelf, services.ErrLastSysAdmin, services.ErrUserDisabled:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case services.ErrImpersonateAdmin:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,161 @@
+package attachment
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"progress-wall-backend/middleware"
+	"progress-wall-backend/models"
+	"progress-wall-backend/services"
+	"progress-wall-backend/storage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// multipartOverhead 为 multipart 表单边界和字段预留的额外字节数
+const multipartOverhead = 1 << 20
+
+// AttachmentHandler 任务附件处理器
+type AttachmentHandler struct {
+	attachmentService *services.AttachmentService
+}
+
+// NewAttachmentHandler 创建附件处理器
+func NewAttachmentHandler(db *gorm.DB, store storage.Storage, maxUploadSize int64) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentService: services.NewAttachmentService(db, store, maxUploadSize),
+	}
+}
+
+// GetAttachments 获取任务附件列表
+// GET /api/tasks/:taskId/attachments
+func (h *AttachmentHandler) GetAttachments(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+
+	attachments, err := h.attachmentService.ListAttachments(uint(taskID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"attachments": attachments})
+}
+
+// UploadAttachment 上传附件（multipart/form-data，字段名 file）
+// POST /api/tasks/:taskId/attachments
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	if !middleware.CheckScope(c, models.ScopeTasksWrite) {
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+
+	maxSize := h.attachmentService.MaxUploadSize()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+multipartOverhead)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("文件大小不能超过%dMB", maxSize/1024/1024)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请选择要上传的文件"})
+		return
+	}
+
+	attachment, err := h.attachmentService.UploadAttachment(uint(taskID), middleware.CurrentActor(c), file)
+	if err != nil {
+		switch err {
+		case services.ErrFileTooLarge:
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("文件大小不能超过%dMB", maxSize/1024/1024)})
+		case services.ErrEmptyFile:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case services.ErrTaskNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// DownloadAttachment 下载附件
+// GET /api/tasks/:taskId/attachments/:attachmentId/download
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	taskID, attachmentID, ok := parseIDs(c)
+	if !ok {
+		return
+	}
+
+	attachment, reader, err := h.attachmentService.OpenAttachment(taskID, attachmentID)
+	if err != nil {
+		if err == services.ErrAttachmentNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.OriginalName}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, attachment.FileSize, attachment.MimeType, reader, nil)
+}
+
+// DeleteAttachment 删除附件
+// DELETE /api/tasks/:taskId/attachments/:attachmentId
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	if !middleware.CheckScope(c, models.ScopeTasksWrite) {
+		return
+	}
+
+	taskID, attachmentID, ok := parseIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.attachmentService.DeleteAttachment(taskID, attachmentID, middleware.CurrentActor(c)); err != nil {
+		switch err {
+		case services.ErrAttachmentNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case services.ErrAccessDenied:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// parseIDs 解析路径中的任务ID和附件ID
+func parseIDs(c *gin.Context) (uint, uint, bool) {
+	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return 0, 0, false
+	}
+	attachmentID, err := strconv.ParseUint(c.Param("attachmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的附件ID"})
+		return 0, 0, false
+	}
+	return uint(taskID), uint(attachmentID), true
+}
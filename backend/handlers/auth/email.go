@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"net/http"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/mailer"
+	"progress-wall-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// EmailHandler 邮箱验证与修改邮箱处理器
+type EmailHandler struct {
+	emailService *services.EmailService
+}
+
+// NewEmailHandler 创建邮箱处理器
+func NewEmailHandler(db *gorm.DB, mail mailer.Mailer, cfg *config.Config) *EmailHandler {
+	return &EmailHandler{
+		emailService: services.NewEmailService(db, mail, cfg),
+	}
+}
+
+// ChangeEmailRequest 修改邮箱请求结构
+type ChangeEmailRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// ConfirmEmail 确认邮箱（注册验证或修改邮箱）
+// POST /api/auth/email/confirm
+func (h *EmailHandler) ConfirmEmail(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	if err := h.emailService.ConfirmEmail(req.Token); err != nil {
+		switch err {
+		case services.ErrInvalidEmailToken:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case services.ErrEmailTaken:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "邮箱验证成功"})
+}
+
+// ResendVerification 重新发送邮箱验证邮件
+// POST /api/auth/email/resend
+func (h *EmailHandler) ResendVerification(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	if err := h.emailService.ResendVerification(req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "如果该邮箱已注册且未验证，我们已重新发送验证邮件"})
+}
+
+// ChangeEmail 申请修改当前用户邮箱，新邮箱确认后生效
+// PUT /api/user/email
+func (h *EmailHandler) ChangeEmail(c *gin.Context) {
+	var req ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	if err := h.emailService.RequestEmailChange(c.GetUint("user_id"), req.Password, req.Email); err != nil {
+		switch err {
+		case services.ErrWrongPassword, services.ErrSameEmail, services.ErrInvalidEmail:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case services.ErrEmailTaken:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case services.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "确认邮件已发送至新邮箱，确认后修改生效"})
+}
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"net/http"
+
+	"progress-wall-backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSHandler 公布JWT验证公钥，供其他内部服务验证本服务签发的令牌
+type JWKSHandler struct{}
+
+// NewJWKSHandler 创建JWKS处理器
+func NewJWKSHandler() *JWKSHandler {
+	return &JWKSHandler{}
+}
+
+// GetJWKS 返回当前所有有效的验证公钥（HS256 模式下为空）
+// GET /.well-known/jwks.json
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	keys := utils.VerificationKeys()
+	jwks := make([]utils.JWK, 0, len(keys))
+	for _, key := range keys {
+		jwks = append(jwks, utils.PublicJWK(key))
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{"keys": jwks})
+}
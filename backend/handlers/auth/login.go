@@ -1,8 +1,13 @@
 package auth
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
+
 	"progress-wall-backend/config"
+	"progress-wall-backend/mailer"
 	"progress-wall-backend/models"
 	"progress-wall-backend/services"
 
@@ -16,9 +21,9 @@ type LoginHandler struct {
 }
 
 // NewLoginHandler 创建登录处理器
-func NewLoginHandler(db *gorm.DB, cfg *config.Config) *LoginHandler {
+func NewLoginHandler(db *gorm.DB, cfg *config.Config, mail mailer.Mailer) *LoginHandler {
 	return &LoginHandler{
-		authService: services.NewAuthService(db, cfg),
+		authService: services.NewAuthService(db, cfg, mail),
 	}
 }
 
@@ -29,9 +34,20 @@ type LoginRequest struct {
 }
 
 // LoginResponse 登录响应结构
+// 启用两步验证的用户只返回 mfaRequired 和 mfaToken，需调用 /api/auth/mfa/verify 完成登录
 type LoginResponse struct {
-	AccessToken string       `json:"accessToken"`
-	User        *models.User `json:"user,omitempty"`
+	AccessToken  string       `json:"accessToken,omitempty"`
+	RefreshToken string       `json:"refreshToken,omitempty"`
+	ExpiresIn    int          `json:"expiresIn,omitempty"`
+	User         *models.User `json:"user,omitempty"`
+	MFARequired  bool         `json:"mfaRequired,omitempty"`
+	MFAToken     string       `json:"mfaToken,omitempty"`
+}
+
+// MFAVerifyRequest 两步验证请求结构
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfaToken" binding:"required"`
+	Code     string `json:"code" binding:"required"`
 }
 
 // Login 处理登录请求
@@ -46,15 +62,23 @@ func (h *LoginHandler) Login(c *gin.Context) {
 	result, err := h.authService.Login(services.LoginRequest{
 		Username: req.Username,
 		Password: req.Password,
+		Client:   clientInfo(c),
 	})
 
 	if err != nil {
+		if respondLocked(c, err) {
+			return
+		}
 		// 根据错误类型返回相应的HTTP状态码
 		switch err {
 		case services.ErrInvalidCredentials:
 			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		case services.ErrUserDisabled:
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case services.ErrEmailNotVerified:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "EMAIL_NOT_VERIFIED"})
+		case services.ErrLDAPUnavailable:
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		}
@@ -62,8 +86,62 @@ func (h *LoginHandler) Login(c *gin.Context) {
 	}
 
 	// 返回成功响应
-	c.JSON(http.StatusOK, LoginResponse{
-		AccessToken: result.AccessToken,
-		User:        result.User,
+	c.JSON(http.StatusOK, newLoginResponse(result))
+}
+
+// VerifyMFA 两步验证第二步，提交验证码或恢复码完成登录
+// POST /api/auth/mfa/verify
+func (h *LoginHandler) VerifyMFA(c *gin.Context) {
+	var req MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	result, err := h.authService.VerifyMFA(req.MFAToken, req.Code, clientInfo(c))
+	if err != nil {
+		if respondLocked(c, err) {
+			return
+		}
+		switch err {
+		case services.ErrInvalidMFAToken, services.ErrInvalidMFACode:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case services.ErrUserDisabled:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, newLoginResponse(result))
+}
+
+// respondLocked 登录被临时锁定时返回429和 Retry-After 头
+// 用户名是否存在都返回同样的响应
+func respondLocked(c *gin.Context, err error) bool {
+	var locked *services.LoginLockedError
+	if !errors.As(err, &locked) {
+		return false
+	}
+	retryAfter := int(math.Ceil(locked.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       locked.Error(),
+		"code":        "LOGIN_LOCKED",
+		"retry_after": retryAfter,
 	})
+	return true
+}
+
+// newLoginResponse 将登录结果转换为响应结构
+func newLoginResponse(result *services.LoginResult) LoginResponse {
+	return LoginResponse{
+		AccessToken:  result.AccessToken,
+		RefreshToken: result.RefreshToken,
+		ExpiresIn:    result.ExpiresIn,
+		User:         result.User,
+		MFARequired:  result.MFARequired,
+		MFAToken:     result.MFAToken,
+	}
 }
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"net/http"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// MFAHandler 两步验证设置处理器
+type MFAHandler struct {
+	mfaService *services.MFAService
+}
+
+// NewMFAHandler 创建两步验证设置处理器
+func NewMFAHandler(db *gorm.DB, cfg *config.Config) *MFAHandler {
+	return &MFAHandler{
+		mfaService: services.NewMFAService(db, cfg),
+	}
+}
+
+// MFACodeRequest 验证码请求结构
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// MFADisableRequest 关闭两步验证请求结构
+type MFADisableRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// Enroll 生成待确认的TOTP密钥和 otpauth URI
+// POST /api/user/mfa/enroll
+func (h *MFAHandler) Enroll(c *gin.Context) {
+	enrollment, err := h.mfaService.Enroll(c.GetUint("user_id"))
+	if err != nil {
+		handleMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// Confirm 提交验证码确认启用两步验证，返回恢复码（仅显示一次）
+// POST /api/user/mfa/confirm
+func (h *MFAHandler) Confirm(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	codes, err := h.mfaService.Confirm(c.GetUint("user_id"), req.Code)
+	if err != nil {
+		handleMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+// POST /api/user/mfa/recovery-codes
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	codes, err := h.mfaService.RegenerateRecoveryCodes(c.GetUint("user_id"), req.Code)
+	if err != nil {
+		handleMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// Disable 关闭两步验证
+// DELETE /api/user/mfa
+func (h *MFAHandler) Disable(c *gin.Context) {
+	var req MFADisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	if err := h.mfaService.Disable(c.GetUint("user_id"), req.Password, req.Code); err != nil {
+		handleMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "两步验证已关闭"})
+}
+
+// handleMFAError 将两步验证相关错误映射为HTTP状态码
+func handleMFAError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrInvalidMFACode, services.ErrWrongPassword, services.ErrMFANotEnrolled:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case services.ErrMFAAlreadyEnabled, services.ErrMFANotEnabled:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case services.ErrMFARequired:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case services.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"net/http"
+	"net/url"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/mailer"
+	"progress-wall-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	oidcStateCookie     = "pw_oidc_state"
+	oidcStateCookiePath = "/api/auth/oidc"
+)
+
+// OIDCHandler 单点登录处理器
+type OIDCHandler struct {
+	oidcService *services.OIDCService
+	cfg         *config.Config
+}
+
+// NewOIDCHandler 创建单点登录处理器
+func NewOIDCHandler(db *gorm.DB, cfg *config.Config, mail mailer.Mailer) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: services.NewOIDCService(db, cfg, mail),
+		cfg:         cfg,
+	}
+}
+
+// OIDCExchangeRequest 换取登录令牌请求结构
+type OIDCExchangeRequest struct {
+	Ticket string `json:"ticket" binding:"required"`
+}
+
+// GetProviders 获取已配置的单点登录方式，供登录页展示
+// GET /api/auth/oidc/providers
+func (h *OIDCHandler) GetProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": h.oidcService.ListProviders()})
+}
+
+// Login 发起单点登录，跳转到提供方授权页
+// GET /api/auth/oidc/:provider/login?redirect=/boards/1
+func (h *OIDCHandler) Login(c *gin.Context) {
+	authURL, state, err := h.oidcService.BeginLogin(c.Request.Context(), c.Param("provider"), c.Query("redirect"))
+	if err != nil {
+		switch err {
+		case services.ErrOIDCProviderNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case services.ErrOIDCFailed:
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	h.setStateCookie(c, state, int(services.OIDCStateTTL.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback 提供方授权回调，完成校验后带一次性凭证跳转回前端
+// GET /api/auth/oidc/:provider/callback?code=...&state=...
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	cookieState, _ := c.Cookie(oidcStateCookie)
+	h.setStateCookie(c, "", -1)
+
+	if c.Query("error") != "" {
+		h.redirectError(c, "access_denied")
+		return
+	}
+
+	ticket, redirectPath, err := h.oidcService.HandleCallback(
+		c.Request.Context(), c.Param("provider"), c.Query("code"), c.Query("state"), cookieState,
+	)
+	if err != nil {
+		switch err {
+		case services.ErrOIDCProviderNotFound, services.ErrOIDCStateInvalid:
+			h.redirectError(c, "invalid_state")
+		case services.ErrOIDCEmailUnverified:
+			h.redirectError(c, "email_unverified")
+		case services.ErrOIDCSignupDisabled:
+			h.redirectError(c, "signup_disabled")
+		case services.ErrUserDisabled:
+			h.redirectError(c, "user_disabled")
+		default:
+			h.redirectError(c, "failed")
+		}
+		return
+	}
+
+	query := url.Values{}
+	query.Set("ticket", ticket)
+	query.Set("redirect", redirectPath)
+	c.Redirect(http.StatusFound, h.cfg.Server.FrontendURL+"/oidc/callback?"+query.Encode())
+}
+
+// Exchange 使用回调中的一次性凭证换取登录令牌，响应与密码登录相同
+// POST /api/auth/oidc/exchange
+func (h *OIDCHandler) Exchange(c *gin.Context) {
+	var req OIDCExchangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	result, err := h.oidcService.ExchangeTicket(req.Ticket, clientInfo(c))
+	if err != nil {
+		switch err {
+		case services.ErrOIDCTicketInvalid:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case services.ErrUserDisabled:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, newLoginResponse(result))
+}
+
+// setStateCookie 写入或清除 state Cookie，SameSite=Lax 保证提供方跳转回来时会携带
+func (h *OIDCHandler) setStateCookie(c *gin.Context, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, value, maxAge, oidcStateCookiePath, "", h.cfg.Server.Mode == "release", true)
+}
+
+// redirectError 单点登录失败时跳转回前端登录页并带上错误原因
+func (h *OIDCHandler) redirectError(c *gin.Context, reason string) {
+	c.Redirect(http.StatusFound, h.cfg.Server.FrontendURL+"/login?sso_error="+url.QueryEscape(reason))
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"net/http"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/mailer"
+	"progress-wall-backend/services"
+	"progress-wall-backend/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PasswordHandler 修改密码与找回密码处理器
+type PasswordHandler struct {
+	passwordService *services.PasswordService
+}
+
+// NewPasswordHandler 创建密码处理器
+func NewPasswordHandler(db *gorm.DB, mail mailer.Mailer, cfg *config.Config) *PasswordHandler {
+	return &PasswordHandler{
+		passwordService: services.NewPasswordService(db, mail, cfg),
+	}
+}
+
+// ChangePasswordRequest 修改密码请求结构
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// ForgotPasswordRequest 找回密码请求结构
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest 重置密码请求结构
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// ChangePassword 修改当前用户密码，成功后其他会话全部失效并返回新的令牌
+// PUT /api/user/password
+func (h *PasswordHandler) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	if err := utils.ValidatePasswordStrength(req.NewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.passwordService.ChangePassword(c.GetUint("user_id"), req.CurrentPassword, req.NewPassword, clientInfo(c))
+	if err != nil {
+		switch err {
+		case services.ErrWrongPassword, services.ErrSamePassword, services.ErrInvalidPassword:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case services.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, RefreshResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+	})
+}
+
+// ForgotPassword 发送找回密码邮件
+// POST /api/auth/password/forgot
+func (h *PasswordHandler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	if err := h.passwordService.RequestPasswordReset(req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "如果该邮箱已注册，我们已向其发送重置密码邮件"})
+}
+
+// ResetPassword 使用邮件中的令牌重置密码
+// POST /api/auth/password/reset
+func (h *PasswordHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	if err := utils.ValidatePasswordStrength(req.NewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.passwordService.ResetPassword(req.Token, req.NewPassword); err != nil {
+		switch err {
+		case services.ErrInvalidResetToken, services.ErrInvalidPassword:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "密码已重置，请使用新密码登录"})
+}
@@ -3,6 +3,7 @@ package auth
 import (
 	"net/http"
 	"progress-wall-backend/config"
+	"progress-wall-backend/mailer"
 	"progress-wall-backend/models"
 	"progress-wall-backend/services"
 	"progress-wall-backend/utils"
@@ -17,23 +18,25 @@ type RegisterHandler struct {
 }
 
 // NewRegisterHandler 创建注册处理器
-func NewRegisterHandler(db *gorm.DB, cfg *config.Config) *RegisterHandler {
+func NewRegisterHandler(db *gorm.DB, cfg *config.Config, mail mailer.Mailer) *RegisterHandler {
 	return &RegisterHandler{
-		authService: services.NewAuthService(db, cfg),
+		authService: services.NewAuthService(db, cfg, mail),
 	}
 }
 
 // RegisterRequest 注册请求结构
 type RegisterRequest struct {
-	Username string `json:"username" binding:"required"`
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
-	Nickname string `json:"nickname"`
+	Username    string `json:"username" binding:"required"`
+	Email       string `json:"email" binding:"required,email"`
+	Password    string `json:"password" binding:"required,min=6"`
+	Nickname    string `json:"nickname"`
+	InviteToken string `json:"invite_token"`
 }
 
 // RegisterResponse 注册响应结构
 type RegisterResponse struct {
-	User *models.User `json:"user"`
+	User       *models.User       `json:"user"`
+	Invitation *models.Invitation `json:"invitation,omitempty"`
 }
 
 // Register 处理注册请求
@@ -52,10 +55,11 @@ func (h *RegisterHandler) Register(c *gin.Context) {
 
 	// 调用service层处理业务逻辑
 	result, err := h.authService.Register(services.RegisterRequest{
-		Username: req.Username,
-		Email:    req.Email,
-		Password: req.Password,
-		Nickname: req.Nickname,
+		Username:    req.Username,
+		Email:       req.Email,
+		Password:    req.Password,
+		Nickname:    req.Nickname,
+		InviteToken: req.InviteToken,
 	})
 
 	if err != nil {
@@ -63,8 +67,10 @@ func (h *RegisterHandler) Register(c *gin.Context) {
 		switch err {
 		case services.ErrUserExists:
 			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
-		case services.ErrInvalidPassword:
+		case services.ErrInvalidPassword, services.ErrInvitationInvalid, services.ErrInvitationEmail:
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case services.ErrInvitationExpired, services.ErrInvitationUsedUp:
+			c.JSON(http.StatusGone, gin.H{"error": err.Error()})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		}
@@ -73,6 +79,7 @@ func (h *RegisterHandler) Register(c *gin.Context) {
 
 	// 返回成功响应
 	c.JSON(http.StatusCreated, RegisterResponse{
-		User: result.User,
+		User:       result.User,
+		Invitation: result.Invitation,
 	})
 }
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"net/http"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TokenHandler 令牌刷新与退出登录处理器
+type TokenHandler struct {
+	sessionService *services.SessionService
+}
+
+// NewTokenHandler 创建令牌处理器
+func NewTokenHandler(db *gorm.DB, cfg *config.Config) *TokenHandler {
+	return &TokenHandler{
+		sessionService: services.NewSessionService(db, cfg),
+	}
+}
+
+// RefreshRequest 刷新/退出请求结构
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// RefreshResponse 刷新响应结构
+type RefreshResponse struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int    `json:"expiresIn"`
+}
+
+// Refresh 使用刷新令牌换取新的令牌对
+// POST /api/auth/refresh
+func (h *TokenHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	tokens, err := h.sessionService.Refresh(req.RefreshToken, clientInfo(c))
+	if err != nil {
+		switch err {
+		case services.ErrInvalidRefresh, services.ErrRefreshReused:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case services.ErrUserDisabled:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, RefreshResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+	})
+}
+
+// Logout 退出登录，吊销刷新令牌所属的会话
+// POST /api/auth/logout
+func (h *TokenHandler) Logout(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	if err := h.sessionService.RevokeByRefreshToken(req.RefreshToken); err != nil && err != services.ErrInvalidRefresh {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 令牌无效时同样视为已退出，避免泄露令牌状态
+	c.JSON(http.StatusOK, gin.H{"message": "已退出登录"})
+}
+
+// clientInfo 获取客户端IP和User-Agent
+func clientInfo(c *gin.Context) services.ClientInfo {
+	return services.ClientInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
@@ -73,6 +73,7 @@ func (h *ColumnHandler) CreateColumn(c *gin.Context) {
 		Name        string `json:"name" binding:"required"`
 		Description string `json:"description"`
 		Color       string `json:"color"`
+		IsDone      bool   `json:"is_done"`
 	}
 
 	if err := c.ShouldBindJSON(&createColumnRequest); err != nil {
@@ -86,6 +87,7 @@ func (h *ColumnHandler) CreateColumn(c *gin.Context) {
 		Color:       createColumnRequest.Color,
 		BoardID:     uint(boardID),
 		Status:      models.ColumnStatusActive,
+		IsDone:      createColumnRequest.IsDone,
 	}
 
 	if err := h.columnService.CreateColumn(column); err != nil {
@@ -110,6 +112,7 @@ func (h *ColumnHandler) UpdateColumn(c *gin.Context) {
 		Color       *string              `json:"color"`
 		Status      *models.ColumnStatus `json:"status"`
 		Position    *int                 `json:"position"`
+		IsDone      *bool                `json:"is_done"`
 	}
 
 	if err := c.ShouldBindJSON(&updateColumnRequest); err != nil {
@@ -133,6 +136,9 @@ func (h *ColumnHandler) UpdateColumn(c *gin.Context) {
 	if updateColumnRequest.Position != nil {
 		updates["position"] = *updateColumnRequest.Position
 	}
+	if updateColumnRequest.IsDone != nil {
+		updates["is_done"] = *updateColumnRequest.IsDone
+	}
 
 	if err := h.columnService.UpdateColumn(uint(columnID), updates); err != nil {
 		if err == services.ErrColumnNotFound {
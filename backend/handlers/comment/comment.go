@@ -0,0 +1,225 @@
+package comment
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/dto"
+	"progress-wall-backend/middleware"
+	"progress-wall-backend/models"
+	"progress-wall-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CommentHandler 任务评论处理器
+type CommentHandler struct {
+	commentService *services.CommentService
+}
+
+// NewCommentHandler 创建评论处理器
+func NewCommentHandler(db *gorm.DB, cfg *config.Config) *CommentHandler {
+	return &CommentHandler{
+		commentService: services.NewCommentService(db, cfg),
+	}
+}
+
+// GetComments 获取任务评论树（分页）
+// GET /api/tasks/:taskId/comments
+func (h *CommentHandler) GetComments(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+
+	var query dto.PaginationQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的分页参数"})
+		return
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = 20
+	}
+
+	comments, total, err := h.commentService.ListComments(uint(taskID), c.GetUint("user_id"), query.Page, query.PageSize)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	data := make([]dto.CommentResponse, len(comments))
+	for i, comment := range comments {
+		data[i] = convertToCommentResponse(comment)
+	}
+
+	c.JSON(http.StatusOK, dto.CommentListResponse{
+		Data:       data,
+		Total:      total,
+		Page:       query.Page,
+		PageSize:   query.PageSize,
+		TotalPages: int(math.Ceil(float64(total) / float64(query.PageSize))),
+	})
+}
+
+// CreateComment 发表评论或回复
+// POST /api/tasks/:taskId/comments
+func (h *CommentHandler) CreateComment(c *gin.Context) {
+	if !middleware.CheckScope(c, models.ScopeTasksWrite) {
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+
+	var req struct {
+		Content  string `json:"content" binding:"required,max=5000"`
+		ParentID *uint  `json:"parent_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	comment, err := h.commentService.CreateComment(uint(taskID), middleware.CurrentActor(c), req.Content, req.ParentID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, convertToCommentResponse(*comment))
+}
+
+// UpdateComment 编辑评论
+// PUT /api/tasks/:taskId/comments/:commentId
+func (h *CommentHandler) UpdateComment(c *gin.Context) {
+	if !middleware.CheckScope(c, models.ScopeTasksWrite) {
+		return
+	}
+
+	taskID, commentID, ok := parseIDs(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Content string `json:"content" binding:"required,max=5000"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	comment, err := h.commentService.UpdateComment(taskID, commentID, middleware.CurrentActor(c), req.Content)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, convertToCommentResponse(*comment))
+}
+
+// HideComment 隐藏或恢复评论
+// PATCH /api/tasks/:taskId/comments/:commentId/hide
+func (h *CommentHandler) HideComment(c *gin.Context) {
+	taskID, commentID, ok := parseIDs(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Hidden *bool `json:"hidden" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误：需要 hidden"})
+		return
+	}
+
+	if err := h.commentService.SetCommentHidden(taskID, commentID, middleware.CurrentActor(c), *req.Hidden); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "更新成功"})
+}
+
+// DeleteComment 删除评论
+// DELETE /api/tasks/:taskId/comments/:commentId
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	if !middleware.CheckScope(c, models.ScopeTasksWrite) {
+		return
+	}
+
+	taskID, commentID, ok := parseIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.commentService.DeleteComment(taskID, commentID, middleware.CurrentActor(c)); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// handleError 将业务错误映射为HTTP状态码
+func handleError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrTaskNotFound, services.ErrCommentNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrInvalidComment, services.ErrParentComment:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case services.ErrAccessDenied:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// parseIDs 解析路径中的任务ID和评论ID
+func parseIDs(c *gin.Context) (uint, uint, bool) {
+	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return 0, 0, false
+	}
+	commentID, err := strconv.ParseUint(c.Param("commentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的评论ID"})
+		return 0, 0, false
+	}
+	return uint(taskID), uint(commentID), true
+}
+
+// convertToCommentResponse 递归转换评论模型为响应DTO
+func convertToCommentResponse(comment models.Comment) dto.CommentResponse {
+	replies := make([]dto.CommentResponse, len(comment.Replies))
+	for i, reply := range comment.Replies {
+		replies[i] = convertToCommentResponse(reply)
+	}
+
+	return dto.CommentResponse{
+		ID:        comment.ID,
+		TaskID:    comment.TaskID,
+		ParentID:  comment.ParentID,
+		UserID:    comment.UserID,
+		Username:  comment.User.Username,
+		Nickname:  comment.User.Nickname,
+		Avatar:    comment.User.Avatar,
+		Content:   comment.Content,
+		Status:    int(comment.Status),
+		CreatedAt: comment.CreatedAt,
+		UpdatedAt: comment.UpdatedAt,
+		Replies:   replies,
+	}
+}
@@ -0,0 +1,154 @@
+package customfield
+
+import (
+	"net/http"
+	"strconv"
+
+	"progress-wall-backend/middleware"
+	"progress-wall-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CustomFieldHandler 自定义字段处理器
+type CustomFieldHandler struct {
+	customFieldService *services.CustomFieldService
+}
+
+// NewCustomFieldHandler 创建自定义字段处理器
+func NewCustomFieldHandler(db *gorm.DB) *CustomFieldHandler {
+	return &CustomFieldHandler{
+		customFieldService: services.NewCustomFieldService(db),
+	}
+}
+
+// CustomFieldRequest 自定义字段请求，更新时未提供的字段保持不变，type 创建后不可修改
+type CustomFieldRequest struct {
+	Name     *string   `json:"name" binding:"omitempty,max=50"`
+	Type     string    `json:"type"`
+	Options  *[]string `json:"options"`
+	Required *bool     `json:"required"`
+	Position *int      `json:"position"`
+}
+
+// input 转换为服务层参数
+func (r CustomFieldRequest) input() services.CustomFieldInput {
+	return services.CustomFieldInput{
+		Name:     r.Name,
+		Type:     r.Type,
+		Options:  r.Options,
+		Required: r.Required,
+		Position: r.Position,
+	}
+}
+
+// GetCustomFields 获取项目的自定义字段
+// GET /api/projects/:projectId/custom-fields
+func (h *CustomFieldHandler) GetCustomFields(c *gin.Context) {
+	projectID, err := strconv.ParseUint(c.Param("projectId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+		return
+	}
+
+	fields, err := h.customFieldService.GetProjectFields(uint(projectID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"fields": fields})
+}
+
+// CreateCustomField 创建自定义字段
+// POST /api/projects/:projectId/custom-fields
+func (h *CustomFieldHandler) CreateCustomField(c *gin.Context) {
+	projectID, err := strconv.ParseUint(c.Param("projectId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+		return
+	}
+
+	var req CustomFieldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	field, err := h.customFieldService.CreateField(uint(projectID), req.input(), middleware.CurrentActor(c))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, field)
+}
+
+// UpdateCustomField 更新自定义字段
+// PUT /api/projects/:projectId/custom-fields/:fieldId
+func (h *CustomFieldHandler) UpdateCustomField(c *gin.Context) {
+	projectID, fieldID, ok := parseFieldParams(c)
+	if !ok {
+		return
+	}
+
+	var req CustomFieldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	field, err := h.customFieldService.UpdateField(projectID, fieldID, req.input(), middleware.CurrentActor(c))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, field)
+}
+
+// DeleteCustomField 删除自定义字段及其在所有任务上的值
+// DELETE /api/projects/:projectId/custom-fields/:fieldId
+func (h *CustomFieldHandler) DeleteCustomField(c *gin.Context) {
+	projectID, fieldID, ok := parseFieldParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.customFieldService.DeleteField(projectID, fieldID, middleware.CurrentActor(c)); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// parseFieldParams 解析项目ID和字段ID
+func parseFieldParams(c *gin.Context) (uint, uint, bool) {
+	projectID, err := strconv.ParseUint(c.Param("projectId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+		return 0, 0, false
+	}
+	fieldID, err := strconv.ParseUint(c.Param("fieldId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的字段ID"})
+		return 0, 0, false
+	}
+	return uint(projectID), uint(fieldID), true
+}
+
+// handleError 将服务层错误映射为 HTTP 响应
+func handleError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrCustomFieldNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrCustomFieldExists, services.ErrCustomFieldOptionInUse:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case services.ErrCustomFieldNameRequired, services.ErrInvalidCustomFieldType, services.ErrInvalidCustomFieldOptions:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
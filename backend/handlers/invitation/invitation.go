@@ -0,0 +1,215 @@
+package invitation
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/mailer"
+	"progress-wall-backend/middleware"
+	"progress-wall-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// InvitationHandler 团队/项目邀请处理器
+type InvitationHandler struct {
+	invitationService *services.InvitationService
+}
+
+// NewInvitationHandler 创建邀请处理器
+func NewInvitationHandler(db *gorm.DB, mail mailer.Mailer, cfg *config.Config) *InvitationHandler {
+	return &InvitationHandler{
+		invitationService: services.NewInvitationService(db, mail, cfg),
+	}
+}
+
+// createInvitationRequest 创建邀请请求
+type createInvitationRequest struct {
+	Email          string `json:"email"`
+	Role           int    `json:"role"`
+	ExpiresInHours int    `json:"expires_in_hours"`
+	MaxUses        int    `json:"max_uses"`
+}
+
+// CreateTeamInvitation 创建团队邀请
+// POST /api/teams/:teamId/invitations
+func (h *InvitationHandler) CreateTeamInvitation(c *gin.Context) {
+	teamID, err := strconv.ParseUint(c.Param("teamId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的团队ID"})
+		return
+	}
+	h.createInvitation(c, uint(teamID), nil)
+}
+
+// CreateProjectInvitation 创建项目邀请
+// POST /api/projects/:projectId/invitations
+func (h *InvitationHandler) CreateProjectInvitation(c *gin.Context) {
+	projectID, err := strconv.ParseUint(c.Param("projectId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+		return
+	}
+	id := uint(projectID)
+	h.createInvitation(c, 0, &id)
+}
+
+// GetTeamInvitations 获取团队邀请列表
+// GET /api/teams/:teamId/invitations
+func (h *InvitationHandler) GetTeamInvitations(c *gin.Context) {
+	teamID, err := strconv.ParseUint(c.Param("teamId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的团队ID"})
+		return
+	}
+	h.listInvitations(c, uint(teamID), nil)
+}
+
+// GetProjectInvitations 获取项目邀请列表
+// GET /api/projects/:projectId/invitations
+func (h *InvitationHandler) GetProjectInvitations(c *gin.Context) {
+	projectID, err := strconv.ParseUint(c.Param("projectId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+		return
+	}
+	id := uint(projectID)
+	h.listInvitations(c, 0, &id)
+}
+
+// RevokeTeamInvitation 撤销团队邀请
+// DELETE /api/teams/:teamId/invitations/:invitationId
+func (h *InvitationHandler) RevokeTeamInvitation(c *gin.Context) {
+	teamID, err := strconv.ParseUint(c.Param("teamId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的团队ID"})
+		return
+	}
+	h.revokeInvitation(c, uint(teamID), nil)
+}
+
+// RevokeProjectInvitation 撤销项目邀请
+// DELETE /api/projects/:projectId/invitations/:invitationId
+func (h *InvitationHandler) RevokeProjectInvitation(c *gin.Context) {
+	projectID, err := strconv.ParseUint(c.Param("projectId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+		return
+	}
+	id := uint(projectID)
+	h.revokeInvitation(c, 0, &id)
+}
+
+// PreviewInvitation 查看邀请信息（无需登录）
+// GET /api/invitations/:token
+func (h *InvitationHandler) PreviewInvitation(c *gin.Context) {
+	preview, err := h.invitationService.PreviewInvitation(c.Param("token"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// AcceptInvitation 当前用户接受邀请
+// POST /api/invitations/accept
+func (h *InvitationHandler) AcceptInvitation(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误：需要 token"})
+		return
+	}
+
+	invitation, err := h.invitationService.AcceptInvitation(req.Token, c.GetUint("user_id"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "已加入",
+		"team_id":    invitation.TeamID,
+		"project_id": invitation.ProjectID,
+	})
+}
+
+func (h *InvitationHandler) createInvitation(c *gin.Context, teamID uint, projectID *uint) {
+	var req createInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+	if req.Role == 0 {
+		req.Role = 1
+	}
+
+	result, err := h.invitationService.CreateInvitation(services.CreateInvitationInput{
+		TeamID:    teamID,
+		ProjectID: projectID,
+		Email:     req.Email,
+		Role:      req.Role,
+		ExpiresIn: time.Duration(req.ExpiresInHours) * time.Hour,
+		MaxUses:   req.MaxUses,
+	}, middleware.CurrentActor(c))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	// 邀请链接仅在创建时返回一次
+	c.JSON(http.StatusCreated, gin.H{
+		"invitation": result.Invitation,
+		"invite_url": result.InviteURL,
+		"email_sent": result.EmailSent,
+	})
+}
+
+func (h *InvitationHandler) listInvitations(c *gin.Context, teamID uint, projectID *uint) {
+	invitations, err := h.invitationService.ListInvitations(teamID, projectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitations": invitations})
+}
+
+func (h *InvitationHandler) revokeInvitation(c *gin.Context, teamID uint, projectID *uint) {
+	invitationID, err := strconv.ParseUint(c.Param("invitationId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的邀请ID"})
+		return
+	}
+
+	if err := h.invitationService.RevokeInvitation(teamID, projectID, uint(invitationID), middleware.CurrentActor(c)); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "邀请已撤销"})
+}
+
+// handleError 将业务错误映射为HTTP状态码
+func handleError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrInvitationNotFound, services.ErrInvitationInvalid,
+		services.ErrTeamNotFound, services.ErrProjectNotFound, services.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrInvitationExpired, services.ErrInvitationUsedUp:
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	case services.ErrInvitationEmail:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case services.ErrUserAlreadyMember, services.ErrMemberExists:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case services.ErrInvalidRole, services.ErrInvalidEmail:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
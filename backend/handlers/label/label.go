@@ -0,0 +1,193 @@
+package label
+
+import (
+	"net/http"
+	"strconv"
+
+	"progress-wall-backend/middleware"
+	"progress-wall-backend/models"
+	"progress-wall-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// LabelHandler 标签处理器
+type LabelHandler struct {
+	labelService *services.LabelService
+}
+
+// NewLabelHandler 创建标签处理器
+func NewLabelHandler(db *gorm.DB) *LabelHandler {
+	return &LabelHandler{
+		labelService: services.NewLabelService(db),
+	}
+}
+
+// GetLabels 获取项目标签列表
+// GET /api/projects/:projectId/labels
+func (h *LabelHandler) GetLabels(c *gin.Context) {
+	projectID, err := strconv.ParseUint(c.Param("projectId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+		return
+	}
+
+	labels, err := h.labelService.GetProjectLabels(uint(projectID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"labels": labels})
+}
+
+// CreateLabel 创建项目标签
+// POST /api/projects/:projectId/labels
+func (h *LabelHandler) CreateLabel(c *gin.Context) {
+	projectID, err := strconv.ParseUint(c.Param("projectId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+		return
+	}
+
+	var req struct {
+		Name  string `json:"name" binding:"required,max=50"`
+		Color string `json:"color"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	label, err := h.labelService.CreateLabel(uint(projectID), req.Name, req.Color, middleware.CurrentActor(c))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, label)
+}
+
+// UpdateLabel 更新项目标签
+// PUT /api/projects/:projectId/labels/:labelId
+func (h *LabelHandler) UpdateLabel(c *gin.Context) {
+	projectID, err := strconv.ParseUint(c.Param("projectId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+		return
+	}
+	labelID, err := strconv.ParseUint(c.Param("labelId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的标签ID"})
+		return
+	}
+
+	var req struct {
+		Name  *string `json:"name" binding:"omitempty,max=50"`
+		Color *string `json:"color"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	label, err := h.labelService.UpdateLabel(uint(projectID), uint(labelID), req.Name, req.Color, middleware.CurrentActor(c))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, label)
+}
+
+// DeleteLabel 删除项目标签
+// DELETE /api/projects/:projectId/labels/:labelId
+func (h *LabelHandler) DeleteLabel(c *gin.Context) {
+	projectID, err := strconv.ParseUint(c.Param("projectId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+		return
+	}
+	labelID, err := strconv.ParseUint(c.Param("labelId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的标签ID"})
+		return
+	}
+
+	if err := h.labelService.DeleteLabel(uint(projectID), uint(labelID), middleware.CurrentActor(c)); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// AddTaskLabel 为任务添加标签
+// POST /api/tasks/:taskId/labels
+func (h *LabelHandler) AddTaskLabel(c *gin.Context) {
+	if !middleware.CheckScope(c, models.ScopeTasksWrite) {
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+
+	var req struct {
+		LabelID uint `json:"label_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误：需要 label_id"})
+		return
+	}
+
+	if err := h.labelService.AddLabelToTask(uint(taskID), req.LabelID, middleware.CurrentActor(c)); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "添加成功"})
+}
+
+// RemoveTaskLabel 移除任务标签
+// DELETE /api/tasks/:taskId/labels/:labelId
+func (h *LabelHandler) RemoveTaskLabel(c *gin.Context) {
+	if !middleware.CheckScope(c, models.ScopeTasksWrite) {
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+	labelID, err := strconv.ParseUint(c.Param("labelId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的标签ID"})
+		return
+	}
+
+	if err := h.labelService.RemoveLabelFromTask(uint(taskID), uint(labelID), middleware.CurrentActor(c)); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "移除成功"})
+}
+
+// handleError 将业务错误映射为HTTP状态码
+func handleError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrLabelNotFound, services.ErrTaskNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrLabelExists:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case services.ErrInvalidColor, services.ErrLabelMismatch, services.ErrLabelNameRequired:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
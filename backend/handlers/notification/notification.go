@@ -24,10 +24,13 @@ func NewNotificationHandler(db *gorm.DB) *NotificationHandler {
 
 // 通知请求体结构（与定时任务格式匹配）
 type TaskNotificationReq struct {
-	UserID           uint   `json:"user_id"`           // 任务创建者ID
+	UserID           uint   `json:"user_id"`           // 接收人ID（任务负责人或关注者）
 	TaskID           uint   `json:"task_id"`           // 任务ID
+	TaskKey          string `json:"task_key"`          // 任务编号，如 WEB-123
 	TaskTitle        string `json:"task_title"`        // 任务标题
 	NotificationType string `json:"notification_type"` // 通知类型
+	Detail           string `json:"detail"`            // 评论内容或移动说明
+	DueDate          string `json:"due_date"`          // 截止时间（按接收人偏好格式化）
 }
 
 // ReceiveTaskNotification 接收定时任务发送的通知
@@ -66,7 +69,14 @@ func (h *NotificationHandler) printNotification(req TaskNotificationReq) {
 	fmt.Printf("时间：%s\n", time.Now().Format("2006-01-02 15:04:05"))
 	fmt.Printf("用户ID：%d\n", req.UserID)
 	fmt.Printf("任务ID：%d\n", req.TaskID)
+	fmt.Printf("任务编号：%s\n", req.TaskKey)
 	fmt.Printf("任务标题：%s\n", req.TaskTitle)
 	fmt.Printf("类型：%s\n", req.NotificationType)
-	fmt.Println("======================================\n")
+	if req.Detail != "" {
+		fmt.Printf("内容：%s\n", req.Detail)
+	}
+	if req.DueDate != "" {
+		fmt.Printf("截止时间：%s\n", req.DueDate)
+	}
+	fmt.Println("======================================")
 }
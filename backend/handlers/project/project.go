@@ -3,8 +3,10 @@ package project
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"progress-wall-backend/middleware"
 	"progress-wall-backend/models"
 	"progress-wall-backend/services"
 
@@ -82,6 +84,7 @@ func (h *ProjectHandler) CreateProject(c *gin.Context) {
 
 	var createProjectRequest struct {
 		Name        string     `json:"name" binding:"required,min=1,max=100"`
+		Key         string     `json:"key"` // 任务编号前缀，不填时根据项目名称生成
 		Description string     `json:"description" binding:"max=500"`
 		Status      *int       `json:"status"`
 		StartDate   *time.Time `json:"start_date"`
@@ -108,6 +111,7 @@ func (h *ProjectHandler) CreateProject(c *gin.Context) {
 
 	project := &models.Project{
 		Name:        createProjectRequest.Name,
+		Key:         strings.ToUpper(strings.TrimSpace(createProjectRequest.Key)),
 		Description: createProjectRequest.Description,
 		Status:      status,
 		StartDate:   createProjectRequest.StartDate,
@@ -117,7 +121,14 @@ func (h *ProjectHandler) CreateProject(c *gin.Context) {
 	}
 
 	if err := h.projectService.CreateProject(project); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch err {
+		case services.ErrInvalidProjectKey:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case services.ErrProjectKeyExists:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
@@ -154,6 +165,7 @@ func (h *ProjectHandler) UpdateProject(c *gin.Context) {
 
 	var updateProjectRequest struct {
 		Name        *string               `json:"name" binding:"omitempty,min=1,max=100"`
+		Key         *string               `json:"key"`
 		Description *string               `json:"description" binding:"omitempty,max=500"`
 		Status      *models.ProjectStatus `json:"status"`
 		StartDate   *time.Time            `json:"start_date"`
@@ -169,6 +181,9 @@ func (h *ProjectHandler) UpdateProject(c *gin.Context) {
 	if updateProjectRequest.Name != nil {
 		updates["name"] = *updateProjectRequest.Name
 	}
+	if updateProjectRequest.Key != nil {
+		updates["project_key"] = strings.ToUpper(strings.TrimSpace(*updateProjectRequest.Key))
+	}
 	if updateProjectRequest.Description != nil {
 		updates["description"] = *updateProjectRequest.Description
 	}
@@ -183,11 +198,16 @@ func (h *ProjectHandler) UpdateProject(c *gin.Context) {
 	}
 
 	if err := h.projectService.UpdateProject(uint(projectID), updates); err != nil {
-		if err == services.ErrProjectNotFound {
+		switch err {
+		case services.ErrProjectNotFound:
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
+		case services.ErrInvalidProjectKey:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case services.ErrProjectKeyExists:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -214,3 +234,120 @@ func (h *ProjectHandler) DeleteProject(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
 }
+
+// GetProjectMembers 获取项目成员列表
+// GET /api/projects/:projectId/members
+func (h *ProjectHandler) GetProjectMembers(c *gin.Context) {
+	projectID, err := strconv.ParseUint(c.Param("projectId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+		return
+	}
+
+	members, err := h.projectService.GetProjectMembers(uint(projectID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+// AddProjectMember 添加项目成员（用户必须已是项目所属团队成员）
+// POST /api/projects/:projectId/members
+func (h *ProjectHandler) AddProjectMember(c *gin.Context) {
+	projectID, err := strconv.ParseUint(c.Param("projectId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+		return
+	}
+
+	var req struct {
+		UserID uint               `json:"user_id" binding:"required"`
+		Role   models.ProjectRole `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	if req.Role == 0 {
+		req.Role = models.ProjectRoleMember
+	}
+
+	member, err := h.projectService.AddProjectMember(uint(projectID), req.UserID, req.Role, middleware.CurrentActor(c))
+	if err != nil {
+		handleMemberError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, member)
+}
+
+// UpdateProjectMember 修改项目成员角色
+// PUT /api/projects/:projectId/members/:userId
+func (h *ProjectHandler) UpdateProjectMember(c *gin.Context) {
+	projectID, userID, ok := parseMemberIDs(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Role models.ProjectRole `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	if err := h.projectService.UpdateProjectMemberRole(projectID, userID, req.Role, middleware.CurrentActor(c)); err != nil {
+		handleMemberError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "更新成功"})
+}
+
+// RemoveProjectMember 移除项目成员
+// DELETE /api/projects/:projectId/members/:userId
+func (h *ProjectHandler) RemoveProjectMember(c *gin.Context) {
+	projectID, userID, ok := parseMemberIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.projectService.RemoveProjectMember(projectID, userID, middleware.CurrentActor(c)); err != nil {
+		handleMemberError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "移除成功"})
+}
+
+// parseMemberIDs 解析路径中的项目ID和用户ID
+func parseMemberIDs(c *gin.Context) (uint, uint, bool) {
+	projectID, err := strconv.ParseUint(c.Param("projectId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+		return 0, 0, false
+	}
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return 0, 0, false
+	}
+	return uint(projectID), uint(userID), true
+}
+
+// handleMemberError 将成员管理相关的业务错误映射为HTTP状态码
+func handleMemberError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrProjectNotFound, services.ErrUserNotFound, services.ErrMemberNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrMemberExists, services.ErrLastProjectAdmin:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case services.ErrNotTeamMember, services.ErrInvalidRole:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,129 @@
+package task
+
+import (
+	"net/http"
+	"strconv"
+
+	"progress-wall-backend/middleware"
+	"progress-wall-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AddAssignee 添加任务负责人
+// POST /api/tasks/:taskId/assignees
+func (h *TaskHandler) AddAssignee(c *gin.Context) {
+	if !middleware.CheckScope(c, models.ScopeTasksWrite) {
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+
+	var req struct {
+		UserID uint `json:"user_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误：需要 user_id"})
+		return
+	}
+
+	if err := h.taskService.AddAssignee(uint(taskID), req.UserID, middleware.CurrentActor(c)); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "添加成功"})
+}
+
+// RemoveAssignee 移除任务负责人
+// DELETE /api/tasks/:taskId/assignees/:userId
+func (h *TaskHandler) RemoveAssignee(c *gin.Context) {
+	if !middleware.CheckScope(c, models.ScopeTasksWrite) {
+		return
+	}
+
+	taskID, userID, ok := parseTaskUserParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.taskService.RemoveAssignee(taskID, userID, middleware.CurrentActor(c)); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "移除成功"})
+}
+
+// AddWatcher 添加任务关注者，未指定 user_id 时关注者为当前用户
+// POST /api/tasks/:taskId/watchers
+func (h *TaskHandler) AddWatcher(c *gin.Context) {
+	if !middleware.CheckScope(c, models.ScopeTasksWrite) {
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+
+	var req struct {
+		UserID uint `json:"user_id"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+			return
+		}
+	}
+	if req.UserID == 0 {
+		req.UserID = c.GetUint("user_id")
+	}
+
+	if err := h.taskService.AddWatcher(uint(taskID), req.UserID, middleware.CurrentActor(c)); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "关注成功"})
+}
+
+// RemoveWatcher 移除任务关注者
+// DELETE /api/tasks/:taskId/watchers/:userId
+func (h *TaskHandler) RemoveWatcher(c *gin.Context) {
+	if !middleware.CheckScope(c, models.ScopeTasksWrite) {
+		return
+	}
+
+	taskID, userID, ok := parseTaskUserParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.taskService.RemoveWatcher(taskID, userID, middleware.CurrentActor(c)); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "移除成功"})
+}
+
+// parseTaskUserParams 解析任务ID和用户ID
+func parseTaskUserParams(c *gin.Context) (uint, uint, bool) {
+	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return 0, 0, false
+	}
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return 0, 0, false
+	}
+	return uint(taskID), uint(userID), true
+}
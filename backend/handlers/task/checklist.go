@@ -0,0 +1,159 @@
+package task
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"progress-wall-backend/middleware"
+	"progress-wall-backend/models"
+	"progress-wall-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChecklistItemRequest 检查项请求，更新时未提供的字段保持不变
+type ChecklistItemRequest struct {
+	Content    *string    `json:"content"`
+	Done       *bool      `json:"done"`
+	Position   *int       `json:"position"`
+	AssigneeID *uint      `json:"assignee_id"`
+	DueDate    *time.Time `json:"due_date"`
+}
+
+// input 转换为服务层参数
+func (r ChecklistItemRequest) input() services.ChecklistItemInput {
+	return services.ChecklistItemInput{
+		Content:    r.Content,
+		Done:       r.Done,
+		Position:   r.Position,
+		AssigneeID: r.AssigneeID,
+		DueDate:    r.DueDate,
+	}
+}
+
+// GetSubtasks 获取任务的子任务
+// GET /api/tasks/:taskId/subtasks
+func (h *TaskHandler) GetSubtasks(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+
+	subtasks, err := h.taskService.GetSubtasks(uint(taskID))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"subtasks": subtasks})
+}
+
+// GetChecklist 获取任务的检查项
+// GET /api/tasks/:taskId/checklist
+func (h *TaskHandler) GetChecklist(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+
+	items, err := h.taskService.GetChecklist(uint(taskID))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// AddChecklistItem 添加检查项
+// POST /api/tasks/:taskId/checklist
+func (h *TaskHandler) AddChecklistItem(c *gin.Context) {
+	if !middleware.CheckScope(c, models.ScopeTasksWrite) {
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+
+	var req ChecklistItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	item, err := h.taskService.AddChecklistItem(uint(taskID), req.input(), middleware.CurrentActor(c))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, item)
+}
+
+// UpdateChecklistItem 更新检查项
+// PUT /api/tasks/:taskId/checklist/:itemId
+func (h *TaskHandler) UpdateChecklistItem(c *gin.Context) {
+	if !middleware.CheckScope(c, models.ScopeTasksWrite) {
+		return
+	}
+
+	taskID, itemID, ok := parseChecklistParams(c)
+	if !ok {
+		return
+	}
+
+	var req ChecklistItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	item, err := h.taskService.UpdateChecklistItem(taskID, itemID, req.input(), middleware.CurrentActor(c))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// DeleteChecklistItem 删除检查项
+// DELETE /api/tasks/:taskId/checklist/:itemId
+func (h *TaskHandler) DeleteChecklistItem(c *gin.Context) {
+	if !middleware.CheckScope(c, models.ScopeTasksWrite) {
+		return
+	}
+
+	taskID, itemID, ok := parseChecklistParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.taskService.DeleteChecklistItem(taskID, itemID, middleware.CurrentActor(c)); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// parseChecklistParams 解析任务ID和检查项ID
+func parseChecklistParams(c *gin.Context) (uint, uint, bool) {
+	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return 0, 0, false
+	}
+	itemID, err := strconv.ParseUint(c.Param("itemId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的检查项ID"})
+		return 0, 0, false
+	}
+	return uint(taskID), uint(itemID), true
+}
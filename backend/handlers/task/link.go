@@ -0,0 +1,86 @@
+package task
+
+import (
+	"net/http"
+	"strconv"
+
+	"progress-wall-backend/middleware"
+	"progress-wall-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTaskLinks 获取任务关联
+// GET /api/tasks/:taskId/links
+func (h *TaskHandler) GetTaskLinks(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+
+	links, err := h.taskService.GetTaskLinks(uint(taskID))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"links": links})
+}
+
+// CreateTaskLink 添加任务关联
+// POST /api/tasks/:taskId/links
+func (h *TaskHandler) CreateTaskLink(c *gin.Context) {
+	if !middleware.CheckScope(c, models.ScopeTasksWrite) {
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+
+	var req struct {
+		Type   string `json:"type" binding:"required"`
+		TaskID uint   `json:"task_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误：需要 type 和 task_id"})
+		return
+	}
+
+	link, err := h.taskService.CreateTaskLink(uint(taskID), req.TaskID, req.Type, middleware.CurrentActor(c))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, link)
+}
+
+// DeleteTaskLink 删除任务关联
+// DELETE /api/tasks/:taskId/links/:linkId
+func (h *TaskHandler) DeleteTaskLink(c *gin.Context) {
+	if !middleware.CheckScope(c, models.ScopeTasksWrite) {
+		return
+	}
+
+	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+	linkID, err := strconv.ParseUint(c.Param("linkId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的关联ID"})
+		return
+	}
+
+	if err := h.taskService.DeleteTaskLink(uint(taskID), uint(linkID), middleware.CurrentActor(c)); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
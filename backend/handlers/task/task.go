@@ -1,10 +1,14 @@
 package task
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	"progress-wall-backend/config"
+	"progress-wall-backend/middleware"
 	"progress-wall-backend/models"
 	"progress-wall-backend/services"
 
@@ -18,9 +22,9 @@ type TaskHandler struct {
 }
 
 // NewTaskHandler 创建任务处理器
-func NewTaskHandler(db *gorm.DB) *TaskHandler {
+func NewTaskHandler(db *gorm.DB, cfg *config.Config) *TaskHandler {
 	return &TaskHandler{
-		taskService: services.NewTaskService(db),
+		taskService: services.NewTaskService(db, cfg),
 	}
 }
 
@@ -62,8 +66,82 @@ func (h *TaskHandler) GetTasks(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"tasks": tasks})
 }
 
+// GetTaskByNumber 根据项目 key（或项目ID）和项目内编号获取任务
+// GET /api/projects/:projectId/tasks/:number
+func (h *TaskHandler) GetTaskByNumber(c *gin.Context) {
+	number, err := strconv.ParseUint(c.Param("number"), 10, 32)
+	if err != nil || number == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务编号"})
+		return
+	}
+
+	task, err := h.taskService.GetTaskByNumber(c.Param("projectId"), uint(number))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// SearchTasks 在项目内搜索任务，q 可以是标题关键字或任务编号（WEB-123、#123），cf[字段ID] 按自定义字段筛选
+// GET /api/projects/:projectId/tasks?q=&cf[3]=
+func (h *TaskHandler) SearchTasks(c *gin.Context) {
+	filter, ok := parseTaskFilter(c)
+	if !ok {
+		return
+	}
+
+	tasks, err := h.taskService.SearchTasks(c.Param("projectId"), filter)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tasks": tasks})
+}
+
+// ExportTasks 按与搜索相同的条件导出项目任务为 CSV
+// GET /api/projects/:projectId/tasks/export?q=&cf[3]=
+func (h *TaskHandler) ExportTasks(c *gin.Context) {
+	filter, ok := parseTaskFilter(c)
+	if !ok {
+		return
+	}
+
+	filename, content, err := h.taskService.ExportTasksCSV(c.Param("projectId"), filter, c.GetUint("user_id"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", content)
+}
+
+// parseTaskFilter 解析任务筛选参数
+func parseTaskFilter(c *gin.Context) (services.TaskFilter, bool) {
+	filter := services.TaskFilter{Query: c.Query("q")}
+	if fields := c.QueryMap("cf"); len(fields) > 0 {
+		filter.CustomFields = make(map[uint]string, len(fields))
+		for key, value := range fields {
+			fieldID, err := strconv.ParseUint(key, 10, 32)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的自定义字段ID"})
+				return filter, false
+			}
+			filter.CustomFields[uint(fieldID)] = value
+		}
+	}
+	return filter, true
+}
+
 // CreateTask 创建任务
 func (h *TaskHandler) CreateTask(c *gin.Context) {
+	if !middleware.CheckScope(c, models.ScopeTasksWrite) {
+		return
+	}
+
 	userID := c.GetUint("user_id")
 	if userID == 0 {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "无法获取用户信息"})
@@ -83,8 +161,12 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		DueDate        *time.Time           `json:"due_date"`
 		StartDate      *time.Time           `json:"start_date"`
 		EstimatedHours *float64             `json:"estimated_hours"`
-		AssigneeID     *uint                `json:"assignee_id"`
-		ProjectID      uint                 `json:"project_id" binding:"required"`
+		AssigneeIDs    []uint               `json:"assignee_ids"`
+		ProjectID      uint                 `json:"project_id"` // 可省略，由列所在的项目决定
+		ParentTaskID   *uint                `json:"parent_task_id"`
+		AutoComplete   bool                 `json:"auto_complete"`
+		// 自定义字段ID到值的映射
+		CustomFields map[uint]json.RawMessage `json:"custom_fields"`
 	}
 
 	if err := c.ShouldBindJSON(&createTaskRequest); err != nil {
@@ -104,15 +186,18 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		Status:         models.TaskStatusTodo,
 		ColumnID:       uint(columnID),
 		CreatorID:      userID,
-		AssigneeID:     createTaskRequest.AssigneeID,
 		ProjectID:      createTaskRequest.ProjectID,
 		DueDate:        createTaskRequest.DueDate,
 		StartDate:      createTaskRequest.StartDate,
 		EstimatedHours: createTaskRequest.EstimatedHours,
+		AutoComplete:   createTaskRequest.AutoComplete,
+	}
+	if createTaskRequest.ParentTaskID != nil && *createTaskRequest.ParentTaskID != 0 {
+		task.ParentTaskID = createTaskRequest.ParentTaskID
 	}
 
-	if err := h.taskService.CreateTask(task); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.taskService.CreateTask(task, createTaskRequest.AssigneeIDs, createTaskRequest.CustomFields); err != nil {
+		handleError(c, err)
 		return
 	}
 
@@ -121,6 +206,10 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 
 // UpdateTask 更新任务
 func (h *TaskHandler) UpdateTask(c *gin.Context) {
+	if !middleware.CheckScope(c, models.ScopeTasksWrite) {
+		return
+	}
+
 	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
@@ -137,7 +226,11 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 		EndDate        *time.Time           `json:"end_date"`
 		EstimatedHours *float64             `json:"estimated_hours"`
 		ActualHours    *float64             `json:"actual_hours"`
-		AssigneeID     *uint                `json:"assignee_id"`
+		AssigneeIDs    *[]uint              `json:"assignee_ids"`   // 替换全部负责人
+		ParentTaskID   *uint                `json:"parent_task_id"` // 为0时解除父子关系
+		AutoComplete   *bool                `json:"auto_complete"`
+		// 只修改出现的自定义字段，值为 null 时清空
+		CustomFields map[uint]json.RawMessage `json:"custom_fields"`
 	}
 
 	if err := c.ShouldBindJSON(&updateTaskRequest); err != nil {
@@ -173,16 +266,18 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 	if updateTaskRequest.ActualHours != nil {
 		updates["actual_hours"] = *updateTaskRequest.ActualHours
 	}
-	if updateTaskRequest.AssigneeID != nil {
-		updates["assignee_id"] = *updateTaskRequest.AssigneeID
+	if updateTaskRequest.AutoComplete != nil {
+		updates["auto_complete"] = *updateTaskRequest.AutoComplete
 	}
 
-	if err := h.taskService.UpdateTask(uint(taskID), updates); err != nil {
-		if err == services.ErrTaskNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	input := services.TaskUpdateInput{
+		Fields:       updates,
+		ParentTaskID: updateTaskRequest.ParentTaskID,
+		AssigneeIDs:  updateTaskRequest.AssigneeIDs,
+		CustomFields: updateTaskRequest.CustomFields,
+	}
+	if err := h.taskService.UpdateTask(uint(taskID), input, middleware.CurrentActor(c)); err != nil {
+		handleError(c, err)
 		return
 	}
 
@@ -191,6 +286,10 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 
 // DeleteTask 删除任务
 func (h *TaskHandler) DeleteTask(c *gin.Context) {
+	if !middleware.CheckScope(c, models.ScopeTasksWrite) {
+		return
+	}
+
 	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
@@ -211,6 +310,10 @@ func (h *TaskHandler) DeleteTask(c *gin.Context) {
 
 // MoveTask 移动任务（拖拽排序）
 func (h *TaskHandler) MoveTask(c *gin.Context) {
+	if !middleware.CheckScope(c, models.ScopeTasksWrite) {
+		return
+	}
+
 	taskID, err := strconv.ParseUint(c.Param("taskId"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
@@ -227,17 +330,40 @@ func (h *TaskHandler) MoveTask(c *gin.Context) {
 		return
 	}
 
-	userID := c.GetUint("user_id")
-	username := c.GetString("username") // 假设中间件中设置了username
-
-	if err := h.taskService.MoveTask(uint(taskID), moveTaskRequest.NewColumnID, moveTaskRequest.NewOrder, userID, username); err != nil {
+	blockers, err := h.taskService.MoveTask(uint(taskID), moveTaskRequest.NewColumnID, moveTaskRequest.NewOrder, middleware.CurrentActor(c))
+	if err != nil {
 		if err == services.ErrTaskNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if err == services.ErrTaskBlocked {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "TASK_BLOCKED", "blockers": blockers})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if len(blockers) > 0 {
+		c.JSON(http.StatusOK, gin.H{"message": "移动成功", "warning": "任务仍被未完成的任务阻塞", "blockers": blockers})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "移动成功"})
 }
+
+// handleError 将任务相关的业务错误映射为HTTP状态码
+func handleError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrTaskNotFound, services.ErrParentTaskNotFound, services.ErrChecklistItemNotFound, services.ErrTaskLinkNotFound,
+		services.ErrProjectNotFound, services.ErrCustomFieldNotFound, services.ErrColumnNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrParentTaskMismatch, services.ErrSubtaskCycle, services.ErrInvalidChecklistItem, services.ErrNotProjectMember,
+		services.ErrInvalidLinkType, services.ErrTaskLinkSelf, services.ErrTaskLinkMismatch, services.ErrTaskLinkCycle,
+		services.ErrInvalidCustomFieldValue, services.ErrCustomFieldRequired, services.ErrColumnProjectMismatch:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case services.ErrTaskLinkExists:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"progress-wall-backend/middleware"
 	"progress-wall-backend/models"
 	"progress-wall-backend/services"
 
@@ -34,8 +35,7 @@ func (h *TeamHandler) CreateTeam(c *gin.Context) {
 		return
 	}
 
-	userID := c.GetUint("user_id")
-	team, err := h.teamService.CreateTeam(req.Name, req.Description, userID)
+	team, err := h.teamService.CreateTeam(req.Name, req.Description, middleware.CurrentActor(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -118,14 +118,128 @@ func (h *TeamHandler) AddMember(c *gin.Context) {
 		req.Role = models.TeamRoleMember // Default to member
 	}
 
-	if err := h.teamService.AddTeamMember(uint(teamID), req.UserID, req.Role); err != nil {
-		if err == services.ErrUserAlreadyMember {
-			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.teamService.AddTeamMember(uint(teamID), req.UserID, req.Role, middleware.CurrentActor(c)); err != nil {
+		handleMemberError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Member added successfully"})
 }
+
+// RemoveMember 移除成员
+// DELETE /api/teams/:teamId/members/:userId
+func (h *TeamHandler) RemoveMember(c *gin.Context) {
+	teamID, userID, ok := parseMemberIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.teamService.RemoveTeamMember(teamID, userID, middleware.CurrentActor(c)); err != nil {
+		handleMemberError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+// UpdateMemberRole 修改成员角色
+// PUT /api/teams/:teamId/members/:userId
+func (h *TeamHandler) UpdateMemberRole(c *gin.Context) {
+	teamID, userID, ok := parseMemberIDs(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Role models.TeamRole `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.teamService.UpdateTeamMemberRole(teamID, userID, req.Role, middleware.CurrentActor(c)); err != nil {
+		handleMemberError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member role updated successfully"})
+}
+
+// LeaveTeam 退出团队
+// POST /api/teams/:teamId/leave
+func (h *TeamHandler) LeaveTeam(c *gin.Context) {
+	// 退出团队影响账号本身，访问令牌需要管理权限
+	if !middleware.CheckScope(c, models.ScopeProjectsAdmin) {
+		return
+	}
+
+	teamID, err := strconv.ParseUint(c.Param("teamId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return
+	}
+
+	if err := h.teamService.LeaveTeam(uint(teamID), middleware.CurrentActor(c)); err != nil {
+		handleMemberError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Left the team successfully"})
+}
+
+// TransferOwnership 转让团队所有权
+// POST /api/teams/:teamId/transfer
+func (h *TeamHandler) TransferOwnership(c *gin.Context) {
+	teamID, err := strconv.ParseUint(c.Param("teamId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return
+	}
+
+	var req struct {
+		UserID uint `json:"user_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.teamService.TransferOwnership(uint(teamID), req.UserID, middleware.CurrentActor(c)); err != nil {
+		handleMemberError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ownership transferred successfully"})
+}
+
+// parseMemberIDs 解析路径中的团队ID和用户ID
+func parseMemberIDs(c *gin.Context) (uint, uint, bool) {
+	teamID, err := strconv.ParseUint(c.Param("teamId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return 0, 0, false
+	}
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return 0, 0, false
+	}
+	return uint(teamID), uint(userID), true
+}
+
+// handleMemberError 将成员管理相关的业务错误映射为HTTP状态码
+func handleMemberError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrTeamNotFound, services.ErrTeamMemberNotFound, services.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrUserAlreadyMember, services.ErrTeamOwner, services.ErrLastProjectAdmin:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case services.ErrInvalidTeamRole:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case services.ErrNotTeamOwner:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
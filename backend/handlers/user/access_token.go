@@ -0,0 +1,93 @@
+package user
+
+import (
+	"net/http"
+	"strconv"
+
+	"progress-wall-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AccessTokenHandler 个人访问令牌管理处理器
+type AccessTokenHandler struct {
+	tokenService *services.AccessTokenService
+}
+
+// NewAccessTokenHandler 创建个人访问令牌管理处理器
+func NewAccessTokenHandler(db *gorm.DB) *AccessTokenHandler {
+	return &AccessTokenHandler{
+		tokenService: services.NewAccessTokenService(db),
+	}
+}
+
+// createAccessTokenRequest 创建个人访问令牌请求
+type createAccessTokenRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes" binding:"required"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+// GetAccessTokens 获取当前用户的个人访问令牌
+// GET /api/user/tokens
+func (h *AccessTokenHandler) GetAccessTokens(c *gin.Context) {
+	tokens, err := h.tokenService.ListTokens(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tokens": tokens})
+}
+
+// CreateAccessToken 创建个人访问令牌，明文令牌只返回这一次
+// POST /api/user/tokens
+func (h *AccessTokenHandler) CreateAccessToken(c *gin.Context) {
+	var req createAccessTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	token, raw, err := h.tokenService.CreateToken(c.GetUint("user_id"), services.CreateAccessTokenInput{
+		Name:          req.Name,
+		Scopes:        req.Scopes,
+		ExpiresInDays: req.ExpiresInDays,
+	})
+	if err != nil {
+		switch err {
+		case services.ErrInvalidTokenName, services.ErrInvalidTokenScope, services.ErrInvalidTokenExpiry:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"token":        token,
+		"access_token": raw,
+	})
+}
+
+// RevokeAccessToken 删除个人访问令牌
+// DELETE /api/user/tokens/:tokenId
+func (h *AccessTokenHandler) RevokeAccessToken(c *gin.Context) {
+	tokenID, err := strconv.ParseUint(c.Param("tokenId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的令牌ID"})
+		return
+	}
+
+	if err := h.tokenService.RevokeToken(c.GetUint("user_id"), uint(tokenID)); err != nil {
+		if err == services.ErrAccessTokenNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "访问令牌已删除"})
+}
@@ -0,0 +1,96 @@
+package user
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/services"
+	"progress-wall-backend/storage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AccountHandler 个人数据导出和注销账号处理器
+type AccountHandler struct {
+	accountService *services.AccountService
+}
+
+// NewAccountHandler 创建账号自助处理器
+func NewAccountHandler(db *gorm.DB, store storage.Storage, cfg *config.Config) *AccountHandler {
+	return &AccountHandler{
+		accountService: services.NewAccountService(db, store, cfg),
+	}
+}
+
+// DeleteAccountRequest 注销账号请求
+type DeleteAccountRequest struct {
+	Password      string `json:"password"`
+	Confirm       string `json:"confirm" binding:"required"`
+	SuccessorID   *uint  `json:"successor_id"`
+	ReassignTasks bool   `json:"reassign_tasks"`
+}
+
+// ExportData 导出个人数据
+// 默认返回包含 data.json 和上传文件的ZIP，format=json 时只返回JSON
+// GET /api/user/export
+func (h *AccountHandler) ExportData(c *gin.Context) {
+	export, err := h.accountService.ExportData(c.GetUint("user_id"), services.ClientInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()})
+	if err != nil {
+		if err == services.ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("progress-wall-%s-%s", export.Profile.Username, export.ExportedAt.Format("20060102"))
+	if c.Query("format") == "json" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".json"))
+		c.JSON(http.StatusOK, export)
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".zip"))
+	c.Status(http.StatusOK)
+	// 响应头已发送，写入失败只能记录日志
+	if err := h.accountService.WriteExportZip(c.Writer, export); err != nil {
+		log.Printf("导出个人数据失败: %v", err)
+	}
+}
+
+// DeleteAccount 注销当前账号
+// DELETE /api/user/account
+func (h *AccountHandler) DeleteAccount(c *gin.Context) {
+	var req DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	err := h.accountService.DeleteAccount(c.GetUint("user_id"), services.DeleteAccountInput{
+		Password:      req.Password,
+		Confirm:       req.Confirm,
+		SuccessorID:   req.SuccessorID,
+		ReassignTasks: req.ReassignTasks,
+	}, services.ClientInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()})
+	if err != nil {
+		switch err {
+		case services.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case services.ErrWrongPassword, services.ErrDeleteConfirmMismatch, services.ErrSuccessorRequired, services.ErrInvalidSuccessor, services.ErrSuccessorNotMember:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case services.ErrLastSysAdmin:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "账号已注销"})
+}
@@ -0,0 +1,79 @@
+package user
+
+import (
+	"net/http"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PreferencesHandler 用户偏好设置处理器
+type PreferencesHandler struct {
+	preferencesService *services.PreferencesService
+}
+
+// NewPreferencesHandler 创建偏好设置处理器
+func NewPreferencesHandler(db *gorm.DB, cfg *config.Config) *PreferencesHandler {
+	return &PreferencesHandler{
+		preferencesService: services.NewPreferencesService(db, cfg),
+	}
+}
+
+// UpdatePreferencesRequest 更新偏好设置请求，未提供的字段保持不变
+type UpdatePreferencesRequest struct {
+	Timezone           *string `json:"timezone"`
+	Locale             *string `json:"locale"`
+	DateFormat         *string `json:"date_format"`
+	DefaultBoardID     *uint   `json:"default_board_id"`
+	EmailNotifications *bool   `json:"email_notifications"`
+	InAppNotifications *bool   `json:"in_app_notifications"`
+}
+
+// GetPreferences 获取当前用户的偏好设置
+// GET /api/user/preferences
+func (h *PreferencesHandler) GetPreferences(c *gin.Context) {
+	prefs, err := h.preferencesService.GetPreferences(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// UpdatePreferences 更新当前用户的偏好设置
+// PUT /api/user/preferences
+func (h *PreferencesHandler) UpdatePreferences(c *gin.Context) {
+	var req UpdatePreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	prefs, err := h.preferencesService.UpdatePreferences(c.GetUint("user_id"), services.UpdatePreferencesInput{
+		Timezone:           req.Timezone,
+		Locale:             req.Locale,
+		DateFormat:         req.DateFormat,
+		DefaultBoardID:     req.DefaultBoardID,
+		EmailNotifications: req.EmailNotifications,
+		InAppNotifications: req.InAppNotifications,
+	})
+	if err != nil {
+		switch err {
+		case services.ErrInvalidTimezone, services.ErrInvalidLocale, services.ErrInvalidDateFormat:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case services.ErrBoardNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case services.ErrAccessDenied:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
@@ -148,9 +148,10 @@ func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
 	if req.Nickname != "" {
 		user.Nickname = req.Nickname
 	}
-	// Email 更新可能需要验证唯一性等逻辑，这里暂时简化
-	if req.Email != "" {
-		user.Email = req.Email
+	// 邮箱需要通过确认流程修改，这里不允许直接覆盖
+	if req.Email != "" && req.Email != user.Email {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "修改邮箱请使用 PUT /api/user/email，新邮箱确认后生效"})
+		return
 	}
 	if req.Phone != "" {
 		user.Phone = req.Phone
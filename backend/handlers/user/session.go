@@ -0,0 +1,61 @@
+package user
+
+import (
+	"net/http"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SessionHandler 登录会话（设备）管理处理器
+type SessionHandler struct {
+	sessionService *services.SessionService
+}
+
+// NewSessionHandler 创建会话管理处理器
+func NewSessionHandler(db *gorm.DB, cfg *config.Config) *SessionHandler {
+	return &SessionHandler{
+		sessionService: services.NewSessionService(db, cfg),
+	}
+}
+
+// GetSessions 获取当前用户的有效登录会话
+// GET /api/user/sessions
+func (h *SessionHandler) GetSessions(c *gin.Context) {
+	sessions, err := h.sessionService.ListSessions(c.GetUint("user_id"), c.GetString("session_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeSession 吊销指定会话
+// DELETE /api/user/sessions/:sessionId
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	if err := h.sessionService.RevokeSession(c.GetUint("user_id"), c.Param("sessionId")); err != nil {
+		if err == services.ErrSessionNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "会话已注销"})
+}
+
+// RevokeOtherSessions 吊销除当前会话外的所有会话
+// DELETE /api/user/sessions
+func (h *SessionHandler) RevokeOtherSessions(c *gin.Context) {
+	if err := h.sessionService.RevokeOtherSessions(c.GetUint("user_id"), c.GetString("session_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "其他会话已全部注销"})
+}
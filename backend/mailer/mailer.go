@@ -0,0 +1,84 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"progress-wall-backend/config"
+)
+
+// Mailer 邮件发送抽象
+type Mailer interface {
+	// Send 发送纯文本邮件
+	Send(to, subject, body string) error
+}
+
+// New 根据配置创建邮件发送器，未配置SMTP主机时使用仅打印日志的发送器
+func New(cfg config.MailConfig) Mailer {
+	if cfg.Host == "" {
+		return &LogMailer{}
+	}
+	return &SMTPMailer{cfg: cfg}
+}
+
+// SMTPMailer 通过SMTP服务器发送邮件
+// 服务器支持 STARTTLS 时自动升级连接；配置了用户名时使用 PLAIN 认证
+type SMTPMailer struct {
+	cfg config.MailConfig
+}
+
+// Send 发送邮件
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	addr := net.JoinHostPort(m.cfg.Host, m.cfg.Port)
+
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+
+	// 信封发件人只能是邮箱地址，From 头可以带显示名称
+	from, err := mail.ParseAddress(m.cfg.From)
+	if err != nil {
+		return fmt.Errorf("无效的发件人地址: %w", err)
+	}
+
+	if err := smtp.SendMail(addr, auth, from.Address, []string{to}, buildMessage(from.String(), to, subject, body)); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	return nil
+}
+
+// LogMailer 将邮件内容打印到日志，用于开发环境
+type LogMailer struct{}
+
+// Send 打印邮件内容
+func (m *LogMailer) Send(to, subject, body string) error {
+	log.Printf("【邮件】收件人: %s, 主题: %s\n%s", to, subject, body)
+	return nil
+}
+
+// buildMessage 构造 RFC 5322 格式的邮件内容
+func buildMessage(from, to, subject, body string) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + mimeEncode(subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// mimeEncode 对包含非ASCII字符的邮件头进行 RFC 2047 编码
+func mimeEncode(s string) string {
+	return mime.QEncoding.Encode("UTF-8", s)
+}
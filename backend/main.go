@@ -6,11 +6,14 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	_ "time/tzdata" // 内置时区数据，容器中缺少系统时区库时用户时区设置仍然可用
 
 	"progress-wall-backend/config"
 	"progress-wall-backend/database"
+	"progress-wall-backend/mailer"
 	"progress-wall-backend/routes"
 	"progress-wall-backend/services"
+	"progress-wall-backend/storage"
 
 	"github.com/robfig/cron/v3"
 )
@@ -18,6 +21,9 @@ import (
 func main() {
 	// 加载配置
 	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("配置错误: %v", err)
+	}
 
 	// 打印配置信息（不打印敏感信息）
 	log.Printf("服务器配置: 端口=%s, 模式=%s, 数据库类型=%s",
@@ -41,13 +47,33 @@ func main() {
 
 	log.Println("数据库初始化完成")
 
+	// 加载JWT签名密钥（非对称模式下首次启动会自动生成）
+	keyService := services.NewKeyService(db, cfg)
+	if err := keyService.Load(); err != nil {
+		log.Fatalf("加载JWT签名密钥失败: %v", err)
+	}
+	log.Printf("JWT签名算法: %s", cfg.JWT.Algorithm)
+
+	// 初始化附件存储
+	store, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatalf("附件存储初始化失败: %v", err)
+	}
+	log.Printf("附件存储: %s", cfg.Storage.Driver)
+
+	// 初始化邮件发送器
+	mail := mailer.New(cfg.Mail)
+	if cfg.Mail.Host == "" {
+		log.Println("未配置SMTP_HOST，邮件内容将仅输出到日志")
+	}
+
 	// 设置路由
-	r := routes.SetupRoutes(db, cfg)
+	r := routes.SetupRoutes(db, cfg, store, mail)
 
 	// 初始化并启动定时任务调度器（核心新增逻辑）
 	var cronInstance *cron.Cron // 声明定时任务实例
-	// 创建调度器实例（传入数据库连接和通知服务URL）(若是后端有了NotificationService，则将URL改成cfg.NotificationService.URL)
-	schedulerIns := services.NewScheduler(db, "http://localhost:8080")
+	// 创建调度器实例（通知服务地址见 cfg.Notification.URL）
+	schedulerIns := services.NewScheduler(db, cfg, keyService, mail)
 	// 启动定时任务，返回cron实例用于后续关闭
 	cronInstance = schedulerIns.Start()
 	defer cronInstance.Stop() // 程序退出时停止定时任务
@@ -3,14 +3,44 @@ package middleware
 import (
 	"net/http"
 	"progress-wall-backend/config"
+	"progress-wall-backend/models"
+	"progress-wall-backend/services"
 	"progress-wall-backend/utils"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
+// passwordChangeAllowed 强制修改密码期间仍可访问的接口
+var passwordChangeAllowed = map[string]bool{
+	"GET /api/user/profile":  true,
+	"PUT /api/user/password": true,
+}
+
+// mfaSetupAllowed 强制启用两步验证期间仍可访问的接口
+var mfaSetupAllowed = map[string]bool{
+	"GET /api/user/profile":      true,
+	"POST /api/user/mfa/enroll":  true,
+	"POST /api/user/mfa/confirm": true,
+}
+
+// restrictedPrefixes 个人访问令牌和模拟登录不能访问的接口前缀（账号安全设置、系统管理和加入团队）
+var restrictedPrefixes = []string{
+	"/api/user/",
+	"/api/admin/",
+	"/api/invitations/",
+}
+
+// impersonationAllowed 模拟登录期间 restrictedPrefixes 中仍可访问的接口
+var impersonationAllowed = map[string]bool{
+	"GET /api/user/profile":     true,
+	"GET /api/user/preferences": true,
+}
+
 // AuthMiddleware JWT认证中间件
-func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+// 除校验签名和有效期外，还会检查令牌所属会话是否已被吊销（退出登录、令牌重用等）
+// 以 pw_ 开头的令牌按个人访问令牌处理，其权限范围保存在上下文的 token_scopes 中
+func AuthMiddleware(cfg *config.Config, sessionService *services.SessionService, tokenService *services.AccessTokenService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 从请求头获取Authorization
 		authHeader := c.GetHeader("Authorization")
@@ -28,19 +58,113 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
-		// 验证token
+		// 个人访问令牌
 		tokenString := parts[1]
+		if strings.HasPrefix(tokenString, models.AccessTokenPrefix) {
+			authenticateAccessToken(c, tokenService, tokenString)
+			return
+		}
+
+		// 验证token
 		claims, err := utils.ValidateToken(tokenString, cfg)
-		if err != nil {
+		if err != nil || claims.Purpose != "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的token"})
 			c.Abort()
 			return
 		}
 
+		// 检查会话是否已被吊销，同时记录最近使用时间
+		state, err := sessionService.ValidateSession(claims.UserID, claims.SessionID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		if !state.Active {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "登录会话已失效，请重新登录"})
+			c.Abort()
+			return
+		}
+
+		// 模拟登录只用于查看和操作用户的业务数据，不能修改账号安全设置或访问系统管理
+		// 强制改密和两步验证要求针对用户本人，模拟登录时不做拦截
+		if claims.ImpersonatorID != 0 && !impersonationAllowed[c.Request.Method+" "+c.FullPath()] && restrictedRoute(c.FullPath()) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "模拟登录期间不能访问该接口", "code": "IMPERSONATION_NOT_ALLOWED"})
+			c.Abort()
+			return
+		}
+
+		// 需要修改密码的用户只能访问修改密码相关接口
+		if claims.ImpersonatorID == 0 && state.MustChangePassword && !passwordChangeAllowed[c.Request.Method+" "+c.FullPath()] {
+			c.JSON(http.StatusForbidden, gin.H{"error": "请先修改初始密码", "code": "PASSWORD_CHANGE_REQUIRED"})
+			c.Abort()
+			return
+		}
+
+		// 策略要求启用两步验证的用户只能访问两步验证设置接口
+		if claims.ImpersonatorID == 0 && !state.MustChangePassword && state.MFASetupRequired && !mfaSetupAllowed[c.Request.Method+" "+c.FullPath()] {
+			c.JSON(http.StatusForbidden, gin.H{"error": "请先启用两步验证", "code": "MFA_SETUP_REQUIRED"})
+			c.Abort()
+			return
+		}
+
 		// 将用户信息存储到上下文
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("session_id", claims.SessionID)
+		if claims.ImpersonatorID != 0 {
+			c.Set("impersonator_id", claims.ImpersonatorID)
+		}
 
 		c.Next()
 	}
 }
+
+// authenticateAccessToken 使用个人访问令牌认证
+func authenticateAccessToken(c *gin.Context, tokenService *services.AccessTokenService, tokenString string) {
+	identity, err := tokenService.Authenticate(tokenString)
+	if err != nil {
+		if err == services.ErrInvalidAccessToken {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		c.Abort()
+		return
+	}
+
+	if restrictedRoute(c.FullPath()) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "该接口不支持使用访问令牌", "code": "ACCESS_TOKEN_NOT_ALLOWED"})
+		c.Abort()
+		return
+	}
+
+	c.Set("user_id", identity.UserID)
+	c.Set("username", identity.Username)
+	c.Set("token_scopes", identity.Scopes)
+
+	c.Next()
+}
+
+// restrictedRoute 判断路由是否属于 restrictedPrefixes
+func restrictedRoute(path string) bool {
+	for _, prefix := range restrictedPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// CurrentActor 返回当前请求的操作者，用于写入活动日志
+// 模拟登录时包含实际操作的管理员ID
+func CurrentActor(c *gin.Context) services.Actor {
+	actor := services.Actor{
+		ID:   c.GetUint("user_id"),
+		Name: c.GetString("username"),
+	}
+	if impersonatorID := c.GetUint("impersonator_id"); impersonatorID != 0 {
+		actor.ImpersonatorID = &impersonatorID
+	}
+	return actor
+}
@@ -5,6 +5,7 @@ import (
 	"progress-wall-backend/models"
 	"progress-wall-backend/services"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -49,10 +50,29 @@ func (m *RBACMiddleware) RequireSysAdmin() gin.HandlerFunc {
 // level: "view" (access) or "manage" (admin).
 // paramKey: the name of the URL parameter containing the ID (e.g., "projectId" or "boardId").
 // idType: "project" (direct project ID) or "board" (board ID, needs resolution to project).
+// "projectKey" accepts either a project ID or a project key such as "WEB".
 func (m *RBACMiddleware) RequireProjectAccess(level string, paramKey string, idType string) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// Personal access tokens also need the matching scope
+		if !CheckScope(c, levelScope(level)) {
+			return
+		}
+
 		userID := c.GetUint("user_id")
 		idStr := c.Param(paramKey)
+
+		// Resolve a project key to its ID first
+		if idType == "projectKey" {
+			if _, err := strconv.ParseUint(idStr, 10, 32); err != nil {
+				var project models.Project
+				if err := m.db.Select("id").Where("project_key = ?", strings.ToUpper(idStr)).First(&project).Error; err != nil {
+					c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+					c.Abort()
+					return
+				}
+				idStr = strconv.FormatUint(uint64(project.ID), 10)
+			}
+		}
 		
 		id, err := strconv.ParseUint(idStr, 10, 32)
 		if err != nil {
@@ -66,7 +86,7 @@ func (m *RBACMiddleware) RequireProjectAccess(level string, paramKey string, idT
 		var projectID uint
 
 		switch idType {
-		case "project":
+		case "project", "projectKey":
 			projectID = resourceID
 
 		case "board":
@@ -132,6 +152,11 @@ func (m *RBACMiddleware) RequireProjectAccess(level string, paramKey string, idT
 // level: "view" or "manage".
 func (m *RBACMiddleware) RequireTeamAccess(level string, paramKey string) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// Personal access tokens also need the matching scope
+		if !CheckScope(c, levelScope(level)) {
+			return
+		}
+
 		userID := c.GetUint("user_id")
 		teamIDStr := c.Param(paramKey)
 		
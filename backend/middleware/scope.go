@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+
+	"progress-wall-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HasScope 判断当前请求是否具备指定的令牌权限范围
+// 通过登录会话认证的请求不受权限范围限制
+func HasScope(c *gin.Context, scope string) bool {
+	value, ok := c.Get("token_scopes")
+	if !ok {
+		return true
+	}
+	scopes, _ := value.([]string)
+	return models.ScopeGrants(scopes, scope)
+}
+
+// CheckScope 在处理器中校验令牌权限范围，不满足时写入403响应并返回false
+func CheckScope(c *gin.Context, scope string) bool {
+	if HasScope(c, scope) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":          "访问令牌权限不足",
+		"code":           "INSUFFICIENT_SCOPE",
+		"required_scope": scope,
+	})
+	c.Abort()
+	return false
+}
+
+// RequireScope 要求访问令牌具备指定权限范围，用于没有项目/团队权限检查的路由
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !CheckScope(c, scope) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// levelScope 项目/团队权限级别对应的令牌权限范围
+func levelScope(level string) string {
+	if level == "manage" {
+		return models.ScopeProjectsAdmin
+	}
+	return models.ScopeTasksRead
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+// AuditLog 安全审计日志表，记录登录锁定、解锁等账号安全事件
+// 与面向团队成员展示的 ActivityLog 不同，审计日志只对系统管理员开放
+type AuditLog struct {
+	ID           uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Action       string    `json:"action" gorm:"size:50;not null;index"`
+	ActorID      *uint     `json:"actor_id" gorm:"index;comment:'操作者ID，系统自动触发时为空'"`
+	TargetUserID *uint     `json:"target_user_id" gorm:"index;comment:'受影响的用户ID'"`
+	Subject      string    `json:"subject" gorm:"size:255;comment:'事件对象，如登录标识或IP'"`
+	IP           string    `json:"ip" gorm:"size:45"`
+	UserAgent    string    `json:"user_agent" gorm:"size:255"`
+	Detail       string    `json:"detail" gorm:"type:text"`
+	CreatedAt    time.Time `json:"created_at" gorm:"index"`
+}
+
+// 审计事件类型
+const (
+	AuditLoginLocked       = "login.locked"        // 账号连续登录失败被临时锁定
+	AuditLoginIPLocked     = "login.ip_locked"     // IP连续登录失败被临时锁定
+	AuditLoginUnlocked     = "login.unlocked"      // 管理员解除账号锁定
+	AuditLDAPUserDisabled  = "ldap.user_disabled"  // 用户已从目录移除，同步时被禁用
+	AuditUserEnabled       = "user.enabled"        // 管理员启用用户
+	AuditUserDisabled      = "user.disabled"       // 管理员禁用用户
+	AuditUserRoleChanged   = "user.role_changed"   // 管理员修改系统角色
+	AuditUserPasswordReset = "user.password_reset" // 管理员强制重置密码
+	AuditUserDeleted       = "user.deleted"        // 管理员删除用户
+	AuditUserImpersonated  = "user.impersonated"   // 管理员模拟登录用户
+	AuditAccountExported   = "account.exported"    // 用户导出个人数据
+	AuditAccountDeleted    = "account.deleted"     // 用户注销账号
+)
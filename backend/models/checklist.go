@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// ChecklistItem 任务检查项
+type ChecklistItem struct {
+	ID          uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	TaskID      uint       `json:"task_id" gorm:"not null;index"`
+	Content     string     `json:"content" gorm:"size:500;not null"`
+	Done        bool       `json:"done" gorm:"not null;comment:'是否已完成'"`
+	Position    int        `json:"position" gorm:"not null;comment:'检查项在任务中的排序位置'"`
+	AssigneeID  *uint      `json:"assignee_id" gorm:"index"`
+	DueDate     *time.Time `json:"due_date"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// 关联关系
+	Assignee *User `json:"assignee,omitempty" gorm:"foreignKey:AssigneeID"`
+}
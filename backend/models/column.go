@@ -15,6 +15,7 @@ type Column struct {
 	Position    int            `json:"position" gorm:"not null;comment:'列在看板中的排序位置'"`
 	BoardID     uint           `json:"board_id" gorm:"not null;index"`
 	Status      ColumnStatus   `json:"status" gorm:"type:tinyint;default:1;comment:'列状态:1=正常,2=禁用'"`
+	IsDone      bool           `json:"is_done" gorm:"not null;default:false;comment:'是否为完成列'"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CustomField 项目自定义字段定义
+type CustomField struct {
+	ID        uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	ProjectID uint           `json:"project_id" gorm:"not null;index"`
+	Name      string         `json:"name" gorm:"size:50;not null"`
+	Type      string         `json:"type" gorm:"size:20;not null;comment:'字段类型:text/number/date/select/multi_select/user/checkbox'"`
+	Options   []string       `json:"options,omitempty" gorm:"type:text;serializer:json;comment:'单选和多选字段的可选项'"`
+	Required  bool           `json:"required" gorm:"not null;comment:'创建任务时必须填写'"`
+	Position  int            `json:"position" gorm:"not null;comment:'字段在项目中的排序位置'"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// 自定义字段类型
+const (
+	CustomFieldText        = "text"
+	CustomFieldNumber      = "number"
+	CustomFieldDate        = "date"
+	CustomFieldSelect      = "select"
+	CustomFieldMultiSelect = "multi_select"
+	CustomFieldUser        = "user"
+	CustomFieldCheckbox    = "checkbox"
+)
+
+// ValidCustomFieldType 是否为支持的自定义字段类型
+func ValidCustomFieldType(fieldType string) bool {
+	switch fieldType {
+	case CustomFieldText, CustomFieldNumber, CustomFieldDate, CustomFieldSelect,
+		CustomFieldMultiSelect, CustomFieldUser, CustomFieldCheckbox:
+		return true
+	}
+	return false
+}
+
+// CustomFieldValue 任务的自定义字段值
+// Value 以 JSON 文本保存：文本、日期（YYYY-MM-DD）和单选为字符串，数字和用户ID为数字，多选为字符串数组，勾选框为布尔值
+type CustomFieldValue struct {
+	ID        uint        `json:"-" gorm:"primaryKey;autoIncrement"`
+	TaskID    uint        `json:"-" gorm:"not null;uniqueIndex:idx_task_custom_field"`
+	FieldID   uint        `json:"field_id" gorm:"not null;uniqueIndex:idx_task_custom_field;index"`
+	Value     interface{} `json:"value" gorm:"type:text;serializer:json;not null"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
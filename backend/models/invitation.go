@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// InvitationStatus 邀请状态
+type InvitationStatus int
+
+const (
+	InvitationStatusActive  InvitationStatus = 1 // 有效
+	InvitationStatusRevoked InvitationStatus = 2 // 已撤销
+)
+
+// Invitation 团队/项目邀请表
+// 邀请链接中的令牌只在创建时返回一次，数据库中仅保存其SHA-256哈希
+type Invitation struct {
+	ID        uint             `json:"id" gorm:"primaryKey;autoIncrement"`
+	TokenHash string           `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	TeamID    uint             `json:"team_id" gorm:"not null;index;comment:'目标团队ID，项目邀请时为项目所属团队'"`
+	ProjectID *uint            `json:"project_id" gorm:"index;comment:'目标项目ID，为空表示团队邀请'"`
+	Email     string           `json:"email" gorm:"size:100;comment:'受邀邮箱，为空表示任何人可通过链接加入'"`
+	Role      int              `json:"role" gorm:"type:tinyint;default:1;comment:'加入后的角色:1=成员,2=管理员'"`
+	InviterID uint             `json:"inviter_id" gorm:"not null;index"`
+	ExpiresAt time.Time        `json:"expires_at" gorm:"not null"`
+	MaxUses   int              `json:"max_uses" gorm:"not null;default:0;comment:'最大使用次数，0表示不限'"`
+	UseCount  int              `json:"use_count" gorm:"default:0"`
+	Status    InvitationStatus `json:"status" gorm:"type:tinyint;default:1;comment:'邀请状态:1=有效,2=已撤销'"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+
+	// 关联关系
+	Team    Team     `json:"-" gorm:"foreignKey:TeamID"`
+	Project *Project `json:"-" gorm:"foreignKey:ProjectID"`
+	Inviter *User    `json:"inviter,omitempty" gorm:"foreignKey:InviterID"`
+}
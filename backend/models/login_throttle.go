@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// 登录失败计数的维度
+const (
+	ThrottleScopeAccount = "account" // 按账号计数（用户不存在时按登录标识计数）
+	ThrottleScopeIP      = "ip"      // 按客户端IP计数
+)
+
+// LoginThrottle 登录失败计数表
+// 持久化保存失败次数和锁定截止时间，服务重启后限制依然有效
+type LoginThrottle struct {
+	ID            uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Scope         string     `json:"scope" gorm:"size:16;not null;uniqueIndex:idx_login_throttle_subject"`
+	Subject       string     `json:"subject" gorm:"size:255;not null;uniqueIndex:idx_login_throttle_subject;comment:'账号标识（user:ID 或 name:登录名）或IP'"`
+	Failures      int        `json:"failures" gorm:"not null;default:0"`
+	LastFailureAt time.Time  `json:"last_failure_at" gorm:"index"`
+	LockedUntil   *time.Time `json:"locked_until"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
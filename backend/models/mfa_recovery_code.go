@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+)
+
+// MFARecoveryCode 两步验证恢复码表
+// 每个恢复码只能使用一次，数据库中仅保存其SHA-256哈希
+type MFARecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	CodeHash  string     `json:"-" gorm:"size:64;not null;index"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
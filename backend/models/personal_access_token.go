@@ -0,0 +1,68 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// AccessTokenPrefix 个人访问令牌前缀，用于和JWT区分
+const AccessTokenPrefix = "pw_"
+
+// 个人访问令牌权限范围，高级范围包含低级范围：projects:admin ⊃ tasks:write ⊃ tasks:read
+const (
+	ScopeTasksRead     = "tasks:read"     // 查看团队、项目、看板和任务
+	ScopeTasksWrite    = "tasks:write"    // 创建和修改任务、评论、附件
+	ScopeProjectsAdmin = "projects:admin" // 管理项目、看板、列、标签和成员
+)
+
+// TokenScopes 所有可用的权限范围，按包含关系从低到高排列
+var TokenScopes = []string{ScopeTasksRead, ScopeTasksWrite, ScopeProjectsAdmin}
+
+// PersonalAccessToken 个人访问令牌表，供脚本和CI使用
+// 明文令牌只在创建时返回一次，数据库仅保存哈希
+type PersonalAccessToken struct {
+	ID          uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID      uint       `json:"user_id" gorm:"not null;index"`
+	Name        string     `json:"name" gorm:"size:100;not null"`
+	TokenPrefix string     `json:"token_prefix" gorm:"size:16;not null;comment:'令牌前几位，便于用户辨认'"`
+	TokenHash   string     `json:"-" gorm:"size:64;not null;uniqueIndex;comment:'令牌SHA-256哈希'"`
+	Scopes      string     `json:"-" gorm:"size:255;not null;comment:'权限范围，空格分隔'"`
+	ExpiresAt   *time.Time `json:"expires_at" gorm:"comment:'过期时间，为空表示永不过期'"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// ScopeList 返回令牌的权限范围列表
+func (t *PersonalAccessToken) ScopeList() []string {
+	return strings.Fields(t.Scopes)
+}
+
+// ValidScope 判断是否为可用的权限范围
+func ValidScope(scope string) bool {
+	return scopeLevel(scope) >= 0
+}
+
+// ScopeGrants 判断已授予的权限范围是否满足所需范围（考虑包含关系）
+func ScopeGrants(granted []string, required string) bool {
+	level := scopeLevel(required)
+	if level < 0 {
+		return false
+	}
+	for _, scope := range granted {
+		if scopeLevel(scope) >= level {
+			return true
+		}
+	}
+	return false
+}
+
+// scopeLevel 返回权限范围在包含关系中的级别，未知范围返回-1
+func scopeLevel(scope string) int {
+	for i, s := range TokenScopes {
+		if s == scope {
+			return i
+		}
+	}
+	return -1
+}
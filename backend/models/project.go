@@ -1,6 +1,9 @@
 package models
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -11,6 +14,8 @@ import (
 type Project struct {
 	ID          uint           `json:"id" gorm:"primaryKey;autoIncrement" comment:"项目唯一标识符，自增主键"`
 	Name        string         `json:"name" gorm:"size:100;not null" comment:"项目名称，最大100字符，必填"`
+	Key         string         `json:"key" gorm:"column:project_key;size:10;uniqueIndex" comment:"项目 key，任务编号的前缀，如 WEB"`
+	TaskSeq     uint           `json:"-" gorm:"not null;default:0" comment:"项目内任务编号计数器，记录最后分配的编号"`
 	Description string         `json:"description" gorm:"type:text" comment:"项目描述，文本类型"`
 	Status      ProjectStatus  `json:"status" gorm:"type:tinyint;default:1;comment:'项目状态:1=进行中,2=已完成,3=已暂停,4=已取消'" comment:"项目状态，1=进行中，2=已完成，3=已暂停，4=已取消"`
 	StartDate   *time.Time     `json:"start_date" comment:"项目开始时间，可为空"`
@@ -69,3 +74,34 @@ const (
 	ProjectRoleMember ProjectRole = 1
 	ProjectRoleAdmin ProjectRole = 2
 )
+
+var projectKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{1,9}$`)
+
+// ValidProjectKey 项目 key 由大写字母开头，共 2-10 位大写字母或数字
+func ValidProjectKey(key string) bool {
+	return projectKeyPattern.MatchString(key)
+}
+
+// DefaultProjectKey 根据项目名称生成默认 key：取名称中的英文字母和数字，最多 4 位；不足 2 位时为 PRJ
+func DefaultProjectKey(name string) string {
+	var key []byte
+	for _, r := range strings.ToUpper(name) {
+		isLetter := r >= 'A' && r <= 'Z'
+		isDigit := r >= '0' && r <= '9'
+		if isLetter || (isDigit && len(key) > 0) {
+			key = append(key, byte(r))
+		}
+		if len(key) == 4 {
+			break
+		}
+	}
+	if len(key) < 2 {
+		return "PRJ"
+	}
+	return string(key)
+}
+
+// TaskKey 任务编号，如 WEB-123
+func TaskKey(projectKey string, number uint) string {
+	return fmt.Sprintf("%s-%d", projectKey, number)
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// Session 登录会话表（刷新令牌）
+// 每次刷新都会轮换出一条新记录并标记旧记录已轮换；同一次登录产生的记录共享 FamilyID，
+// 访问令牌中的 sid 即为 FamilyID。已轮换的令牌再次被使用时整个会话族会被吊销
+type Session struct {
+	ID         uint       `json:"-" gorm:"primaryKey;autoIncrement"`
+	FamilyID   string     `json:"family_id" gorm:"size:64;not null;index"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	TokenHash  string     `json:"-" gorm:"size:64;not null;uniqueIndex;comment:'刷新令牌SHA-256哈希'"`
+	IP         string     `json:"ip" gorm:"size:45"`
+	UserAgent  string     `json:"user_agent" gorm:"size:255"`
+	LoginAt    time.Time  `json:"login_at" gorm:"not null;comment:'会话族首次登录时间'"`
+	LastSeenAt time.Time  `json:"last_seen_at" gorm:"comment:'最近一次使用时间'"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null;index"`
+	RotatedAt  *time.Time `json:"-"`
+	RevokedAt  *time.Time `json:"-" gorm:"index"`
+	CreatedAt  time.Time  `json:"-"`
+	UpdatedAt  time.Time  `json:"-"`
+	// ImpersonatorID 管理员模拟登录会话的发起人，该会话没有可用的刷新令牌
+	ImpersonatorID *uint `json:"impersonator_id,omitempty" gorm:"index"`
+}
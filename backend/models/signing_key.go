@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// SigningKey JWT签名密钥表
+// 同一时刻只有一个密钥用于签名；轮换时新密钥先发布到 JWKS，延迟一段时间后才开始签名，
+// 旧密钥停止签名后继续保留到其签发的令牌全部过期
+type SigningKey struct {
+	ID          uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Kid         string     `json:"kid" gorm:"size:64;not null;uniqueIndex"`
+	Algorithm   string     `json:"algorithm" gorm:"size:16;not null"`
+	PrivateKey  string     `json:"-" gorm:"type:text;not null;comment:'使用JWT_SECRET派生密钥加密的私钥'"`
+	PublicKey   string     `json:"public_key" gorm:"type:text;not null"`
+	ActivatesAt time.Time  `json:"activates_at" gorm:"not null;comment:'开始用于签名的时间'"`
+	RetiredAt   *time.Time `json:"retired_at" gorm:"comment:'停止签名的时间'"`
+	ExpiresAt   *time.Time `json:"expires_at" gorm:"index;comment:'停止验证并从JWKS移除的时间'"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
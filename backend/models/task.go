@@ -21,21 +21,38 @@ type Task struct {
 	ActualHours       *float64       `json:"actual_hours" gorm:"type:decimal(8,2);comment:'实际工时(小时)'"`
 	ColumnID          uint           `json:"column_id" gorm:"not null;index"`
 	CreatorID         uint           `json:"creator_id" gorm:"not null;index"`
-	AssigneeID        *uint          `json:"assignee_id" gorm:"index"`
-	ProjectID         uint           `json:"project_id" gorm:"not null;index"`
+	ProjectID         uint           `json:"project_id" gorm:"not null;index;uniqueIndex:idx_task_project_number"`
+	Number            uint           `json:"number" gorm:"not null;default:0;uniqueIndex:idx_task_project_number;comment:'项目内任务编号'"`
+	ParentTaskID      *uint          `json:"parent_task_id" gorm:"index;comment:'父任务ID'"`
+	AutoComplete      bool           `json:"auto_complete" gorm:"not null;default:false;comment:'子任务全部完成时自动完成'"`
 	CreatedAt         time.Time      `json:"created_at"`
 	UpdatedAt         time.Time      `json:"updated_at"`
 	DeletedAt         gorm.DeletedAt `json:"-" gorm:"index"`
 	DeadlineAlertSent int            `json:"deadline_alert_sent" gorm:"default:0;index;comment:'是否发送提醒:1=已发送,0=未发送'"` // 新增字段：是否已发送提醒
 
+	// 任务编号，如 WEB-123，由项目 key 和 Number 组成
+	Key string `json:"key" gorm:"-"`
+
+	// 检查项和子任务进度，仅在查询看板、任务详情和子任务列表时填充
+	ChecklistTotal int `json:"checklist_total" gorm:"-"`
+	ChecklistDone  int `json:"checklist_done" gorm:"-"`
+	SubtaskTotal   int `json:"subtask_total" gorm:"-"`
+	SubtaskDone    int `json:"subtask_done" gorm:"-"`
+
 	// 关联关系
-	Column      Column       `json:"column,omitempty" gorm:"foreignKey:ColumnID"`
-	Creator     User         `json:"creator,omitempty" gorm:"foreignKey:CreatorID"`
-	Assignee    *User        `json:"assignee,omitempty" gorm:"foreignKey:AssigneeID"`
-	Project     Project      `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
-	Comments    []Comment    `json:"comments,omitempty" gorm:"foreignKey:TaskID"`
-	Attachments []Attachment `json:"attachments,omitempty" gorm:"foreignKey:TaskID"`
-	Labels      []Label      `json:"labels,omitempty" gorm:"many2many:task_labels"`
+	Column      Column          `json:"column,omitempty" gorm:"foreignKey:ColumnID"`
+	Creator     User            `json:"creator,omitempty" gorm:"foreignKey:CreatorID"`
+	Assignees   []User          `json:"assignees,omitempty" gorm:"many2many:task_assignees"`
+	Watchers    []User          `json:"watchers,omitempty" gorm:"many2many:task_watchers"`
+	Project     Project         `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
+	Comments    []Comment       `json:"comments,omitempty" gorm:"foreignKey:TaskID"`
+	Attachments []Attachment    `json:"attachments,omitempty" gorm:"foreignKey:TaskID"`
+	Labels      []Label         `json:"labels,omitempty" gorm:"many2many:task_labels"`
+	Checklist   []ChecklistItem `json:"checklist,omitempty" gorm:"foreignKey:TaskID"`
+	Subtasks    []Task          `json:"subtasks,omitempty" gorm:"foreignKey:ParentTaskID"`
+
+	// 自定义字段值，字段定义见项目的 CustomField
+	CustomFields []CustomFieldValue `json:"custom_fields,omitempty" gorm:"foreignKey:TaskID"`
 }
 
 // TaskPriority 任务优先级枚举
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// TaskAssignee 任务负责人关联表，一个任务可以有多个负责人
+type TaskAssignee struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	TaskID    uint      `json:"task_id" gorm:"not null;uniqueIndex:idx_task_assignee"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_task_assignee;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TaskWatcher 任务关注者关联表，关注者接收任务通知但不负责任务
+type TaskWatcher struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	TaskID    uint      `json:"task_id" gorm:"not null;uniqueIndex:idx_task_watcher"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_task_watcher;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// TaskLink 任务之间的关联，只能在同一项目内建立
+// blocks 表示源任务阻塞目标任务；relates_to 无方向；duplicates 表示源任务与目标任务重复
+type TaskLink struct {
+	ID           uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	SourceTaskID uint      `json:"source_task_id" gorm:"not null;index"`
+	TargetTaskID uint      `json:"target_task_id" gorm:"not null;index"`
+	LinkType     string    `json:"link_type" gorm:"size:20;not null;comment:'关联类型:blocks/relates_to/duplicates'"`
+	CreatorID    uint      `json:"creator_id" gorm:"not null"`
+	CreatedAt    time.Time `json:"created_at"`
+
+	// 关联关系
+	SourceTask Task `json:"-" gorm:"foreignKey:SourceTaskID"`
+	TargetTask Task `json:"-" gorm:"foreignKey:TargetTaskID"`
+}
+
+// 任务关联类型
+const (
+	TaskLinkBlocks     = "blocks"
+	TaskLinkRelatesTo  = "relates_to"
+	TaskLinkDuplicates = "duplicates"
+)
+
+// 从目标任务一侧看到的反向关联类型，仅用于接口
+const (
+	TaskLinkBlockedBy    = "blocked_by"
+	TaskLinkDuplicatedBy = "duplicated_by"
+)
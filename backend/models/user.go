@@ -12,12 +12,17 @@ type User struct {
 	ID        uint           `json:"id" gorm:"primaryKey;autoIncrement" comment:"用户唯一标识符，自增主键"`
 	Username  string         `json:"username" gorm:"uniqueIndex;size:50;not null" comment:"用户名，唯一标识，最大50字符"`
 	Email     string         `json:"email" gorm:"uniqueIndex;size:100;not null" comment:"邮箱地址，唯一标识，最大100字符"`
+	EmailVerified bool       `json:"email_verified" gorm:"default:false" comment:"邮箱是否已验证"`
 	Password  string         `json:"-" gorm:"size:255;not null" comment:"密码哈希值，不在JSON中返回，最大255字符"`
 	Nickname  string         `json:"nickname" gorm:"size:50" comment:"用户昵称，用于显示，最大50字符"`
 	Avatar    string         `json:"avatar" gorm:"size:255" comment:"头像URL地址，最大255字符"`
 	Phone     string         `json:"phone" gorm:"size:20" comment:"手机号码，最大20字符"`
 	Status    UserStatus     `json:"status" gorm:"type:tinyint;default:1;comment:'用户状态:1=正常,2=禁用,3=删除'"`
 	SystemRole SystemRole    `json:"system_role" gorm:"type:tinyint;default:1;comment:'系统角色: 1=普通用户, 2=系统管理员'"`
+	MustChangePassword bool  `json:"must_change_password" gorm:"default:false" comment:"是否需要在下次登录后修改密码"`
+	TOTPEnabled bool         `json:"totp_enabled" gorm:"column:totp_enabled;default:false" comment:"是否已启用TOTP两步验证"`
+	TOTPSecret  string       `json:"-" gorm:"column:totp_secret;size:64" comment:"TOTP密钥（Base32），启用前为待确认密钥"`
+	TOTPLastStep int64       `json:"-" gorm:"column:totp_last_step;default:0" comment:"最近一次使用的TOTP时间步，防止验证码重放"`
 	LastLogin *time.Time     `json:"last_login" comment:"最后登录时间，可为空"`
 	CreatedAt time.Time      `json:"created_at" comment:"创建时间"`
 	UpdatedAt time.Time      `json:"updated_at" comment:"更新时间"`
@@ -26,7 +31,7 @@ type User struct {
 	// 关联关系
 	Projects         []Project             `json:"projects,omitempty" gorm:"many2many:project_members"`
 	CreatedTasks     []Task                `json:"created_tasks,omitempty" gorm:"foreignKey:CreatorID"`
-	AssignedTasks    []Task                `json:"assigned_tasks,omitempty" gorm:"foreignKey:AssigneeID"`
+	AssignedTasks    []Task                `json:"assigned_tasks,omitempty" gorm:"many2many:task_assignees"`
 	Comments         []Comment             `json:"comments,omitempty" gorm:"foreignKey:UserID"`
 }
 
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// UserIdentity 外部身份提供方账号与本地用户的关联
+// 同一提供方的同一 subject 只能关联一个本地用户
+type UserIdentity struct {
+	ID          uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID      uint       `json:"user_id" gorm:"not null;index"`
+	Provider    string     `json:"provider" gorm:"size:50;not null;uniqueIndex:idx_user_identity_subject"`
+	Subject     string     `json:"subject" gorm:"size:255;not null;uniqueIndex:idx_user_identity_subject;comment:'提供方用户唯一标识（sub）'"`
+	Email       string     `json:"email" gorm:"size:100"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// LDAPIdentityProvider 目录用户外部身份的提供方标识，OIDC 提供方不能使用该名称
+const LDAPIdentityProvider = "ldap"
+
+// OIDCLoginState 进行中的单点登录请求
+// state 明文保存在浏览器 Cookie 和回调参数中，数据库仅保存其哈希；回调时一次性删除
+type OIDCLoginState struct {
+	ID           uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	StateHash    string    `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	Provider     string    `json:"provider" gorm:"size:50;not null"`
+	Nonce        string    `json:"-" gorm:"size:64;not null"`
+	CodeVerifier string    `json:"-" gorm:"size:128;not null;comment:'PKCE校验码'"`
+	RedirectPath string    `json:"redirect_path" gorm:"size:255;comment:'登录完成后前端跳转的路径'"`
+	ExpiresAt    time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+)
+
+// UserPreferences 用户偏好设置，每个用户最多一条，未设置时使用默认值
+type UserPreferences struct {
+	ID                 uint      `json:"-" gorm:"primaryKey;autoIncrement"`
+	UserID             uint      `json:"-" gorm:"not null;uniqueIndex"`
+	Timezone           string    `json:"timezone" gorm:"size:64;not null;comment:'IANA时区，如 Asia/Shanghai'"`
+	Locale             string    `json:"locale" gorm:"size:16;not null"`
+	DateFormat         string    `json:"date_format" gorm:"size:16;not null"`
+	DefaultBoardID     *uint     `json:"default_board_id"`
+	EmailNotifications bool      `json:"email_notifications" gorm:"not null;comment:'是否接收邮件通知'"`
+	InAppNotifications bool      `json:"in_app_notifications" gorm:"not null;comment:'是否接收站内通知'"`
+	CreatedAt          time.Time `json:"-"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// 支持的语言
+const (
+	LocaleZhCN = "zh-CN"
+	LocaleEnUS = "en-US"
+)
+
+// DateFormats 支持的日期格式及对应的 Go 时间布局
+var DateFormats = map[string]string{
+	"YYYY-MM-DD": "2006-01-02",
+	"YYYY/MM/DD": "2006/01/02",
+	"DD/MM/YYYY": "02/01/2006",
+	"MM/DD/YYYY": "01/02/2006",
+}
+
+// Location 返回偏好时区，无效时返回 UTC
+// 通过服务层读取的偏好已将空或无效的时区替换为服务器默认时区（SERVER_TIMEZONE），这里不会回退到主机时区
+func (p *UserPreferences) Location() *time.Location {
+	if p.Timezone != "" && p.Timezone != "Local" {
+		if loc, err := time.LoadLocation(p.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+// FormatTime 按偏好的时区和日期格式显示时间（精确到分钟）
+func (p *UserPreferences) FormatTime(t time.Time) string {
+	layout, ok := DateFormats[p.DateFormat]
+	if !ok {
+		layout = DateFormats["YYYY-MM-DD"]
+	}
+	return t.In(p.Location()).Format(layout + " 15:04")
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// UserTokenPurpose 一次性令牌用途
+type UserTokenPurpose string
+
+const (
+	UserTokenPasswordReset UserTokenPurpose = "password_reset" // 找回密码
+	UserTokenEmailVerify   UserTokenPurpose = "email_verify"   // 验证注册邮箱
+	UserTokenEmailChange   UserTokenPurpose = "email_change"   // 确认新邮箱
+	UserTokenOIDCLogin     UserTokenPurpose = "oidc_login"     // 单点登录回调后前端换取登录令牌
+)
+
+// UserToken 发送给用户的一次性令牌（找回密码、邮箱验证等）
+// 令牌明文只出现在邮件链接中，数据库仅保存其SHA-256哈希
+type UserToken struct {
+	ID        uint             `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID    uint             `json:"user_id" gorm:"not null;index"`
+	Purpose   UserTokenPurpose `json:"purpose" gorm:"size:32;not null;index"`
+	TokenHash string           `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	Email     string           `json:"email" gorm:"size:100;comment:'待确认的邮箱地址'"`
+	ExpiresAt time.Time        `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time       `json:"used_at"`
+	CreatedAt time.Time        `json:"created_at"`
+}
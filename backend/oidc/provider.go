@@ -0,0 +1,341 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"progress-wall-backend/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// keyRefreshInterval 遇到未知 kid 时重新拉取 JWKS 的最小间隔
+const keyRefreshInterval = time.Minute
+
+// Provider OpenID Connect 身份提供方客户端（授权码 + PKCE 流程）
+type Provider struct {
+	cfg         config.OIDCProviderConfig
+	redirectURL string
+	client      *http.Client
+
+	mu            sync.Mutex
+	metadata      *metadata
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+// metadata 提供方发现文档中用到的字段
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Token 令牌端点返回的令牌
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+}
+
+// Identity 从ID令牌和用户信息端点得到的用户身份
+type Identity struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+	Groups            []string
+}
+
+// NewProvider 创建身份提供方客户端，端点在首次使用时通过发现文档获取
+func NewProvider(cfg config.OIDCProviderConfig, redirectURL string) *Provider {
+	return &Provider{
+		cfg:         cfg,
+		redirectURL: redirectURL,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Config 返回提供方配置
+func (p *Provider) Config() config.OIDCProviderConfig {
+	return p.cfg
+}
+
+// AuthCodeURL 生成跳转到提供方登录页的授权地址
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(codeVerifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.redirectURL},
+		"scope":                 {strings.Join(p.cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(md.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return md.AuthorizationEndpoint + sep + query.Encode(), nil
+}
+
+// Exchange 使用授权码和 PKCE 校验码换取令牌
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (*Token, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.redirectURL},
+		"code_verifier": {codeVerifier},
+		"client_id":     {p.cfg.ClientID},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, md.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	var token Token
+	if err := p.doJSON(req, &token); err != nil {
+		return nil, fmt.Errorf("换取令牌失败: %v", err)
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("提供方未返回 id_token")
+	}
+	return &token, nil
+}
+
+// Identify 校验ID令牌（签名、颁发者、受众、有效期和 nonce），
+// ID令牌缺少邮箱或用户组时再从用户信息端点补充
+func (p *Provider) Identify(ctx context.Context, token *Token, nonce string) (*Identity, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(token.IDToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "ES256", "ES384", "EdDSA"}),
+		jwt.WithIssuer(md.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ID令牌校验失败: %v", err)
+	}
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, errors.New("ID令牌 nonce 不匹配")
+	}
+	if azp, ok := claims["azp"].(string); ok && azp != p.cfg.ClientID {
+		return nil, errors.New("ID令牌 azp 不匹配")
+	}
+
+	identity := identityFromClaims(claims, p.cfg.GroupsClaim)
+	if identity.Subject == "" {
+		return nil, errors.New("ID令牌缺少 sub")
+	}
+
+	if (identity.Email == "" || identity.Groups == nil) && md.UserinfoEndpoint != "" && token.AccessToken != "" {
+		info, err := p.userInfo(ctx, md.UserinfoEndpoint, token.AccessToken)
+		if err != nil {
+			return nil, err
+		}
+		extra := identityFromClaims(info, p.cfg.GroupsClaim)
+		if extra.Subject != identity.Subject {
+			return nil, errors.New("用户信息 sub 与ID令牌不一致")
+		}
+		if identity.Email == "" {
+			identity.Email, identity.EmailVerified = extra.Email, extra.EmailVerified
+		}
+		if identity.Groups == nil {
+			identity.Groups = extra.Groups
+		}
+		if identity.Name == "" {
+			identity.Name = extra.Name
+		}
+		if identity.PreferredUsername == "" {
+			identity.PreferredUsername = extra.PreferredUsername
+		}
+	}
+	return identity, nil
+}
+
+// userInfo 调用用户信息端点
+func (p *Provider) userInfo(ctx context.Context, endpoint, accessToken string) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	info := map[string]interface{}{}
+	if err := p.doJSON(req, &info); err != nil {
+		return nil, fmt.Errorf("获取用户信息失败: %v", err)
+	}
+	return info, nil
+}
+
+// discover 获取并缓存提供方发现文档
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var md metadata
+	if err := p.doJSON(req, &md); err != nil {
+		return nil, fmt.Errorf("获取 OIDC 发现文档失败: %v", err)
+	}
+	if strings.TrimRight(md.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("发现文档中的 issuer %q 与配置不一致", md.Issuer)
+	}
+	if md.AuthorizationEndpoint == "" || md.TokenEndpoint == "" || md.JWKSURI == "" {
+		return nil, errors.New("发现文档缺少必要的端点")
+	}
+	p.metadata = &md
+	return p.metadata, nil
+}
+
+// key 按 kid 查找验证公钥，找不到时重新拉取 JWKS（限制频率）
+func (p *Provider) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key := p.lookupKey(kid); key != nil {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < keyRefreshInterval {
+		return nil, fmt.Errorf("未知的签名密钥 %q", kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.metadata.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.doJSON(req, &set); err != nil {
+		return nil, fmt.Errorf("获取 JWKS 失败: %v", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	if key := p.lookupKey(kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("未知的签名密钥 %q", kid)
+}
+
+// lookupKey 在缓存中查找公钥；令牌未带 kid 且只有一个公钥时使用该公钥
+func (p *Provider) lookupKey(kid string) crypto.PublicKey {
+	if key, ok := p.keys[kid]; ok {
+		return key
+	}
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key
+		}
+	}
+	return nil
+}
+
+// doJSON 发送请求并解析JSON响应
+func (p *Provider) doJSON(req *http.Request, out interface{}) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s 返回状态码 %d: %s", req.URL.Path, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, out)
+}
+
+// identityFromClaims 从声明中提取用户身份
+func identityFromClaims(claims map[string]interface{}, groupsClaim string) *Identity {
+	identity := &Identity{}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+	identity.PreferredUsername, _ = claims["preferred_username"].(string)
+
+	// 部分提供方将 email_verified 编码为字符串
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = v
+	case string:
+		identity.EmailVerified = v == "true"
+	}
+
+	switch v := claims[groupsClaim].(type) {
+	case []interface{}:
+		identity.Groups = []string{}
+		for _, g := range v {
+			if s, ok := g.(string); ok {
+				identity.Groups = append(identity.Groups, s)
+			}
+		}
+	case string:
+		identity.Groups = strings.Fields(strings.ReplaceAll(v, ",", " "))
+	}
+	return identity
+}
@@ -1,20 +1,30 @@
 package routes
 
 import (
+	"log"
 	"strings"
 
 	"progress-wall-backend/config"
 	"progress-wall-backend/handlers/activity"
+	"progress-wall-backend/handlers/admin"
+	"progress-wall-backend/handlers/attachment"
 	"progress-wall-backend/handlers/auth"
 	"progress-wall-backend/handlers/board"
 	"progress-wall-backend/handlers/column"
+	"progress-wall-backend/handlers/comment"
+	"progress-wall-backend/handlers/customfield"
+	"progress-wall-backend/handlers/invitation"
+	"progress-wall-backend/handlers/label"
 	"progress-wall-backend/handlers/notification"
 	"progress-wall-backend/handlers/project"
 	"progress-wall-backend/handlers/task"
 	"progress-wall-backend/handlers/team"
 	"progress-wall-backend/handlers/user"
+	"progress-wall-backend/mailer"
 	"progress-wall-backend/middleware"
+	"progress-wall-backend/models"
 	"progress-wall-backend/services"
+	"progress-wall-backend/storage"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -22,13 +32,17 @@ import (
 )
 
 // SetupRoutes 设置路由
-func SetupRoutes(db *gorm.DB, cfg *config.Config) *gin.Engine {
+func SetupRoutes(db *gorm.DB, cfg *config.Config, store storage.Storage, mail mailer.Mailer) *gin.Engine {
 	// 根据配置设置Gin模式
 	if cfg.Server.Mode == "release" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	r := gin.Default()
+	// 未配置可信代理时不信任任何转发头，ClientIP 直接使用连接的远端地址
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("设置可信代理失败: %v", err)
+	}
 
 	// 配置CORS
 	corsConfig := cors.DefaultConfig()
@@ -47,51 +61,102 @@ func SetupRoutes(db *gorm.DB, cfg *config.Config) *gin.Engine {
 
 	r.Use(cors.New(corsConfig))
 
-	// 静态文件服务
+	// 静态文件服务（仅头像等公开资源，任务附件通过鉴权接口下载）
 	r.Static("/uploads", "./uploads")
 
 	permService := services.NewPermissionService(db)
 	rbac := middleware.NewRBACMiddleware(permService, db)
 
 	// 初始化处理器
-	loginHandler := auth.NewLoginHandler(db, cfg)
-	registerHandler := auth.NewRegisterHandler(db, cfg)
+	loginHandler := auth.NewLoginHandler(db, cfg, mail)
+	registerHandler := auth.NewRegisterHandler(db, cfg, mail)
+	tokenHandler := auth.NewTokenHandler(db, cfg)
+	passwordHandler := auth.NewPasswordHandler(db, mail, cfg)
+	emailHandler := auth.NewEmailHandler(db, mail, cfg)
+	mfaHandler := auth.NewMFAHandler(db, cfg)
+	adminUserHandler := admin.NewUserHandler(db, mail, cfg)
+	auditHandler := admin.NewAuditHandler(db)
+	ldapHandler := admin.NewLDAPHandler(db, cfg)
 	profileHandler := user.NewProfileHandler(db)
+	sessionHandler := user.NewSessionHandler(db, cfg)
+	accessTokenHandler := user.NewAccessTokenHandler(db)
+	accountHandler := user.NewAccountHandler(db, store, cfg)
+	preferencesHandler := user.NewPreferencesHandler(db, cfg)
 	projectHandler := project.NewProjectHandler(db)
 	boardHandler := board.NewBoardHandler(db)
 	columnHandler := column.NewColumnHandler(db)
-	taskHandler := task.NewTaskHandler(db)
+	taskHandler := task.NewTaskHandler(db, cfg)
+	commentHandler := comment.NewCommentHandler(db, cfg)
+	labelHandler := label.NewLabelHandler(db)
+	customFieldHandler := customfield.NewCustomFieldHandler(db)
+	attachmentHandler := attachment.NewAttachmentHandler(db, store, cfg.Storage.MaxUploadSize)
 	teamHandler := team.NewTeamHandler(db)
+	invitationHandler := invitation.NewInvitationHandler(db, mail, cfg)
 	boardActivitiesHandler := activity.NewBoardActivitiesHandler(db)
 	taskActivitiesHandler := activity.NewTaskActivitiesHandler(db)
+	jwksHandler := auth.NewJWKSHandler()
+	oidcHandler := auth.NewOIDCHandler(db, cfg, mail)
 	// 添加通知处理器初始化
 	notificationHandler := notification.NewNotificationHandler(db)
 
+	// JWT验证公钥
+	r.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
+
 	// 公开路由（不需要认证）
 	api := r.Group("/api")
 	{
 		authGroup := api.Group("/auth")
 		{
 			authGroup.POST("/login", loginHandler.Login)
+			authGroup.POST("/mfa/verify", loginHandler.VerifyMFA)
 			authGroup.POST("/register", registerHandler.Register)
+			authGroup.POST("/refresh", tokenHandler.Refresh)
+			authGroup.POST("/logout", tokenHandler.Logout)
+			authGroup.POST("/password/forgot", passwordHandler.ForgotPassword)
+			authGroup.POST("/password/reset", passwordHandler.ResetPassword)
+			authGroup.POST("/email/confirm", emailHandler.ConfirmEmail)
+			authGroup.POST("/email/resend", emailHandler.ResendVerification)
+			authGroup.GET("/oidc/providers", oidcHandler.GetProviders)
+			authGroup.GET("/oidc/:provider/login", oidcHandler.Login)
+			authGroup.GET("/oidc/:provider/callback", oidcHandler.Callback)
+			authGroup.POST("/oidc/exchange", oidcHandler.Exchange)
 		}
 
+		// 邀请预览（未注册用户通过邀请链接访问）
+		api.GET("/invitations/:token", invitationHandler.PreviewInvitation)
+
 		// 通知相关
 		api.POST("/notifications", notificationHandler.ReceiveTaskNotification)
 	}
 
 	// 受保护的路由（需要认证）
 	protected := api.Group("")
-	protected.Use(middleware.AuthMiddleware(cfg))
+	protected.Use(middleware.AuthMiddleware(cfg, services.NewSessionService(db, cfg), services.NewAccessTokenService(db)))
 	{
 		// 用户相关
 		protected.GET("/user/profile", profileHandler.GetProfile)
 		protected.PUT("/user/profile", profileHandler.UpdateProfile)
 		protected.POST("/user/avatar", profileHandler.UploadAvatar)
+		protected.PUT("/user/password", passwordHandler.ChangePassword)
+		protected.PUT("/user/email", emailHandler.ChangeEmail)
+		protected.POST("/user/mfa/enroll", mfaHandler.Enroll)
+		protected.POST("/user/mfa/confirm", mfaHandler.Confirm)
+		protected.POST("/user/mfa/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+		protected.DELETE("/user/mfa", mfaHandler.Disable)
+		protected.GET("/user/sessions", sessionHandler.GetSessions)
+		protected.DELETE("/user/sessions", sessionHandler.RevokeOtherSessions)
+		protected.DELETE("/user/sessions/:sessionId", sessionHandler.RevokeSession)
+		protected.GET("/user/tokens", accessTokenHandler.GetAccessTokens)
+		protected.POST("/user/tokens", accessTokenHandler.CreateAccessToken)
+		protected.DELETE("/user/tokens/:tokenId", accessTokenHandler.RevokeAccessToken)
+		protected.GET("/user/preferences", preferencesHandler.GetPreferences)
+		protected.PUT("/user/preferences", preferencesHandler.UpdatePreferences)
+		protected.GET("/user/export", accountHandler.ExportData)
+		protected.DELETE("/user/account", accountHandler.DeleteAccount)
 
 		// Team Routes
-		protected.POST("/teams", teamHandler.CreateTeam)
-		protected.GET("/teams", teamHandler.GetMyTeams)
+		protected.POST("/teams", middleware.RequireScope(models.ScopeProjectsAdmin), teamHandler.CreateTeam)
+		protected.GET("/teams", middleware.RequireScope(models.ScopeTasksRead), teamHandler.GetMyTeams)
 		protected.GET("/teams/:teamId",
 			rbac.RequireTeamAccess("view", "teamId"),
 			teamHandler.GetTeam,
@@ -104,6 +169,49 @@ func SetupRoutes(db *gorm.DB, cfg *config.Config) *gin.Engine {
 			rbac.RequireTeamAccess("manage", "teamId"),
 			teamHandler.AddMember,
 		)
+		protected.PUT("/teams/:teamId/members/:userId",
+			rbac.RequireTeamAccess("manage", "teamId"),
+			teamHandler.UpdateMemberRole,
+		)
+		protected.DELETE("/teams/:teamId/members/:userId",
+			rbac.RequireTeamAccess("manage", "teamId"),
+			teamHandler.RemoveMember,
+		)
+		protected.POST("/teams/:teamId/leave",
+			rbac.RequireTeamAccess("view", "teamId"),
+			teamHandler.LeaveTeam,
+		)
+		protected.POST("/teams/:teamId/transfer",
+			rbac.RequireTeamAccess("manage", "teamId"),
+			teamHandler.TransferOwnership,
+		)
+
+		// 邀请相关
+		protected.GET("/teams/:teamId/invitations",
+			rbac.RequireTeamAccess("manage", "teamId"),
+			invitationHandler.GetTeamInvitations,
+		)
+		protected.POST("/teams/:teamId/invitations",
+			rbac.RequireTeamAccess("manage", "teamId"),
+			invitationHandler.CreateTeamInvitation,
+		)
+		protected.DELETE("/teams/:teamId/invitations/:invitationId",
+			rbac.RequireTeamAccess("manage", "teamId"),
+			invitationHandler.RevokeTeamInvitation,
+		)
+		protected.GET("/projects/:projectId/invitations",
+			rbac.RequireProjectAccess("manage", "projectId", "project"),
+			invitationHandler.GetProjectInvitations,
+		)
+		protected.POST("/projects/:projectId/invitations",
+			rbac.RequireProjectAccess("manage", "projectId", "project"),
+			invitationHandler.CreateProjectInvitation,
+		)
+		protected.DELETE("/projects/:projectId/invitations/:invitationId",
+			rbac.RequireProjectAccess("manage", "projectId", "project"),
+			invitationHandler.RevokeProjectInvitation,
+		)
+		protected.POST("/invitations/accept", invitationHandler.AcceptInvitation)
 
 		// Project Routes
 		protected.POST("/teams/:teamId/projects",
@@ -114,7 +222,7 @@ func SetupRoutes(db *gorm.DB, cfg *config.Config) *gin.Engine {
 			rbac.RequireTeamAccess("view", "teamId"),
 			projectHandler.GetTeamProjects,
 		)
-		protected.GET("/projects", projectHandler.GetProjects)
+		protected.GET("/projects", middleware.RequireScope(models.ScopeTasksRead), projectHandler.GetProjects)
 		protected.GET("/projects/:projectId",
 			rbac.RequireProjectAccess("view", "projectId", "project"),
 			projectHandler.GetProject,
@@ -127,9 +235,61 @@ func SetupRoutes(db *gorm.DB, cfg *config.Config) *gin.Engine {
 			rbac.RequireProjectAccess("manage", "projectId", "project"),
 			projectHandler.DeleteProject,
 		)
+		protected.GET("/projects/:projectId/members",
+			rbac.RequireProjectAccess("view", "projectId", "project"),
+			projectHandler.GetProjectMembers,
+		)
+		protected.POST("/projects/:projectId/members",
+			rbac.RequireProjectAccess("manage", "projectId", "project"),
+			projectHandler.AddProjectMember,
+		)
+		protected.PUT("/projects/:projectId/members/:userId",
+			rbac.RequireProjectAccess("manage", "projectId", "project"),
+			projectHandler.UpdateProjectMember,
+		)
+		protected.DELETE("/projects/:projectId/members/:userId",
+			rbac.RequireProjectAccess("manage", "projectId", "project"),
+			projectHandler.RemoveProjectMember,
+		)
+
+		// 标签相关
+		protected.GET("/projects/:projectId/labels",
+			rbac.RequireProjectAccess("view", "projectId", "project"),
+			labelHandler.GetLabels,
+		)
+		protected.POST("/projects/:projectId/labels",
+			rbac.RequireProjectAccess("manage", "projectId", "project"),
+			labelHandler.CreateLabel,
+		)
+		protected.PUT("/projects/:projectId/labels/:labelId",
+			rbac.RequireProjectAccess("manage", "projectId", "project"),
+			labelHandler.UpdateLabel,
+		)
+		protected.DELETE("/projects/:projectId/labels/:labelId",
+			rbac.RequireProjectAccess("manage", "projectId", "project"),
+			labelHandler.DeleteLabel,
+		)
+
+		// 自定义字段相关
+		protected.GET("/projects/:projectId/custom-fields",
+			rbac.RequireProjectAccess("view", "projectId", "project"),
+			customFieldHandler.GetCustomFields,
+		)
+		protected.POST("/projects/:projectId/custom-fields",
+			rbac.RequireProjectAccess("manage", "projectId", "project"),
+			customFieldHandler.CreateCustomField,
+		)
+		protected.PUT("/projects/:projectId/custom-fields/:fieldId",
+			rbac.RequireProjectAccess("manage", "projectId", "project"),
+			customFieldHandler.UpdateCustomField,
+		)
+		protected.DELETE("/projects/:projectId/custom-fields/:fieldId",
+			rbac.RequireProjectAccess("manage", "projectId", "project"),
+			customFieldHandler.DeleteCustomField,
+		)
 
 		// 看板相关
-		protected.GET("/boards", boardHandler.GetBoards)
+		protected.GET("/boards", middleware.RequireScope(models.ScopeTasksRead), boardHandler.GetBoards)
 		protected.GET("/projects/:projectId/boards",
 			rbac.RequireProjectAccess("view", "projectId", "project"),
 			boardHandler.GetBoardsByProject,
@@ -183,6 +343,18 @@ func SetupRoutes(db *gorm.DB, cfg *config.Config) *gin.Engine {
 			rbac.RequireProjectAccess("view", "columnId", "column"),
 			taskHandler.CreateTask,
 		)
+		protected.GET("/projects/:projectId/tasks",
+			rbac.RequireProjectAccess("view", "projectId", "projectKey"),
+			taskHandler.SearchTasks,
+		)
+		protected.GET("/projects/:projectId/tasks/export",
+			rbac.RequireProjectAccess("view", "projectId", "projectKey"),
+			taskHandler.ExportTasks,
+		)
+		protected.GET("/projects/:projectId/tasks/:number",
+			rbac.RequireProjectAccess("view", "projectId", "projectKey"),
+			taskHandler.GetTaskByNumber,
+		)
 		protected.GET("/tasks/:taskId",
 			rbac.RequireProjectAccess("view", "taskId", "task"),
 			taskHandler.GetTask,
@@ -199,12 +371,131 @@ func SetupRoutes(db *gorm.DB, cfg *config.Config) *gin.Engine {
 			rbac.RequireProjectAccess("view", "taskId", "task"),
 			taskHandler.MoveTask,
 		)
+		protected.GET("/tasks/:taskId/subtasks",
+			rbac.RequireProjectAccess("view", "taskId", "task"),
+			taskHandler.GetSubtasks,
+		)
+
+		// 任务负责人与关注者
+		protected.POST("/tasks/:taskId/assignees",
+			rbac.RequireProjectAccess("view", "taskId", "task"),
+			taskHandler.AddAssignee,
+		)
+		protected.DELETE("/tasks/:taskId/assignees/:userId",
+			rbac.RequireProjectAccess("view", "taskId", "task"),
+			taskHandler.RemoveAssignee,
+		)
+		protected.POST("/tasks/:taskId/watchers",
+			rbac.RequireProjectAccess("view", "taskId", "task"),
+			taskHandler.AddWatcher,
+		)
+		protected.DELETE("/tasks/:taskId/watchers/:userId",
+			rbac.RequireProjectAccess("view", "taskId", "task"),
+			taskHandler.RemoveWatcher,
+		)
+
+		// 任务关联
+		protected.GET("/tasks/:taskId/links",
+			rbac.RequireProjectAccess("view", "taskId", "task"),
+			taskHandler.GetTaskLinks,
+		)
+		protected.POST("/tasks/:taskId/links",
+			rbac.RequireProjectAccess("view", "taskId", "task"),
+			taskHandler.CreateTaskLink,
+		)
+		protected.DELETE("/tasks/:taskId/links/:linkId",
+			rbac.RequireProjectAccess("view", "taskId", "task"),
+			taskHandler.DeleteTaskLink,
+		)
+
+		// 任务检查项
+		protected.GET("/tasks/:taskId/checklist",
+			rbac.RequireProjectAccess("view", "taskId", "task"),
+			taskHandler.GetChecklist,
+		)
+		protected.POST("/tasks/:taskId/checklist",
+			rbac.RequireProjectAccess("view", "taskId", "task"),
+			taskHandler.AddChecklistItem,
+		)
+		protected.PUT("/tasks/:taskId/checklist/:itemId",
+			rbac.RequireProjectAccess("view", "taskId", "task"),
+			taskHandler.UpdateChecklistItem,
+		)
+		protected.DELETE("/tasks/:taskId/checklist/:itemId",
+			rbac.RequireProjectAccess("view", "taskId", "task"),
+			taskHandler.DeleteChecklistItem,
+		)
+
+		// 评论相关
+		protected.GET("/tasks/:taskId/comments",
+			rbac.RequireProjectAccess("view", "taskId", "task"),
+			commentHandler.GetComments,
+		)
+		protected.POST("/tasks/:taskId/comments",
+			rbac.RequireProjectAccess("view", "taskId", "task"),
+			commentHandler.CreateComment,
+		)
+		protected.PUT("/tasks/:taskId/comments/:commentId",
+			rbac.RequireProjectAccess("view", "taskId", "task"),
+			commentHandler.UpdateComment,
+		)
+		protected.PATCH("/tasks/:taskId/comments/:commentId/hide",
+			rbac.RequireProjectAccess("manage", "taskId", "task"),
+			commentHandler.HideComment,
+		)
+		protected.DELETE("/tasks/:taskId/comments/:commentId",
+			rbac.RequireProjectAccess("view", "taskId", "task"),
+			commentHandler.DeleteComment,
+		)
+
+		// 任务标签
+		protected.POST("/tasks/:taskId/labels",
+			rbac.RequireProjectAccess("view", "taskId", "task"),
+			labelHandler.AddTaskLabel,
+		)
+		protected.DELETE("/tasks/:taskId/labels/:labelId",
+			rbac.RequireProjectAccess("view", "taskId", "task"),
+			labelHandler.RemoveTaskLabel,
+		)
+
+		// 附件相关
+		protected.GET("/tasks/:taskId/attachments",
+			rbac.RequireProjectAccess("view", "taskId", "task"),
+			attachmentHandler.GetAttachments,
+		)
+		protected.POST("/tasks/:taskId/attachments",
+			rbac.RequireProjectAccess("view", "taskId", "task"),
+			attachmentHandler.UploadAttachment,
+		)
+		protected.GET("/tasks/:taskId/attachments/:attachmentId/download",
+			rbac.RequireProjectAccess("view", "taskId", "task"),
+			attachmentHandler.DownloadAttachment,
+		)
+		protected.DELETE("/tasks/:taskId/attachments/:attachmentId",
+			rbac.RequireProjectAccess("view", "taskId", "task"),
+			attachmentHandler.DeleteAttachment,
+		)
 
 		// 看板活动日志
-		protected.GET("/boards/:boardId/activities", boardActivitiesHandler.GetBoardActivities)
+		protected.GET("/boards/:boardId/activities", middleware.RequireScope(models.ScopeTasksRead), boardActivitiesHandler.GetBoardActivities)
 
 		// 任务活动日志
-		protected.GET("/tasks/:taskId/activities", taskActivitiesHandler.GetTaskActivities)
+		protected.GET("/tasks/:taskId/activities", middleware.RequireScope(models.ScopeTasksRead), taskActivitiesHandler.GetTaskActivities)
+
+		// 系统管理
+		adminGroup := protected.Group("/admin", rbac.RequireSysAdmin())
+		{
+			adminGroup.GET("/users", adminUserHandler.GetUsers)
+			adminGroup.GET("/users/:userId", adminUserHandler.GetUser)
+			adminGroup.PUT("/users/:userId/status", adminUserHandler.UpdateUserStatus)
+			adminGroup.PUT("/users/:userId/role", adminUserHandler.UpdateUserRole)
+			adminGroup.POST("/users/:userId/password-reset", adminUserHandler.ResetUserPassword)
+			adminGroup.DELETE("/users/:userId", adminUserHandler.DeleteUser)
+			adminGroup.POST("/users/:userId/unlock", adminUserHandler.UnlockUser)
+			adminGroup.POST("/users/:userId/impersonate", adminUserHandler.ImpersonateUser)
+			adminGroup.GET("/audit-logs", auditHandler.GetAuditLogs)
+			adminGroup.POST("/ldap/sync", ldapHandler.SyncUsers)
+		}
 	}
 
 	return r
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"progress-wall-backend/dto"
+	"progress-wall-backend/models"
+	"progress-wall-backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// maxAccessTokenDays 个人访问令牌最长有效期（天）
+const maxAccessTokenDays = 365
+
+// AccessTokenService 个人访问令牌服务
+type AccessTokenService struct {
+	db *gorm.DB
+}
+
+// NewAccessTokenService 创建个人访问令牌服务
+func NewAccessTokenService(db *gorm.DB) *AccessTokenService {
+	return &AccessTokenService{db: db}
+}
+
+// CreateAccessTokenInput 创建个人访问令牌参数
+type CreateAccessTokenInput struct {
+	Name          string
+	Scopes        []string
+	ExpiresInDays int // 0 表示永不过期
+}
+
+// AccessTokenIdentity 个人访问令牌认证结果
+type AccessTokenIdentity struct {
+	UserID   uint
+	Username string
+	Scopes   []string
+}
+
+// CreateToken 为用户创建个人访问令牌，返回令牌信息和明文令牌（仅此一次）
+func (s *AccessTokenService) CreateToken(userID uint, input CreateAccessTokenInput) (*dto.AccessTokenResponse, string, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || utf8.RuneCountInString(name) > 100 {
+		return nil, "", ErrInvalidTokenName
+	}
+	scopes, err := normalizeScopes(input.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	if input.ExpiresInDays < 0 || input.ExpiresInDays > maxAccessTokenDays {
+		return nil, "", ErrInvalidTokenExpiry
+	}
+
+	secret, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return nil, "", err
+	}
+	raw := models.AccessTokenPrefix + secret
+
+	token := &models.PersonalAccessToken{
+		UserID:      userID,
+		Name:        name,
+		TokenPrefix: raw[:len(models.AccessTokenPrefix)+8],
+		TokenHash:   utils.HashToken(raw),
+		Scopes:      strings.Join(scopes, " "),
+	}
+	if input.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, input.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+	if err := s.db.Create(token).Error; err != nil {
+		return nil, "", fmt.Errorf("创建访问令牌失败: %v", err)
+	}
+
+	return toAccessTokenResponse(token), raw, nil
+}
+
+// ListTokens 获取用户的个人访问令牌
+func (s *AccessTokenService) ListTokens(userID uint) ([]dto.AccessTokenResponse, error) {
+	var tokens []models.PersonalAccessToken
+	if err := s.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error; err != nil {
+		return nil, fmt.Errorf("查询访问令牌失败: %v", err)
+	}
+
+	result := make([]dto.AccessTokenResponse, 0, len(tokens))
+	for i := range tokens {
+		result = append(result, *toAccessTokenResponse(&tokens[i]))
+	}
+	return result, nil
+}
+
+// RevokeToken 删除用户的个人访问令牌，立即失效
+func (s *AccessTokenService) RevokeToken(userID, tokenID uint) error {
+	result := s.db.Where("id = ? AND user_id = ?", tokenID, userID).Delete(&models.PersonalAccessToken{})
+	if result.Error != nil {
+		return fmt.Errorf("删除访问令牌失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrAccessTokenNotFound
+	}
+	return nil
+}
+
+// Authenticate 校验个人访问令牌并更新最近使用时间
+func (s *AccessTokenService) Authenticate(raw string) (*AccessTokenIdentity, error) {
+	if !strings.HasPrefix(raw, models.AccessTokenPrefix) {
+		return nil, ErrInvalidAccessToken
+	}
+
+	var row struct {
+		ID         uint
+		UserID     uint
+		Scopes     string
+		ExpiresAt  *time.Time
+		LastUsedAt *time.Time
+		Username   string
+		Status     models.UserStatus
+	}
+	result := s.db.Table("personal_access_tokens").
+		Select("personal_access_tokens.id, personal_access_tokens.user_id, personal_access_tokens.scopes, personal_access_tokens.expires_at, personal_access_tokens.last_used_at, users.username, users.status").
+		Joins("JOIN users ON users.id = personal_access_tokens.user_id AND users.deleted_at IS NULL").
+		Where("personal_access_tokens.token_hash = ?", utils.HashToken(raw)).
+		Limit(1).
+		Scan(&row)
+	if result.Error != nil {
+		return nil, fmt.Errorf("查询访问令牌失败: %v", result.Error)
+	}
+	now := time.Now()
+	if result.RowsAffected == 0 || row.Status != models.UserStatusEnabled ||
+		(row.ExpiresAt != nil && now.After(*row.ExpiresAt)) {
+		return nil, ErrInvalidAccessToken
+	}
+
+	if row.LastUsedAt == nil || now.Sub(*row.LastUsedAt) > lastSeenInterval {
+		if err := s.db.Model(&models.PersonalAccessToken{}).Where("id = ?", row.ID).
+			UpdateColumn("last_used_at", now).Error; err != nil {
+			return nil, fmt.Errorf("更新访问令牌失败: %v", err)
+		}
+	}
+
+	return &AccessTokenIdentity{
+		UserID:   row.UserID,
+		Username: row.Username,
+		Scopes:   strings.Fields(row.Scopes),
+	}, nil
+}
+
+// normalizeScopes 校验并去重权限范围
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, ErrInvalidTokenScope
+	}
+	seen := make(map[string]bool, len(scopes))
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !models.ValidScope(scope) {
+			return nil, ErrInvalidTokenScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	return result, nil
+}
+
+func toAccessTokenResponse(token *models.PersonalAccessToken) *dto.AccessTokenResponse {
+	return &dto.AccessTokenResponse{
+		ID:          token.ID,
+		Name:        token.Name,
+		TokenPrefix: token.TokenPrefix,
+		Scopes:      token.ScopeList(),
+		ExpiresAt:   token.ExpiresAt,
+		LastUsedAt:  token.LastUsedAt,
+		CreatedAt:   token.CreatedAt,
+	}
+}
@@ -0,0 +1,494 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/models"
+	"progress-wall-backend/storage"
+	"progress-wall-backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// deletedUserName 已注销用户在活动日志中显示的名称
+const deletedUserName = "deleted user"
+
+// AccountService 账号自助服务：个人数据导出和注销账号
+type AccountService struct {
+	db    *gorm.DB
+	cfg   *config.Config
+	store storage.Storage
+}
+
+// NewAccountService 创建账号自助服务
+func NewAccountService(db *gorm.DB, store storage.Storage, cfg *config.Config) *AccountService {
+	return &AccountService{
+		db:    db,
+		cfg:   cfg,
+		store: store,
+	}
+}
+
+// AccountExport 个人数据导出内容
+type AccountExport struct {
+	ExportedAt    time.Time               `json:"exported_at"`
+	Profile       *models.User            `json:"profile"`
+	Preferences   *models.UserPreferences `json:"preferences"`
+	Identities    []models.UserIdentity   `json:"identities"`
+	Comments      []models.Comment        `json:"comments"`
+	CreatedTasks  []models.Task           `json:"created_tasks"`
+	AssignedTasks []models.Task           `json:"assigned_tasks"`
+	Activities    []models.ActivityLog    `json:"activities"`
+	Attachments   []models.Attachment     `json:"attachments"`
+}
+
+// DeleteAccountInput 注销账号请求
+type DeleteAccountInput struct {
+	Password      string // 本地账号需要验证当前密码
+	Confirm       string // 必须与当前用户名一致
+	SuccessorID   *uint  // 接手拥有的团队、项目和看板的用户
+	ReassignTasks bool   // 为true时把分配给自己的任务转给接手人，否则取消分配
+}
+
+// ExportData 汇总用户的个人数据，并写入审计日志
+func (s *AccountService) ExportData(userID uint, client ClientInfo) (*AccountExport, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("查询用户失败: %v", err)
+	}
+
+	prefs, err := loadPreferences(s.db, s.cfg, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 导出时间按用户时区显示
+	export := &AccountExport{ExportedAt: time.Now().In(prefs.Location()), Profile: &user, Preferences: prefs}
+	queries := []struct {
+		dest  interface{}
+		query *gorm.DB
+	}{
+		{&export.Identities, s.db.Where("user_id = ?", userID)},
+		{&export.Comments, s.db.Where("user_id = ? AND status <> ?", userID, models.CommentStatusDeleted)},
+		{&export.CreatedTasks, s.db.Where("creator_id = ?", userID)},
+		{&export.AssignedTasks, s.db.Where("id IN (?)", s.db.Model(&models.TaskAssignee{}).Select("task_id").Where("user_id = ?", userID))},
+		{&export.Activities, s.db.Where("user_id = ?", userID)},
+		{&export.Attachments, s.db.Where("uploader_id = ? AND status = ?", userID, models.AttachmentStatusNormal)},
+	}
+	for _, q := range queries {
+		if err := q.query.Order("id ASC").Find(q.dest).Error; err != nil {
+			return nil, fmt.Errorf("导出个人数据失败: %v", err)
+		}
+	}
+
+	if err := recordAudit(s.db, &models.AuditLog{
+		Action:       models.AuditAccountExported,
+		ActorID:      &user.ID,
+		TargetUserID: &user.ID,
+		Subject:      user.Username,
+		IP:           client.IP,
+		UserAgent:    client.UserAgent,
+	}); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// WriteExportZip 将个人数据写为ZIP：data.json 为全部数据，files/ 下为头像和上传的附件
+// 单个文件读取失败时跳过并记录日志，不影响其余内容
+func (s *AccountService) WriteExportZip(w io.Writer, export *AccountExport) error {
+	zw := zip.NewWriter(w)
+
+	data, err := zw.Create("data.json")
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(data)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(export); err != nil {
+		return fmt.Errorf("写入导出数据失败: %v", err)
+	}
+
+	if avatar := export.Profile.Avatar; strings.HasPrefix(avatar, "/uploads/avatars/") {
+		name := path.Base(avatar)
+		if err := copyToZip(zw, "files/avatar/"+name, func() (io.ReadCloser, error) {
+			return os.Open(filepath.Join("uploads", "avatars", name))
+		}); err != nil {
+			log.Printf("导出头像失败: %v", err)
+		}
+	}
+
+	for _, attachment := range export.Attachments {
+		attachment := attachment
+		name := fmt.Sprintf("files/attachments/%d-%s", attachment.ID, path.Base(attachment.OriginalName))
+		if err := copyToZip(zw, name, func() (io.ReadCloser, error) {
+			return s.store.Get(attachment.FilePath)
+		}); err != nil {
+			log.Printf("导出附件 %d 失败: %v", attachment.ID, err)
+		}
+	}
+
+	return zw.Close()
+}
+
+// copyToZip 将 open 打开的内容写入ZIP中的 name
+func copyToZip(zw *zip.Writer, name string, open func() (io.ReadCloser, error)) error {
+	r, err := open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	return err
+}
+
+// DeleteAccount 注销账号
+// 拥有的团队、项目和看板转给接手人，分配给自己的任务转给接手人或取消分配；
+// 账号信息匿名化后软删除，活动日志中的用户名改为 deleted user，评论和创建的任务保留
+func (s *AccountService) DeleteAccount(userID uint, input DeleteAccountInput, client ClientInfo) error {
+	var avatar string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return fmt.Errorf("查询用户失败: %v", err)
+		}
+		if input.Confirm != user.Username {
+			return ErrDeleteConfirmMismatch
+		}
+
+		// 外部身份登录的用户没有可用的本地密码
+		var identities int64
+		if err := tx.Model(&models.UserIdentity{}).Where("user_id = ?", user.ID).Count(&identities).Error; err != nil {
+			return fmt.Errorf("查询外部身份失败: %v", err)
+		}
+		if identities == 0 && !utils.CheckPasswordHash(input.Password, user.Password) {
+			return ErrWrongPassword
+		}
+
+		if err := ensureOtherSysAdmin(tx, &user); err != nil {
+			return err
+		}
+
+		successor, err := s.loadSuccessor(tx, &user, input.SuccessorID)
+		if err != nil {
+			return err
+		}
+		actor := Actor{ID: user.ID, Name: user.Username}
+		if err := s.handOver(tx, &user, successor, actor); err != nil {
+			return err
+		}
+
+		if input.ReassignTasks && successor != nil {
+			if err := reassignTasks(tx, user.ID, successor.ID); err != nil {
+				return err
+			}
+		}
+
+		if err := s.removeAccountData(tx, &user); err != nil {
+			return err
+		}
+		avatar = user.Avatar
+		if err := anonymizeUser(tx, &user); err != nil {
+			return err
+		}
+
+		return recordAudit(tx, &models.AuditLog{
+			Action:       models.AuditAccountDeleted,
+			ActorID:      &user.ID,
+			TargetUserID: &user.ID,
+			Subject:      user.Username,
+			IP:           client.IP,
+			UserAgent:    client.UserAgent,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	// 头像保存在本地上传目录，账号删除成功后再移除文件
+	if strings.HasPrefix(avatar, "/uploads/avatars/") {
+		if err := os.Remove(filepath.Join("uploads", "avatars", path.Base(avatar))); err != nil && !os.IsNotExist(err) {
+			log.Printf("删除头像文件失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// loadSuccessor 校验接手人；用户拥有团队、项目或看板，或是某个项目唯一的管理员时必须指定
+func (s *AccountService) loadSuccessor(tx *gorm.DB, user *models.User, successorID *uint) (*models.User, error) {
+	if successorID == nil {
+		var owned int64
+		for _, q := range []*gorm.DB{
+			tx.Model(&models.Team{}).Where("creator_id = ?", user.ID),
+			tx.Model(&models.Project{}).Where("owner_id = ?", user.ID),
+			tx.Model(&models.Board{}).Where("owner_id = ?", user.ID),
+			tx.Model(&models.Project{}).Where("id IN (?)", soleAdminProjectIDs(tx, user.ID)),
+		} {
+			var count int64
+			if err := q.Count(&count).Error; err != nil {
+				return nil, fmt.Errorf("查询拥有的资源失败: %v", err)
+			}
+			owned += count
+		}
+		if owned > 0 {
+			return nil, ErrSuccessorRequired
+		}
+		return nil, nil
+	}
+
+	if *successorID == user.ID {
+		return nil, ErrInvalidSuccessor
+	}
+	var successor models.User
+	if err := tx.Where("id = ? AND status = ?", *successorID, models.UserStatusEnabled).First(&successor).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidSuccessor
+		}
+		return nil, fmt.Errorf("查询接手人失败: %v", err)
+	}
+	return &successor, nil
+}
+
+// handOver 将用户拥有的团队、项目和看板转给接手人，并在用户唯一管理的项目中将接手人设为管理员
+// 接手人必须已是相应团队的成员，转让后成为团队或项目管理员
+func (s *AccountService) handOver(tx *gorm.DB, user, successor *models.User, actor Actor) error {
+	if successor == nil {
+		return nil
+	}
+
+	var teams []models.Team
+	if err := tx.Where("creator_id = ?", user.ID).Find(&teams).Error; err != nil {
+		return fmt.Errorf("查询团队失败: %v", err)
+	}
+	for _, team := range teams {
+		if err := promoteTeamMember(tx, team.ID, successor.ID); err != nil {
+			return err
+		}
+		if err := tx.Model(&team).Update("creator_id", successor.ID).Error; err != nil {
+			return fmt.Errorf("转让团队失败: %v", err)
+		}
+		if err := recordTeamActivity(tx, team.ID, actor, fmt.Sprintf("transferred team ownership to %s", successor.Username)); err != nil {
+			return err
+		}
+	}
+
+	var projects []models.Project
+	if err := tx.Where("owner_id = ?", user.ID).Find(&projects).Error; err != nil {
+		return fmt.Errorf("查询项目失败: %v", err)
+	}
+	for _, project := range projects {
+		if err := ensureProjectAdmin(tx, &project, successor.ID); err != nil {
+			return err
+		}
+		if err := tx.Model(&project).Update("owner_id", successor.ID).Error; err != nil {
+			return fmt.Errorf("转让项目失败: %v", err)
+		}
+		projectID := project.ID
+		if err := tx.Create(&models.ActivityLog{
+			UserID:         actor.ID,
+			Username:       actor.Name,
+			ImpersonatorID: actor.ImpersonatorID,
+			ActionType:     models.ActionUpdate,
+			EntityType:     models.EntityProject,
+			EntityID:       projectID,
+			ProjectID:      &projectID,
+			Description:    fmt.Sprintf("transferred project ownership to %s", successor.Username),
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	var boards []models.Board
+	if err := tx.Preload("Project").Where("owner_id = ?", user.ID).Find(&boards).Error; err != nil {
+		return fmt.Errorf("查询看板失败: %v", err)
+	}
+	for _, board := range boards {
+		if err := ensureProjectAdmin(tx, &board.Project, successor.ID); err != nil {
+			return err
+		}
+		if err := tx.Model(&board).Update("owner_id", successor.ID).Error; err != nil {
+			return fmt.Errorf("转让看板失败: %v", err)
+		}
+		boardID := board.ID
+		if err := tx.Create(&models.ActivityLog{
+			UserID:         actor.ID,
+			Username:       actor.Name,
+			ImpersonatorID: actor.ImpersonatorID,
+			ActionType:     models.ActionUpdate,
+			EntityType:     models.EntityBoard,
+			EntityID:       boardID,
+			BoardID:        &boardID,
+			ProjectID:      &board.ProjectID,
+			Description:    fmt.Sprintf("transferred board ownership to %s", successor.Username),
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	// 拥有的项目已在上面转让，这里处理用户是唯一管理员但不是所有者的项目
+	var adminProjects []models.Project
+	if err := tx.Where("id IN (?)", soleAdminProjectIDs(tx, user.ID)).Find(&adminProjects).Error; err != nil {
+		return fmt.Errorf("查询项目失败: %v", err)
+	}
+	for _, project := range adminProjects {
+		if err := ensureProjectAdmin(tx, &project, successor.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// soleAdminProjectIDs 返回用户是唯一管理员的项目ID子查询
+func soleAdminProjectIDs(tx *gorm.DB, userID uint) *gorm.DB {
+	return tx.Table("project_members AS pm").Select("pm.project_id").
+		Where("pm.user_id = ? AND pm.role = ?", userID, models.ProjectRoleAdmin).
+		Where(`NOT EXISTS (SELECT 1 FROM project_members AS o
+			WHERE o.project_id = pm.project_id AND o.role = ? AND o.user_id <> ?)`, models.ProjectRoleAdmin, userID)
+}
+
+// promoteTeamMember 确保用户是团队管理员，不是团队成员时返回 ErrSuccessorNotMember
+func promoteTeamMember(tx *gorm.DB, teamID, userID uint) error {
+	var member models.TeamMember
+	if err := tx.Where("team_id = ? AND user_id = ?", teamID, userID).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSuccessorNotMember
+		}
+		return fmt.Errorf("查询团队成员失败: %v", err)
+	}
+	if member.Role == models.TeamRoleAdmin {
+		return nil
+	}
+	if err := tx.Model(&member).Update("role", models.TeamRoleAdmin).Error; err != nil {
+		return fmt.Errorf("更新团队角色失败: %v", err)
+	}
+	return nil
+}
+
+// ensureProjectAdmin 确保用户是项目管理员，用户必须已是项目所在团队的成员
+func ensureProjectAdmin(tx *gorm.DB, project *models.Project, userID uint) error {
+	var count int64
+	if err := tx.Model(&models.TeamMember{}).
+		Where("team_id = ? AND user_id = ?", project.TeamID, userID).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("查询团队成员失败: %v", err)
+	}
+	if count == 0 {
+		return ErrSuccessorNotMember
+	}
+
+	var member models.ProjectMember
+	err := tx.Where("project_id = ? AND user_id = ?", project.ID, userID).First(&member).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if err := tx.Create(&models.ProjectMember{
+			ProjectID: project.ID,
+			UserID:    userID,
+			Role:      models.ProjectRoleAdmin,
+			JoinedAt:  time.Now(),
+		}).Error; err != nil {
+			return fmt.Errorf("添加项目成员失败: %v", err)
+		}
+	case err != nil:
+		return fmt.Errorf("查询项目成员失败: %v", err)
+	case member.Role != models.ProjectRoleAdmin:
+		if err := tx.Model(&member).Update("role", models.ProjectRoleAdmin).Error; err != nil {
+			return fmt.Errorf("更新项目角色失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// removeAccountData 删除登录凭据、外部身份和团队/项目成员身份
+func (s *AccountService) removeAccountData(tx *gorm.DB, user *models.User) error {
+	if err := revokeUserSessions(tx, user.ID, ""); err != nil {
+		return err
+	}
+	for _, model := range []interface{}{
+		&models.PersonalAccessToken{},
+		&models.UserToken{},
+		&models.MFARecoveryCode{},
+		&models.UserIdentity{},
+		&models.ProjectMember{},
+		&models.TeamMember{},
+		&models.TaskAssignee{},
+		&models.TaskWatcher{},
+	} {
+		if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(model).Error; err != nil {
+			return fmt.Errorf("删除账号数据失败: %v", err)
+		}
+	}
+	if err := tx.Model(&models.ActivityLog{}).Where("user_id = ?", user.ID).Update("username", deletedUserName).Error; err != nil {
+		return fmt.Errorf("更新活动日志失败: %v", err)
+	}
+	return nil
+}
+
+// reassignTasks 将用户负责的任务转给接手人，接手人已是负责人的任务不重复添加
+func reassignTasks(tx *gorm.DB, userID, successorID uint) error {
+	err := tx.Exec(`INSERT INTO task_assignees (task_id, user_id, created_at)
+		SELECT task_id, ?, ? FROM task_assignees AS a
+		WHERE a.user_id = ? AND NOT EXISTS (
+			SELECT 1 FROM task_assignees AS b WHERE b.task_id = a.task_id AND b.user_id = ?
+		)`, successorID, time.Now(), userID, successorID).Error
+	if err != nil {
+		return fmt.Errorf("转交任务负责人失败: %v", err)
+	}
+	return nil
+}
+
+// anonymizeUser 清除用户的个人信息并软删除，用户ID保留以维持评论和任务的关联
+func anonymizeUser(tx *gorm.DB, user *models.User) error {
+	password, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return err
+	}
+	hashed, err := utils.HashPassword(password)
+	if err != nil {
+		return errors.New("加密密码失败")
+	}
+
+	user.Username = fmt.Sprintf("deleted-%d", user.ID)
+	user.Email = fmt.Sprintf("deleted-%d@deleted.invalid", user.ID)
+	err = tx.Model(user).Updates(map[string]interface{}{
+		"username":             user.Username,
+		"email":                user.Email,
+		"email_verified":       false,
+		"password":             hashed,
+		"nickname":             "",
+		"avatar":               "",
+		"phone":                "",
+		"status":               models.UserStatusDeleted,
+		"must_change_password": false,
+		"totp_enabled":         false,
+		"totp_secret":          "",
+	}).Error
+	if err != nil {
+		return fmt.Errorf("匿名化用户失败: %v", err)
+	}
+	if err := tx.Delete(user).Error; err != nil {
+		return fmt.Errorf("删除用户失败: %v", err)
+	}
+	return nil
+}
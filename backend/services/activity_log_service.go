@@ -8,6 +8,14 @@ import (
 	"gorm.io/gorm"
 )
 
+// Actor 写入活动日志的操作者
+// 管理员模拟登录期间 ID 和 Name 为被模拟的用户，ImpersonatorID 为实际操作的管理员
+type Actor struct {
+	ID             uint
+	Name           string
+	ImpersonatorID *uint
+}
+
 // ActivityLogService 活动日志服务
 type ActivityLogService struct {
 	repo repository.ActivityLogRepository
@@ -111,3 +119,18 @@ func (s *ActivityLogService) VerifyTaskAccess(userID, taskID uint) error {
 
 	return nil
 }
+
+// recordTaskActivity 在给定事务中写入与任务相关的活动日志
+// 自动补全 TaskID / ProjectID，并通过任务所在列解析出 BoardID
+func recordTaskActivity(tx *gorm.DB, task *models.Task, log *models.ActivityLog) error {
+	var column models.Column
+	if err := tx.Select("board_id").First(&column, task.ColumnID).Error; err == nil {
+		boardID := column.BoardID
+		log.BoardID = &boardID
+	}
+	taskID := task.ID
+	projectID := task.ProjectID
+	log.TaskID = &taskID
+	log.ProjectID = &projectID
+	return tx.Create(log).Error
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/mailer"
+	"progress-wall-backend/models"
+	"progress-wall-backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// AdminUserService 系统管理员用户管理服务
+// 所有变更都写入审计日志；禁用、删除和强制重置密码会立即吊销用户的全部登录会话
+type AdminUserService struct {
+	db              *gorm.DB
+	cfg             *config.Config
+	passwordService *PasswordService
+}
+
+// NewAdminUserService 创建用户管理服务
+func NewAdminUserService(db *gorm.DB, mail mailer.Mailer, cfg *config.Config) *AdminUserService {
+	return &AdminUserService{
+		db:              db,
+		cfg:             cfg,
+		passwordService: NewPasswordService(db, mail, cfg),
+	}
+}
+
+// AdminUserQuery 用户列表查询条件
+type AdminUserQuery struct {
+	Search     string // 按用户名、邮箱或昵称模糊搜索
+	Status     models.UserStatus
+	SystemRole models.SystemRole
+	Page       int
+	PageSize   int
+}
+
+// ListUsers 分页查询用户，按状态为已删除筛选时包含已软删除的用户
+func (s *AdminUserService) ListUsers(query AdminUserQuery) ([]models.User, int64, error) {
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PageSize < 1 || query.PageSize > 100 {
+		query.PageSize = 20
+	}
+
+	db := s.db.Model(&models.User{})
+	if query.Status == models.UserStatusDeleted {
+		db = db.Unscoped()
+	}
+	if query.Status != 0 {
+		db = db.Where("status = ?", query.Status)
+	}
+	if query.SystemRole != 0 {
+		db = db.Where("system_role = ?", query.SystemRole)
+	}
+	if search := strings.TrimSpace(query.Search); search != "" {
+		pattern := "%" + escapeLike(strings.ToLower(search)) + "%"
+		db = db.Where("LOWER(username) LIKE ? ESCAPE '!' OR LOWER(email) LIKE ? ESCAPE '!' OR LOWER(nickname) LIKE ? ESCAPE '!'", pattern, pattern, pattern)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询用户失败: %v", err)
+	}
+
+	var users []models.User
+	err := db.Order("id ASC").
+		Offset((query.Page - 1) * query.PageSize).
+		Limit(query.PageSize).
+		Find(&users).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询用户失败: %v", err)
+	}
+	return users, total, nil
+}
+
+// GetUser 获取单个用户
+func (s *AdminUserService) GetUser(userID uint) (*models.User, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("查询用户失败: %v", err)
+	}
+	return &user, nil
+}
+
+// SetStatus 启用或禁用用户，禁用后用户已签发的访问令牌立即失效
+func (s *AdminUserService) SetStatus(userID uint, status models.UserStatus, actorID uint, client ClientInfo) (*models.User, error) {
+	if status != models.UserStatusEnabled && status != models.UserStatusDisabled {
+		return nil, ErrInvalidUserStatus
+	}
+	if userID == actorID && status != models.UserStatusEnabled {
+		return nil, ErrModifySelf
+	}
+
+	return s.update(userID, func(tx *gorm.DB, user *models.User) (*models.AuditLog, error) {
+		if user.Status == status {
+			return nil, nil
+		}
+		if status != models.UserStatusEnabled {
+			if err := ensureOtherSysAdmin(tx, user); err != nil {
+				return nil, err
+			}
+		}
+		if err := tx.Model(user).UpdateColumn("status", status).Error; err != nil {
+			return nil, fmt.Errorf("更新用户状态失败: %v", err)
+		}
+		user.Status = status
+
+		if status == models.UserStatusEnabled {
+			return &models.AuditLog{Action: models.AuditUserEnabled, Detail: fmt.Sprintf("启用用户 %s", user.Username)}, nil
+		}
+		if err := revokeUserSessions(tx, user.ID, ""); err != nil {
+			return nil, err
+		}
+		return &models.AuditLog{Action: models.AuditUserDisabled, Detail: fmt.Sprintf("禁用用户 %s", user.Username)}, nil
+	}, actorID, client)
+}
+
+// SetSystemRole 设置用户的系统角色
+func (s *AdminUserService) SetSystemRole(userID uint, role models.SystemRole, actorID uint, client ClientInfo) (*models.User, error) {
+	if role != models.SystemRoleUser && role != models.SystemRoleAdmin {
+		return nil, ErrInvalidSystemRole
+	}
+	if userID == actorID && role != models.SystemRoleAdmin {
+		return nil, ErrModifySelf
+	}
+
+	return s.update(userID, func(tx *gorm.DB, user *models.User) (*models.AuditLog, error) {
+		if user.SystemRole == role {
+			return nil, nil
+		}
+		if role != models.SystemRoleAdmin {
+			if err := ensureOtherSysAdmin(tx, user); err != nil {
+				return nil, err
+			}
+		}
+		if err := tx.Model(user).UpdateColumn("system_role", role).Error; err != nil {
+			return nil, fmt.Errorf("更新系统角色失败: %v", err)
+		}
+		user.SystemRole = role
+
+		detail := fmt.Sprintf("将用户 %s 设为系统管理员", user.Username)
+		if role == models.SystemRoleUser {
+			detail = fmt.Sprintf("取消用户 %s 的系统管理员角色", user.Username)
+		}
+		return &models.AuditLog{Action: models.AuditUserRoleChanged, Detail: detail}, nil
+	}, actorID, client)
+}
+
+// ForcePasswordReset 强制用户重置密码：吊销全部会话，下次登录后必须先修改密码，
+// 同时向用户邮箱发送找回密码链接
+func (s *AdminUserService) ForcePasswordReset(userID, actorID uint, client ClientInfo) (*models.User, error) {
+	user, err := s.update(userID, func(tx *gorm.DB, user *models.User) (*models.AuditLog, error) {
+		if err := tx.Model(user).UpdateColumn("must_change_password", true).Error; err != nil {
+			return nil, fmt.Errorf("更新用户失败: %v", err)
+		}
+		user.MustChangePassword = true
+		if err := revokeUserSessions(tx, user.ID, ""); err != nil {
+			return nil, err
+		}
+		return &models.AuditLog{Action: models.AuditUserPasswordReset, Detail: fmt.Sprintf("强制用户 %s 重置密码", user.Username)}, nil
+	}, actorID, client)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Status == models.UserStatusEnabled {
+		if err := s.passwordService.sendResetLink(user); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// DeleteUser 软删除用户，用户数据保留，账号无法再登录
+func (s *AdminUserService) DeleteUser(userID, actorID uint, client ClientInfo) error {
+	if userID == actorID {
+		return ErrModifySelf
+	}
+
+	_, err := s.update(userID, func(tx *gorm.DB, user *models.User) (*models.AuditLog, error) {
+		if err := ensureOtherSysAdmin(tx, user); err != nil {
+			return nil, err
+		}
+		if err := tx.Model(user).UpdateColumn("status", models.UserStatusDeleted).Error; err != nil {
+			return nil, fmt.Errorf("删除用户失败: %v", err)
+		}
+		if err := tx.Delete(user).Error; err != nil {
+			return nil, fmt.Errorf("删除用户失败: %v", err)
+		}
+		if err := revokeUserSessions(tx, user.ID, ""); err != nil {
+			return nil, err
+		}
+		return &models.AuditLog{Action: models.AuditUserDeleted, Detail: fmt.Sprintf("删除用户 %s", user.Username)}, nil
+	}, actorID, client)
+	return err
+}
+
+// ImpersonationResult 模拟登录结果
+type ImpersonationResult struct {
+	AccessToken string
+	ExpiresIn   int
+	User        *models.User
+}
+
+// Impersonate 以指定用户的身份签发有时限的访问令牌，不签发刷新令牌
+// 令牌对应一条记录了发起人的会话，用户可在会话列表中看到并吊销；不能模拟系统管理员和已禁用的用户
+func (s *AdminUserService) Impersonate(userID, actorID uint, reason string, client ClientInfo) (*ImpersonationResult, error) {
+	ttl := time.Duration(s.cfg.Auth.ImpersonationMinutes) * time.Minute
+	var accessToken string
+
+	user, err := s.update(userID, func(tx *gorm.DB, user *models.User) (*models.AuditLog, error) {
+		if user.SystemRole == models.SystemRoleAdmin {
+			return nil, ErrImpersonateAdmin
+		}
+		if user.Status != models.UserStatusEnabled {
+			return nil, ErrUserDisabled
+		}
+
+		familyID, err := utils.GenerateSecureToken(16)
+		if err != nil {
+			return nil, err
+		}
+		// 刷新令牌不返回给调用方，会话只能通过访问令牌使用
+		refreshToken, err := utils.GenerateSecureToken(32)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		session := &models.Session{
+			FamilyID:       familyID,
+			UserID:         user.ID,
+			TokenHash:      utils.HashToken(refreshToken),
+			IP:             client.IP,
+			UserAgent:      truncate(client.UserAgent, 255),
+			LoginAt:        now,
+			LastSeenAt:     now,
+			ExpiresAt:      now.Add(ttl),
+			ImpersonatorID: &actorID,
+		}
+		if err := tx.Create(session).Error; err != nil {
+			return nil, fmt.Errorf("创建会话失败: %v", err)
+		}
+
+		accessToken, err = utils.GenerateImpersonationToken(user.ID, user.Username, familyID, actorID, ttl, s.cfg)
+		if err != nil {
+			return nil, ErrGenerateToken
+		}
+
+		detail := fmt.Sprintf("模拟登录用户 %s", user.Username)
+		if reason = strings.TrimSpace(reason); reason != "" {
+			detail += "，原因：" + reason
+		}
+		return &models.AuditLog{Action: models.AuditUserImpersonated, Detail: detail}, nil
+	}, actorID, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ImpersonationResult{
+		AccessToken: accessToken,
+		ExpiresIn:   int(ttl.Seconds()),
+		User:        user,
+	}, nil
+}
+
+// update 在事务中加载用户并执行变更，变更函数返回非空审计日志时补全操作者信息后写入
+func (s *AdminUserService) update(userID uint, apply func(tx *gorm.DB, user *models.User) (*models.AuditLog, error), actorID uint, client ClientInfo) (*models.User, error) {
+	var user models.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&user, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return fmt.Errorf("查询用户失败: %v", err)
+		}
+
+		entry, err := apply(tx, &user)
+		if err != nil || entry == nil {
+			return err
+		}
+		entry.ActorID = &actorID
+		entry.TargetUserID = &user.ID
+		entry.Subject = user.Username
+		entry.IP = client.IP
+		entry.UserAgent = client.UserAgent
+		return recordAudit(tx, entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ensureOtherSysAdmin 禁用、降级或删除系统管理员前，确认还有其他可用的系统管理员
+func ensureOtherSysAdmin(tx *gorm.DB, user *models.User) error {
+	if user.SystemRole != models.SystemRoleAdmin || user.Status != models.UserStatusEnabled {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&models.User{}).
+		Where("id <> ? AND system_role = ? AND status = ?", user.ID, models.SystemRoleAdmin, models.UserStatusEnabled).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("查询系统管理员失败: %v", err)
+	}
+	if count == 0 {
+		return ErrLastSysAdmin
+	}
+	return nil
+}
+
+// escapeLike 以 ! 为转义符转义 LIKE 通配符，使搜索词按字面匹配（MySQL 和 SQLite 通用）
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"progress-wall-backend/models"
+	"progress-wall-backend/storage"
+
+	"gorm.io/gorm"
+)
+
+// AttachmentService 任务附件服务
+type AttachmentService struct {
+	db            *gorm.DB
+	store         storage.Storage
+	permService   *PermissionService
+	maxUploadSize int64
+}
+
+// NewAttachmentService 创建附件服务
+func NewAttachmentService(db *gorm.DB, store storage.Storage, maxUploadSize int64) *AttachmentService {
+	return &AttachmentService{
+		db:            db,
+		store:         store,
+		permService:   NewPermissionService(db),
+		maxUploadSize: maxUploadSize,
+	}
+}
+
+// MaxUploadSize 返回允许上传的最大文件大小（字节）
+func (s *AttachmentService) MaxUploadSize() int64 {
+	return s.maxUploadSize
+}
+
+// ListAttachments 获取任务的附件列表
+func (s *AttachmentService) ListAttachments(taskID uint) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	err := s.db.Where("task_id = ? AND status = ?", taskID, models.AttachmentStatusNormal).
+		Preload("Uploader").
+		Order("created_at DESC").
+		Find(&attachments).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询附件列表失败: %v", err)
+	}
+	return attachments, nil
+}
+
+// UploadAttachment 上传附件
+// MIME 类型根据文件内容嗅探，不信任客户端提供的 Content-Type
+func (s *AttachmentService) UploadAttachment(taskID uint, actor Actor, fileHeader *multipart.FileHeader) (*models.Attachment, error) {
+	if fileHeader.Size > s.maxUploadSize {
+		return nil, ErrFileTooLarge
+	}
+	if fileHeader.Size == 0 {
+		return nil, ErrEmptyFile
+	}
+
+	var task models.Task
+	if err := s.db.First(&task, taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, fmt.Errorf("查询任务失败: %v", err)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("读取上传文件失败: %v", err)
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("读取上传文件失败: %v", err)
+	}
+	mimeType := http.DetectContentType(head[:n])
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("读取上传文件失败: %v", err)
+	}
+
+	originalName := filepath.Base(fileHeader.Filename)
+	filename, err := randomFilename(filepath.Ext(originalName))
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("attachments/%d/%s", taskID, filename)
+
+	if err := s.store.Put(key, file, fileHeader.Size, mimeType); err != nil {
+		return nil, fmt.Errorf("保存文件失败: %v", err)
+	}
+
+	attachment := &models.Attachment{
+		Filename:     filename,
+		OriginalName: originalName,
+		FilePath:     key,
+		FileSize:     fileHeader.Size,
+		MimeType:     mimeType,
+		TaskID:       taskID,
+		UploaderID:   actor.ID,
+		Status:       models.AttachmentStatusNormal,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(attachment).Error; err != nil {
+			return fmt.Errorf("保存附件信息失败: %v", err)
+		}
+		return recordTaskActivity(tx, &task, &models.ActivityLog{
+			UserID:         actor.ID,
+			Username:       actor.Name,
+			ImpersonatorID: actor.ImpersonatorID,
+			ActionType:     models.ActionAttach,
+			EntityType:     models.EntityAttachment,
+			EntityID:       attachment.ID,
+			Description:    fmt.Sprintf("attached \"%s\"", originalName),
+		})
+	})
+	if err != nil {
+		// 数据库写入失败时清理已上传的文件
+		s.store.Delete(key)
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// OpenAttachment 打开附件内容用于下载，调用方负责关闭返回的 ReadCloser
+func (s *AttachmentService) OpenAttachment(taskID, attachmentID uint) (*models.Attachment, io.ReadCloser, error) {
+	attachment, err := s.getAttachment(taskID, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader, err := s.store.Get(attachment.FilePath)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return nil, nil, ErrAttachmentNotFound
+		}
+		return nil, nil, fmt.Errorf("读取附件失败: %v", err)
+	}
+	return attachment, reader, nil
+}
+
+// DeleteAttachment 删除附件，上传者或项目管理员可以删除
+func (s *AttachmentService) DeleteAttachment(taskID, attachmentID uint, actor Actor) error {
+	attachment, err := s.getAttachment(taskID, attachmentID)
+	if err != nil {
+		return err
+	}
+
+	var task models.Task
+	if err := s.db.First(&task, taskID).Error; err != nil {
+		return fmt.Errorf("查询任务失败: %v", err)
+	}
+
+	if attachment.UploaderID != actor.ID {
+		canManage, err := s.permService.CanManageProject(actor.ID, task.ProjectID)
+		if err != nil {
+			return err
+		}
+		if !canManage {
+			return ErrAccessDenied
+		}
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(attachment).Update("status", models.AttachmentStatusDeleted).Error; err != nil {
+			return fmt.Errorf("删除附件失败: %v", err)
+		}
+		if err := tx.Delete(attachment).Error; err != nil {
+			return fmt.Errorf("删除附件失败: %v", err)
+		}
+		return recordTaskActivity(tx, &task, &models.ActivityLog{
+			UserID:         actor.ID,
+			Username:       actor.Name,
+			ImpersonatorID: actor.ImpersonatorID,
+			ActionType:     models.ActionDelete,
+			EntityType:     models.EntityAttachment,
+			EntityID:       attachment.ID,
+			Description:    fmt.Sprintf("removed attachment \"%s\"", attachment.OriginalName),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	// 文件删除失败不影响业务结果，记录已经被标记删除
+	if err := s.store.Delete(attachment.FilePath); err != nil {
+		log.Printf("删除附件文件失败: %v", err)
+	}
+	return nil
+}
+
+// getAttachment 查询属于指定任务的正常状态附件
+func (s *AttachmentService) getAttachment(taskID, attachmentID uint) (*models.Attachment, error) {
+	var attachment models.Attachment
+	err := s.db.Where("id = ? AND task_id = ? AND status = ?", attachmentID, taskID, models.AttachmentStatusNormal).
+		First(&attachment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, fmt.Errorf("查询附件失败: %v", err)
+	}
+	return &attachment, nil
+}
+
+// randomFilename 生成不可猜测的存储文件名，保留原始扩展名
+func randomFilename(ext string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成文件名失败: %v", err)
+	}
+	ext = strings.ToLower(ext)
+	if len(ext) > 10 || strings.TrimLeft(ext, ".abcdefghijklmnopqrstuvwxyz0123456789") != "" {
+		ext = ""
+	}
+	return fmt.Sprintf("%d_%s%s", time.Now().UnixNano(), hex.EncodeToString(buf), ext), nil
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"fmt"
+
+	"progress-wall-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// AuditService 安全审计日志服务
+type AuditService struct {
+	db *gorm.DB
+}
+
+// NewAuditService 创建审计日志服务
+func NewAuditService(db *gorm.DB) *AuditService {
+	return &AuditService{db: db}
+}
+
+// AuditLogQuery 审计日志查询条件
+type AuditLogQuery struct {
+	Action       string
+	TargetUserID uint
+	Page         int
+	PageSize     int
+}
+
+// ListAuditLogs 按时间倒序分页查询审计日志
+func (s *AuditService) ListAuditLogs(query AuditLogQuery) ([]models.AuditLog, int64, error) {
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PageSize < 1 || query.PageSize > 100 {
+		query.PageSize = 20
+	}
+
+	db := s.db.Model(&models.AuditLog{})
+	if query.Action != "" {
+		db = db.Where("action = ?", query.Action)
+	}
+	if query.TargetUserID != 0 {
+		db = db.Where("target_user_id = ?", query.TargetUserID)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询审计日志失败: %v", err)
+	}
+
+	var logs []models.AuditLog
+	err := db.Order("created_at DESC, id DESC").
+		Offset((query.Page - 1) * query.PageSize).
+		Limit(query.PageSize).
+		Find(&logs).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询审计日志失败: %v", err)
+	}
+	return logs, total, nil
+}
+
+// recordAudit 写入一条审计日志，调用方可传入事务保证与业务操作一致
+func recordAudit(tx *gorm.DB, entry *models.AuditLog) error {
+	entry.UserAgent = truncate(entry.UserAgent, 255)
+	if err := tx.Create(entry).Error; err != nil {
+		return fmt.Errorf("写入审计日志失败: %v", err)
+	}
+	return nil
+}
@@ -2,9 +2,12 @@ package services
 
 import (
 	"errors"
+	"log"
+	"sync"
 	"time"
 
 	"progress-wall-backend/config"
+	"progress-wall-backend/mailer"
 	"progress-wall-backend/models"
 	"progress-wall-backend/utils"
 
@@ -13,28 +16,55 @@ import (
 
 // AuthService 认证服务
 type AuthService struct {
-	db  *gorm.DB
-	cfg *config.Config
+	db              *gorm.DB
+	cfg             *config.Config
+	sessionService  *SessionService
+	emailService    *EmailService
+	throttleService *LoginThrottleService
+	ldapService     *LDAPService
 }
 
 // NewAuthService 创建认证服务
-func NewAuthService(db *gorm.DB, cfg *config.Config) *AuthService {
+func NewAuthService(db *gorm.DB, cfg *config.Config, mail mailer.Mailer) *AuthService {
 	return &AuthService{
-		db:  db,
-		cfg: cfg,
+		db:              db,
+		cfg:             cfg,
+		sessionService:  NewSessionService(db, cfg),
+		emailService:    NewEmailService(db, mail, cfg),
+		throttleService: NewLoginThrottleService(db, cfg),
+		ldapService:     NewLDAPService(db, cfg),
 	}
 }
 
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// dummyPasswordHash 用户不存在时用于比对的哈希，使响应耗时与用户存在时一致
+func dummyPasswordHash() string {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = utils.HashPassword("progress-wall-dummy-password")
+	})
+	return dummyHash
+}
+
 // LoginRequest 登录请求结构
 type LoginRequest struct {
 	Username string
 	Password string
+	Client   ClientInfo
 }
 
 // LoginResult 登录结果
+// 启用两步验证的用户密码校验通过后只返回 MFAToken，需再调用 VerifyMFA 完成登录
 type LoginResult struct {
-	AccessToken string
-	User        *models.User
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int
+	User         *models.User
+	MFARequired  bool
+	MFAToken     string
 }
 
 // Login 处理登录业务逻辑
@@ -44,11 +74,58 @@ func (s *AuthService) Login(req LoginRequest) (*LoginResult, error) {
 	result := s.db.Where("username = ? OR email = ?", req.Username, req.Username).
 		First(&user)
 
+	found := true
 	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, ErrInvalidCredentials
+		if !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
 		}
-		return nil, ErrUserNotFound
+		found = false
+	}
+
+	// 账号或IP处于锁定期时直接拒绝，不再校验密码
+	account := AccountSubject(&user, req.Username)
+	if err := s.throttleService.Check(account, req.Client.IP); err != nil {
+		return nil, err
+	}
+
+	// 目录用户以及本地不存在的登录名在启用LDAP时通过目录校验密码
+	useLDAP := false
+	if s.ldapService.Enabled() {
+		useLDAP = !found
+		if found {
+			isDirectoryUser, err := s.ldapService.isDirectoryUser(user.ID)
+			if err != nil {
+				return nil, err
+			}
+			useLDAP = isDirectoryUser
+		}
+	}
+
+	// 验证密码（用户不存在时同样执行一次哈希比对并计入失败次数，避免暴露用户是否存在）
+	passwordOK := false
+	if useLDAP {
+		ldapUser, err := s.ldapService.Login(req.Username, req.Password)
+		if err != nil && err != errLDAPInvalidCredentials {
+			log.Printf("LDAP 登录失败: %v", err)
+			return nil, ErrLDAPUnavailable
+		}
+		if err == nil {
+			user, found, passwordOK = *ldapUser, true, true
+		}
+	} else if !found {
+		utils.CheckPasswordHash(req.Password, dummyPasswordHash())
+	} else {
+		passwordOK = utils.CheckPasswordHash(req.Password, user.Password)
+	}
+	if !passwordOK {
+		var userID *uint
+		if found {
+			userID = &user.ID
+		}
+		if err := s.throttleService.RecordFailure(account, userID, req.Client); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidCredentials
 	}
 
 	// 检查用户状态
@@ -56,30 +133,94 @@ func (s *AuthService) Login(req LoginRequest) (*LoginResult, error) {
 		return nil, ErrUserDisabled
 	}
 
-	// 验证密码
-	if !utils.CheckPasswordHash(req.Password, user.Password) {
-		return nil, ErrInvalidCredentials
+	// 开启邮箱验证策略时，未验证邮箱的用户不能登录
+	if s.cfg.Auth.RequireEmailVerification && !user.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+
+	// 启用两步验证时先返回待验证令牌
+	if user.TOTPEnabled {
+		mfaToken, err := utils.GenerateMFAToken(user.ID, user.Username, s.cfg)
+		if err != nil {
+			return nil, ErrGenerateToken
+		}
+		return &LoginResult{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		}, nil
+	}
+
+	return s.completeLogin(&user, req.Client)
+}
+
+// VerifyMFA 两步验证第二步：校验待验证令牌和验证码（或恢复码）后完成登录
+func (s *AuthService) VerifyMFA(mfaToken, code string, client ClientInfo) (*LoginResult, error) {
+	claims, err := utils.ValidateToken(mfaToken, s.cfg)
+	if err != nil || claims.Purpose != utils.MFATokenPurpose {
+		return nil, ErrInvalidMFAToken
 	}
 
-	// 生成JWT token
-	token, err := utils.GenerateToken(user.ID, user.Username, s.cfg)
+	var user models.User
+	if err := s.db.First(&user, claims.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidMFAToken
+		}
+		return nil, ErrUserNotFound
+	}
+	if user.Status != models.UserStatusEnabled {
+		return nil, ErrUserDisabled
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrInvalidMFAToken
+	}
+
+	// 验证码错误同样计入账号的登录失败次数，防止暴力猜测验证码
+	account := AccountSubject(&user, "")
+	if err := s.throttleService.Check(account, client.IP); err != nil {
+		return nil, err
+	}
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		return verifySecondFactor(tx, &user, code)
+	}); err != nil {
+		if err == ErrInvalidMFACode {
+			if err := s.throttleService.RecordFailure(account, &user.ID, client); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	return s.completeLogin(&user, client)
+}
+
+// completeLogin 创建登录会话、签发令牌并更新最后登录时间
+func (s *AuthService) completeLogin(user *models.User, client ClientInfo) (*LoginResult, error) {
+	// 创建登录会话并签发令牌
+	tokens, err := s.sessionService.CreateSession(user, client)
 	if err != nil {
-		return nil, ErrGenerateToken
+		return nil, err
 	}
 
 	// 更新最后登录时间
 	now := time.Now()
 	user.LastLogin = &now
-	if err := s.db.Save(&user).Error; err != nil {
+	if err := s.db.Model(user).UpdateColumn("last_login", now).Error; err != nil {
 		return nil, ErrUpdateLoginTime
 	}
 
+	// 完成登录后清除账号的失败计数
+	if err := s.throttleService.Reset(AccountSubject(user, "")); err != nil {
+		return nil, err
+	}
+
 	// 清除敏感信息
 	user.Password = ""
 
 	return &LoginResult{
-		AccessToken: token,
-		User:        &user,
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+		User:         user,
 	}, nil
 }
 
@@ -89,11 +230,14 @@ type RegisterRequest struct {
 	Email    string
 	Password string
 	Nickname string
+	// InviteToken 可选的邀请令牌，注册成功后自动加入邀请对应的团队/项目
+	InviteToken string
 }
 
 // RegisterResult 注册结果
 type RegisterResult struct {
-	User *models.User
+	User       *models.User
+	Invitation *models.Invitation
 }
 
 // Register 处理注册业务逻辑
@@ -147,14 +291,46 @@ func (s *AuthService) Register(req RegisterRequest) (*RegisterResult, error) {
 		Status:   models.UserStatusEnabled,
 	}
 
-	if err := s.db.Create(&user).Error; err != nil {
-		return nil, errors.New("创建用户失败")
+	// 创建用户与接受邀请在同一事务中完成，邀请无效时不会创建账号
+	var invitation *models.Invitation
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			// 并发注册时检查通过后仍可能触发唯一索引冲突
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return ErrUserExists
+			}
+			return errors.New("创建用户失败")
+		}
+		if req.InviteToken == "" {
+			return nil
+		}
+		var err error
+		invitation, err = acceptInvitation(tx, req.InviteToken, &user)
+		if err != nil {
+			return err
+		}
+		// 指定邮箱的邀请已通过该邮箱送达，可视为邮箱已验证
+		if invitation.Email != "" {
+			user.EmailVerified = true
+			return tx.Model(&user).Update("email_verified", true).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.EmailVerified {
+		if err := s.emailService.SendVerification(&user); err != nil {
+			return nil, err
+		}
 	}
 
 	// 清除敏感信息
 	user.Password = ""
 
 	return &RegisterResult{
-		User: &user,
+		User:       &user,
+		Invitation: invitation,
 	}, nil
 }
@@ -29,9 +29,10 @@ func (s *BoardService) GetBoardByID(boardID uint) (*models.Board, error) {
 			return db.Order("position ASC")
 		}).
 		Preload("Columns.Tasks", func(db *gorm.DB) *gorm.DB {
-			return db.Order("position ASC").Preload("Assignee")
+			return db.Order("position ASC").Preload("Assignees")
 		}).
 		Preload("Columns.Tasks.Creator").
+		Preload("Columns.Tasks.CustomFields").
 		Preload("Owner").
 		First(&board, boardID)
 
@@ -42,6 +43,20 @@ func (s *BoardService) GetBoardByID(boardID uint) (*models.Board, error) {
 		return nil, fmt.Errorf("查询看板失败: %v", result.Error)
 	}
 
+	// 卡片上显示任务编号以及检查项和子任务进度
+	var tasks []*models.Task
+	for i := range board.Columns {
+		for j := range board.Columns[i].Tasks {
+			tasks = append(tasks, &board.Columns[i].Tasks[j])
+		}
+	}
+	if err := fillTaskProgress(s.db, tasks); err != nil {
+		return nil, err
+	}
+	if err := fillTaskKeys(s.db, tasks); err != nil {
+		return nil, err
+	}
+
 	return &board, nil
 }
 
@@ -124,6 +139,7 @@ func (s *BoardService) CreateBoard(board *models.Board) error {
 				Position:    5000,
 				BoardID:     board.ID,
 				Status:      models.ColumnStatusActive,
+				IsDone:      true,
 			},
 		}
 
@@ -0,0 +1,321 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// CommentService 任务评论服务
+type CommentService struct {
+	db          *gorm.DB
+	permService *PermissionService
+	notifier    *Notifier
+}
+
+// NewCommentService 创建评论服务
+func NewCommentService(db *gorm.DB, cfg *config.Config) *CommentService {
+	return &CommentService{
+		db:          db,
+		permService: NewPermissionService(db),
+		notifier:    NewNotifier(db, cfg),
+	}
+}
+
+// ListComments 分页获取任务的评论树
+// 分页以顶层评论为单位，每条顶层评论携带其全部回复（按时间升序）
+// 已删除的评论保留在树中但清空内容；隐藏的评论仅作者和项目管理员可见内容
+func (s *CommentService) ListComments(taskID, viewerID uint, page, pageSize int) ([]models.Comment, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20 // 默认每页20条
+	}
+
+	task, err := s.getTask(s.db, taskID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int64
+	if err := s.db.Model(&models.Comment{}).
+		Where("task_id = ? AND parent_id IS NULL", taskID).
+		Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询评论总数失败: %v", err)
+	}
+
+	var roots []models.Comment
+	if err := s.db.Where("task_id = ? AND parent_id IS NULL", taskID).
+		Preload("User").
+		Order("created_at ASC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&roots).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询评论失败: %v", err)
+	}
+
+	// 逐层查询当前页顶层评论下的回复
+	var replies []models.Comment
+	parentIDs := make([]uint, len(roots))
+	for i, root := range roots {
+		parentIDs[i] = root.ID
+	}
+	for len(parentIDs) > 0 {
+		var level []models.Comment
+		if err := s.db.Where("task_id = ? AND parent_id IN ?", taskID, parentIDs).
+			Preload("User").
+			Order("created_at ASC").
+			Find(&level).Error; err != nil {
+			return nil, 0, fmt.Errorf("查询评论回复失败: %v", err)
+		}
+		replies = append(replies, level...)
+		parentIDs = parentIDs[:0]
+		for _, reply := range level {
+			parentIDs = append(parentIDs, reply.ID)
+		}
+	}
+
+	canModerate, err := s.permService.CanManageProject(viewerID, task.ProjectID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	children := make(map[uint][]models.Comment)
+	for _, reply := range replies {
+		children[*reply.ParentID] = append(children[*reply.ParentID], reply)
+	}
+
+	var attach func(comment *models.Comment)
+	attach = func(comment *models.Comment) {
+		maskComment(comment, viewerID, canModerate)
+		comment.Replies = children[comment.ID]
+		for i := range comment.Replies {
+			attach(&comment.Replies[i])
+		}
+	}
+	for i := range roots {
+		attach(&roots[i])
+	}
+
+	return roots, total, nil
+}
+
+// CreateComment 创建评论或回复
+func (s *CommentService) CreateComment(taskID uint, actor Actor, content string, parentID *uint) (*models.Comment, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, ErrInvalidComment
+	}
+
+	comment := &models.Comment{
+		Content:  content,
+		TaskID:   taskID,
+		UserID:   actor.ID,
+		ParentID: parentID,
+		Status:   models.CommentStatusNormal,
+	}
+
+	var task *models.Task
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		task, err = s.getTask(tx, taskID)
+		if err != nil {
+			return err
+		}
+
+		description := "commented on this task"
+		if parentID != nil {
+			var parent models.Comment
+			if err := tx.Where("id = ? AND task_id = ?", *parentID, taskID).First(&parent).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrParentComment
+				}
+				return fmt.Errorf("查询父评论失败: %v", err)
+			}
+			if parent.Status == models.CommentStatusDeleted {
+				return ErrParentComment
+			}
+			description = "replied to a comment"
+		}
+
+		if err := tx.Create(comment).Error; err != nil {
+			return fmt.Errorf("创建评论失败: %v", err)
+		}
+
+		return recordTaskActivity(tx, task, &models.ActivityLog{
+			UserID:         actor.ID,
+			Username:       actor.Name,
+			ImpersonatorID: actor.ImpersonatorID,
+			ActionType:     models.ActionComment,
+			EntityType:     models.EntityComment,
+			EntityID:       comment.ID,
+			Description:    description,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.db.Preload("User").First(comment, comment.ID)
+	s.notifier.NotifyTaskAsync(*task, NotificationComment, fmt.Sprintf("%s: %s", actor.Name, truncate(content, 200)), actor.ID)
+	return comment, nil
+}
+
+// UpdateComment 编辑评论，仅评论作者可以编辑
+func (s *CommentService) UpdateComment(taskID, commentID uint, actor Actor, content string) (*models.Comment, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, ErrInvalidComment
+	}
+
+	var comment models.Comment
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		task, err := s.getTask(tx, taskID)
+		if err != nil {
+			return err
+		}
+		if err := s.getComment(tx, taskID, commentID, &comment); err != nil {
+			return err
+		}
+		if comment.UserID != actor.ID {
+			return ErrAccessDenied
+		}
+
+		comment.Content = content
+		if err := tx.Model(&comment).Update("content", content).Error; err != nil {
+			return fmt.Errorf("更新评论失败: %v", err)
+		}
+
+		return recordTaskActivity(tx, task, &models.ActivityLog{
+			UserID:         actor.ID,
+			Username:       actor.Name,
+			ImpersonatorID: actor.ImpersonatorID,
+			ActionType:     models.ActionComment,
+			EntityType:     models.EntityComment,
+			EntityID:       comment.ID,
+			Description:    "edited a comment",
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.db.Preload("User").First(&comment, comment.ID)
+	return &comment, nil
+}
+
+// SetCommentHidden 隐藏或恢复评论（权限由路由层的 manage 级别校验保证）
+func (s *CommentService) SetCommentHidden(taskID, commentID uint, actor Actor, hidden bool) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		task, err := s.getTask(tx, taskID)
+		if err != nil {
+			return err
+		}
+		var comment models.Comment
+		if err := s.getComment(tx, taskID, commentID, &comment); err != nil {
+			return err
+		}
+
+		status := models.CommentStatusNormal
+		description := "restored a hidden comment"
+		if hidden {
+			status = models.CommentStatusHidden
+			description = "hid a comment"
+		}
+		if err := tx.Model(&comment).Update("status", status).Error; err != nil {
+			return fmt.Errorf("更新评论状态失败: %v", err)
+		}
+
+		return recordTaskActivity(tx, task, &models.ActivityLog{
+			UserID:         actor.ID,
+			Username:       actor.Name,
+			ImpersonatorID: actor.ImpersonatorID,
+			ActionType:     models.ActionComment,
+			EntityType:     models.EntityComment,
+			EntityID:       comment.ID,
+			Description:    description,
+		})
+	})
+}
+
+// DeleteComment 删除评论（标记为已删除，保留在回复树中）
+// 评论作者或项目管理员可以删除
+func (s *CommentService) DeleteComment(taskID, commentID uint, actor Actor) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		task, err := s.getTask(tx, taskID)
+		if err != nil {
+			return err
+		}
+		var comment models.Comment
+		if err := s.getComment(tx, taskID, commentID, &comment); err != nil {
+			return err
+		}
+
+		if comment.UserID != actor.ID {
+			canManage, err := s.permService.CanManageProject(actor.ID, task.ProjectID)
+			if err != nil {
+				return err
+			}
+			if !canManage {
+				return ErrAccessDenied
+			}
+		}
+
+		if err := tx.Model(&comment).Update("status", models.CommentStatusDeleted).Error; err != nil {
+			return fmt.Errorf("删除评论失败: %v", err)
+		}
+
+		return recordTaskActivity(tx, task, &models.ActivityLog{
+			UserID:         actor.ID,
+			Username:       actor.Name,
+			ImpersonatorID: actor.ImpersonatorID,
+			ActionType:     models.ActionComment,
+			EntityType:     models.EntityComment,
+			EntityID:       comment.ID,
+			Description:    "deleted a comment",
+		})
+	})
+}
+
+// getTask 查询评论所属任务
+func (s *CommentService) getTask(tx *gorm.DB, taskID uint) (*models.Task, error) {
+	var task models.Task
+	if err := tx.First(&task, taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, fmt.Errorf("查询任务失败: %v", err)
+	}
+	return &task, nil
+}
+
+// getComment 查询属于指定任务且未被删除的评论
+func (s *CommentService) getComment(tx *gorm.DB, taskID, commentID uint, comment *models.Comment) error {
+	err := tx.Where("id = ? AND task_id = ? AND status <> ?", commentID, taskID, models.CommentStatusDeleted).
+		First(comment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCommentNotFound
+		}
+		return fmt.Errorf("查询评论失败: %v", err)
+	}
+	return nil
+}
+
+// maskComment 根据评论状态和查看者权限清除不可见的内容
+func maskComment(comment *models.Comment, viewerID uint, canModerate bool) {
+	switch comment.Status {
+	case models.CommentStatusDeleted:
+		comment.Content = ""
+	case models.CommentStatusHidden:
+		if comment.UserID != viewerID && !canModerate {
+			comment.Content = ""
+		}
+	}
+}
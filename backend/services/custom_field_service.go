@@ -0,0 +1,549 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"progress-wall-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// customFieldDateLayout 日期字段的格式
+const customFieldDateLayout = "2006-01-02"
+
+// maxCustomFieldText 文本字段值的最大字符数
+const maxCustomFieldText = 1000
+
+// CustomFieldService 项目自定义字段服务
+type CustomFieldService struct {
+	db *gorm.DB
+}
+
+// NewCustomFieldService 创建自定义字段服务
+func NewCustomFieldService(db *gorm.DB) *CustomFieldService {
+	return &CustomFieldService{
+		db: db,
+	}
+}
+
+// CustomFieldInput 创建或更新自定义字段的参数，更新时为 nil 的字段保持不变，Type 创建后不可修改
+type CustomFieldInput struct {
+	Name     *string
+	Type     string
+	Options  *[]string
+	Required *bool
+	Position *int
+}
+
+// GetProjectFields 获取项目的自定义字段定义
+func (s *CustomFieldService) GetProjectFields(projectID uint) ([]models.CustomField, error) {
+	return projectCustomFields(s.db, projectID)
+}
+
+// CreateField 创建自定义字段，排在项目已有字段之后
+func (s *CustomFieldService) CreateField(projectID uint, input CustomFieldInput, actor Actor) (*models.CustomField, error) {
+	if input.Name == nil || strings.TrimSpace(*input.Name) == "" {
+		return nil, ErrCustomFieldNameRequired
+	}
+	if !models.ValidCustomFieldType(input.Type) {
+		return nil, ErrInvalidCustomFieldType
+	}
+	field := &models.CustomField{
+		ProjectID: projectID,
+		Name:      strings.TrimSpace(*input.Name),
+		Type:      input.Type,
+	}
+	if input.Required != nil {
+		field.Required = *input.Required
+	}
+
+	var options []string
+	if input.Options != nil {
+		options = *input.Options
+	}
+	options, err := normalizeFieldOptions(field.Type, options)
+	if err != nil {
+		return nil, err
+	}
+	field.Options = options
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.ensureUniqueName(tx, projectID, field.Name, 0); err != nil {
+			return err
+		}
+
+		var maxPosition int
+		if err := tx.Model(&models.CustomField{}).
+			Where("project_id = ?", projectID).
+			Select("COALESCE(MAX(position), -1)").
+			Scan(&maxPosition).Error; err != nil {
+			return fmt.Errorf("查询自定义字段失败: %v", err)
+		}
+		field.Position = maxPosition + 1
+		if input.Position != nil {
+			field.Position = *input.Position
+		}
+
+		if err := tx.Create(field).Error; err != nil {
+			return fmt.Errorf("创建自定义字段失败: %v", err)
+		}
+		return tx.Create(&models.ActivityLog{
+			UserID:         actor.ID,
+			Username:       actor.Name,
+			ImpersonatorID: actor.ImpersonatorID,
+			ActionType:     models.ActionCreate,
+			EntityType:     models.EntityCustomField,
+			EntityID:       field.ID,
+			ProjectID:      &projectID,
+			Description:    fmt.Sprintf("created custom field \"%s\"", field.Name),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return field, nil
+}
+
+// UpdateField 更新自定义字段的名称、选项、是否必填或排序，仍被任务使用的选项不能删除
+func (s *CustomFieldService) UpdateField(projectID, fieldID uint, input CustomFieldInput, actor Actor) (*models.CustomField, error) {
+	var field models.CustomField
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.getField(tx, projectID, fieldID, &field); err != nil {
+			return err
+		}
+
+		var columns []string
+		if input.Name != nil {
+			name := strings.TrimSpace(*input.Name)
+			if name == "" {
+				return ErrCustomFieldNameRequired
+			}
+			if err := s.ensureUniqueName(tx, projectID, name, fieldID); err != nil {
+				return err
+			}
+			field.Name = name
+			columns = append(columns, "name")
+		}
+		if input.Options != nil {
+			options, err := normalizeFieldOptions(field.Type, *input.Options)
+			if err != nil {
+				return err
+			}
+			if err := s.ensureOptionsUnused(tx, &field, options); err != nil {
+				return err
+			}
+			field.Options = options
+			columns = append(columns, "options")
+		}
+		if input.Required != nil {
+			field.Required = *input.Required
+			columns = append(columns, "required")
+		}
+		if input.Position != nil {
+			field.Position = *input.Position
+			columns = append(columns, "position")
+		}
+		if len(columns) == 0 {
+			return nil
+		}
+
+		// 按结构体更新以便选项经过 JSON 序列化，Select 保证 required=false 等零值也会写入
+		if err := tx.Model(&field).Select(columns).Updates(&field).Error; err != nil {
+			return fmt.Errorf("更新自定义字段失败: %v", err)
+		}
+		return tx.Create(&models.ActivityLog{
+			UserID:         actor.ID,
+			Username:       actor.Name,
+			ImpersonatorID: actor.ImpersonatorID,
+			ActionType:     models.ActionUpdate,
+			EntityType:     models.EntityCustomField,
+			EntityID:       field.ID,
+			ProjectID:      &projectID,
+			Description:    fmt.Sprintf("updated custom field \"%s\"", field.Name),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &field, nil
+}
+
+// DeleteField 删除自定义字段及所有任务上的字段值
+func (s *CustomFieldService) DeleteField(projectID, fieldID uint, actor Actor) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var field models.CustomField
+		if err := s.getField(tx, projectID, fieldID, &field); err != nil {
+			return err
+		}
+
+		if err := tx.Where("field_id = ?", fieldID).Delete(&models.CustomFieldValue{}).Error; err != nil {
+			return fmt.Errorf("删除自定义字段值失败: %v", err)
+		}
+		if err := tx.Delete(&field).Error; err != nil {
+			return fmt.Errorf("删除自定义字段失败: %v", err)
+		}
+		return tx.Create(&models.ActivityLog{
+			UserID:         actor.ID,
+			Username:       actor.Name,
+			ImpersonatorID: actor.ImpersonatorID,
+			ActionType:     models.ActionDelete,
+			EntityType:     models.EntityCustomField,
+			EntityID:       field.ID,
+			ProjectID:      &projectID,
+			Description:    fmt.Sprintf("deleted custom field \"%s\"", field.Name),
+		}).Error
+	})
+}
+
+// getField 查询属于指定项目的自定义字段
+func (s *CustomFieldService) getField(tx *gorm.DB, projectID, fieldID uint, field *models.CustomField) error {
+	if err := tx.Where("id = ? AND project_id = ?", fieldID, projectID).First(field).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCustomFieldNotFound
+		}
+		return fmt.Errorf("查询自定义字段失败: %v", err)
+	}
+	return nil
+}
+
+// ensureUniqueName 校验项目内字段名称唯一（excludeID 为更新时排除的自身ID）
+func (s *CustomFieldService) ensureUniqueName(tx *gorm.DB, projectID uint, name string, excludeID uint) error {
+	var count int64
+	if err := tx.Model(&models.CustomField{}).
+		Where("project_id = ? AND name = ? AND id <> ?", projectID, name, excludeID).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("查询自定义字段失败: %v", err)
+	}
+	if count > 0 {
+		return ErrCustomFieldExists
+	}
+	return nil
+}
+
+// ensureOptionsUnused 检查更新后被移除的选项没有任务在使用
+func (s *CustomFieldService) ensureOptionsUnused(tx *gorm.DB, field *models.CustomField, options []string) error {
+	kept := make(map[string]bool, len(options))
+	for _, option := range options {
+		kept[option] = true
+	}
+	for _, option := range field.Options {
+		if kept[option] {
+			continue
+		}
+		query, arg := customFieldMatch(field, option)
+		var count int64
+		if err := tx.Model(&models.CustomFieldValue{}).
+			Where("field_id = ?", field.ID).
+			Where(query, arg).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("查询自定义字段值失败: %v", err)
+		}
+		if count > 0 {
+			return ErrCustomFieldOptionInUse
+		}
+	}
+	return nil
+}
+
+// projectCustomFields 按排序位置查询项目的自定义字段
+func projectCustomFields(db *gorm.DB, projectID uint) ([]models.CustomField, error) {
+	var fields []models.CustomField
+	if err := db.Where("project_id = ?", projectID).
+		Order("position ASC, id ASC").
+		Find(&fields).Error; err != nil {
+		return nil, fmt.Errorf("查询自定义字段失败: %v", err)
+	}
+	return fields, nil
+}
+
+// normalizeFieldOptions 去除选项首尾空白；只有单选和多选字段有选项，且至少一个、不能为空或重复
+func normalizeFieldOptions(fieldType string, options []string) ([]string, error) {
+	if fieldType != models.CustomFieldSelect && fieldType != models.CustomFieldMultiSelect {
+		return nil, nil
+	}
+	if len(options) == 0 {
+		return nil, ErrInvalidCustomFieldOptions
+	}
+
+	seen := make(map[string]bool, len(options))
+	normalized := make([]string, 0, len(options))
+	for _, option := range options {
+		option = strings.TrimSpace(option)
+		if option == "" || utf8.RuneCountInString(option) > 100 || seen[option] {
+			return nil, ErrInvalidCustomFieldOptions
+		}
+		seen[option] = true
+		normalized = append(normalized, option)
+	}
+	return normalized, nil
+}
+
+// normalizeFieldValue 按字段类型校验任务的字段值，返回 nil 表示清空该字段
+func normalizeFieldValue(db *gorm.DB, field *models.CustomField, raw json.RawMessage) (interface{}, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	switch field.Type {
+	case models.CustomFieldText:
+		var text string
+		if err := json.Unmarshal(raw, &text); err != nil {
+			return nil, ErrInvalidCustomFieldValue
+		}
+		text = strings.TrimSpace(text)
+		if utf8.RuneCountInString(text) > maxCustomFieldText {
+			return nil, ErrInvalidCustomFieldValue
+		}
+		if text == "" {
+			return nil, nil
+		}
+		return text, nil
+
+	case models.CustomFieldNumber:
+		var number float64
+		if err := json.Unmarshal(raw, &number); err != nil {
+			return nil, ErrInvalidCustomFieldValue
+		}
+		return number, nil
+
+	case models.CustomFieldDate:
+		var date string
+		if err := json.Unmarshal(raw, &date); err != nil {
+			return nil, ErrInvalidCustomFieldValue
+		}
+		if _, err := time.Parse(customFieldDateLayout, date); err != nil {
+			return nil, ErrInvalidCustomFieldValue
+		}
+		return date, nil
+
+	case models.CustomFieldSelect:
+		var option string
+		if err := json.Unmarshal(raw, &option); err != nil || !containsOption(field.Options, option) {
+			return nil, ErrInvalidCustomFieldValue
+		}
+		return option, nil
+
+	case models.CustomFieldMultiSelect:
+		var selected []string
+		if err := json.Unmarshal(raw, &selected); err != nil {
+			return nil, ErrInvalidCustomFieldValue
+		}
+		// 按字段定义中的选项顺序保存，去掉重复项
+		chosen := make(map[string]bool, len(selected))
+		for _, option := range selected {
+			if !containsOption(field.Options, option) {
+				return nil, ErrInvalidCustomFieldValue
+			}
+			chosen[option] = true
+		}
+		var options []string
+		for _, option := range field.Options {
+			if chosen[option] {
+				options = append(options, option)
+			}
+		}
+		if len(options) == 0 {
+			return nil, nil
+		}
+		return options, nil
+
+	case models.CustomFieldUser:
+		var userID uint
+		if err := json.Unmarshal(raw, &userID); err != nil || userID == 0 {
+			return nil, ErrInvalidCustomFieldValue
+		}
+		canAccess, err := NewPermissionService(db).CanAccessProject(userID, field.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		if !canAccess {
+			return nil, ErrNotProjectMember
+		}
+		return userID, nil
+
+	case models.CustomFieldCheckbox:
+		var checked bool
+		if err := json.Unmarshal(raw, &checked); err != nil {
+			return nil, ErrInvalidCustomFieldValue
+		}
+		return checked, nil
+	}
+	return nil, ErrInvalidCustomFieldType
+}
+
+// containsOption 选项是否在字段定义中
+func containsOption(options []string, option string) bool {
+	for _, o := range options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// setTaskCustomFields 写入任务的自定义字段值，值为 null 时清空该字段；必填字段不能清空，
+// 创建任务时（creating 为 true）所有必填字段都必须填写。返回值有变化的字段名称
+func setTaskCustomFields(tx *gorm.DB, task *models.Task, values map[uint]json.RawMessage, creating bool) ([]string, error) {
+	fields, err := projectCustomFields(tx, task.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]bool, len(fields))
+	for _, field := range fields {
+		byID[field.ID] = true
+	}
+	for fieldID := range values {
+		if !byID[fieldID] {
+			return nil, ErrCustomFieldNotFound
+		}
+	}
+
+	var changed []string
+	for i := range fields {
+		field := &fields[i]
+		raw, ok := values[field.ID]
+		if !ok {
+			if creating && field.Required {
+				return nil, ErrCustomFieldRequired
+			}
+			continue
+		}
+		value, err := normalizeFieldValue(tx, field, raw)
+		if err != nil {
+			return nil, err
+		}
+
+		if value == nil {
+			if field.Required {
+				return nil, ErrCustomFieldRequired
+			}
+			result := tx.Where("task_id = ? AND field_id = ?", task.ID, field.ID).Delete(&models.CustomFieldValue{})
+			if result.Error != nil {
+				return nil, fmt.Errorf("清空自定义字段失败: %v", result.Error)
+			}
+			if result.RowsAffected > 0 {
+				changed = append(changed, field.Name)
+			}
+			continue
+		}
+
+		var existing models.CustomFieldValue
+		err = tx.Where("task_id = ? AND field_id = ?", task.ID, field.ID).First(&existing).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := tx.Create(&models.CustomFieldValue{TaskID: task.ID, FieldID: field.ID, Value: value}).Error; err != nil {
+				return nil, fmt.Errorf("保存自定义字段失败: %v", err)
+			}
+		case err != nil:
+			return nil, fmt.Errorf("查询自定义字段值失败: %v", err)
+		default:
+			if sameFieldValue(existing.Value, value) {
+				continue
+			}
+			existing.Value = value
+			if err := tx.Save(&existing).Error; err != nil {
+				return nil, fmt.Errorf("保存自定义字段失败: %v", err)
+			}
+		}
+		changed = append(changed, field.Name)
+	}
+	return changed, nil
+}
+
+// sameFieldValue 比较两个字段值的 JSON 表示是否相同
+func sameFieldValue(a, b interface{}) bool {
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(aJSON) == string(bJSON)
+}
+
+// customFieldMatch 返回匹配字段值的查询条件：多选字段包含该选项，文本字段包含该文本（不区分大小写），
+// 其他类型与 value 相等。字段值以 JSON 文本保存，条件按 JSON 编码后的文本比较
+func customFieldMatch(field *models.CustomField, value interface{}) (string, interface{}) {
+	encoded, _ := json.Marshal(value)
+	switch field.Type {
+	case models.CustomFieldText:
+		text := strings.Trim(string(encoded), `"`)
+		return "LOWER(value) LIKE ? ESCAPE '!'", "%" + escapeLike(strings.ToLower(text)) + "%"
+	case models.CustomFieldMultiSelect:
+		return "value LIKE ? ESCAPE '!'", "%" + escapeLike(string(encoded)) + "%"
+	}
+	return "value = ?", string(encoded)
+}
+
+// parseFieldFilter 将查询参数中的筛选值转换为字段类型对应的值
+func parseFieldFilter(field *models.CustomField, filter string) (interface{}, error) {
+	switch field.Type {
+	case models.CustomFieldNumber:
+		number, err := strconv.ParseFloat(filter, 64)
+		if err != nil || math.IsInf(number, 0) || math.IsNaN(number) {
+			return nil, ErrInvalidCustomFieldValue
+		}
+		return number, nil
+	case models.CustomFieldDate:
+		if _, err := time.Parse(customFieldDateLayout, filter); err != nil {
+			return nil, ErrInvalidCustomFieldValue
+		}
+	case models.CustomFieldUser:
+		userID, err := strconv.ParseUint(filter, 10, 32)
+		if err != nil {
+			return nil, ErrInvalidCustomFieldValue
+		}
+		return uint(userID), nil
+	case models.CustomFieldCheckbox:
+		checked, err := strconv.ParseBool(filter)
+		if err != nil {
+			return nil, ErrInvalidCustomFieldValue
+		}
+		return checked, nil
+	}
+	return filter, nil
+}
+
+// applyCustomFieldFilters 按自定义字段筛选项目内的任务。勾选框字段筛选 false 时包括未填写的任务
+func applyCustomFieldFilters(db *gorm.DB, projectID uint, filters map[uint]string) (*gorm.DB, error) {
+	if len(filters) == 0 {
+		return db, nil
+	}
+	fields, err := projectCustomFields(db.Session(&gorm.Session{NewDB: true}), projectID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.CustomField, len(fields))
+	for i := range fields {
+		byID[fields[i].ID] = &fields[i]
+	}
+
+	for fieldID, filter := range filters {
+		field, ok := byID[fieldID]
+		if !ok {
+			return nil, ErrCustomFieldNotFound
+		}
+		value, err := parseFieldFilter(field, strings.TrimSpace(filter))
+		if err != nil {
+			return nil, err
+		}
+
+		matched := db.Session(&gorm.Session{NewDB: true}).
+			Model(&models.CustomFieldValue{}).
+			Select("task_id").
+			Where("field_id = ?", field.ID)
+		if field.Type == models.CustomFieldCheckbox && value == false {
+			query, arg := customFieldMatch(field, true)
+			db = db.Where("id NOT IN (?)", matched.Where(query, arg))
+			continue
+		}
+		query, arg := customFieldMatch(field, value)
+		db = db.Where("id IN (?)", matched.Where(query, arg))
+	}
+	return db, nil
+}
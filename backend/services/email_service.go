@@ -0,0 +1,202 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/mailer"
+	"progress-wall-backend/models"
+	"progress-wall-backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// emailTokenTTL 邮箱验证链接有效期
+const emailTokenTTL = 24 * time.Hour
+
+// EmailService 邮箱验证与修改邮箱服务
+type EmailService struct {
+	db          *gorm.DB
+	mailer      mailer.Mailer
+	frontendURL string
+}
+
+// NewEmailService 创建邮箱服务
+func NewEmailService(db *gorm.DB, mail mailer.Mailer, cfg *config.Config) *EmailService {
+	return &EmailService{
+		db:          db,
+		mailer:      mail,
+		frontendURL: strings.TrimRight(cfg.Server.FrontendURL, "/"),
+	}
+}
+
+// SendVerification 向用户当前邮箱发送验证邮件，之前未使用的验证链接随即作废
+func (s *EmailService) SendVerification(user *models.User) error {
+	token, err := s.createToken(user.ID, models.UserTokenEmailVerify, user.Email)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("你好 %s，\n\n感谢注册 Progress Wall。请点击以下链接验证你的邮箱（%d 小时内有效）：\n%s\n\n如果你没有注册过 Progress Wall，请忽略此邮件。\n",
+		user.Username, int(emailTokenTTL.Hours()), s.confirmURL(token))
+	if err := s.mailer.Send(user.Email, "验证你的 Progress Wall 邮箱", body); err != nil {
+		log.Printf("发送验证邮件失败: %v", err)
+	}
+	return nil
+}
+
+// ResendVerification 重新发送验证邮件
+// 无论邮箱是否存在或已验证都返回成功，避免泄露账号信息
+func (s *EmailService) ResendVerification(email string) error {
+	var user models.User
+	result := s.db.Where("LOWER(email) = ? AND status = ?", strings.ToLower(strings.TrimSpace(email)), models.UserStatusEnabled).Limit(1).Find(&user)
+	if result.Error != nil {
+		return fmt.Errorf("查询用户失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 || user.EmailVerified {
+		return nil
+	}
+	return s.SendVerification(&user)
+}
+
+// RequestEmailChange 申请修改邮箱，需要校验当前密码
+// 新邮箱统一保存为小写，在用户点击确认链接后才会生效，同时通知旧邮箱
+func (s *EmailService) RequestEmailChange(userID uint, password, newEmail string) error {
+	newEmail = strings.ToLower(strings.TrimSpace(newEmail))
+	if !utils.ValidateEmail(newEmail) {
+		return ErrInvalidEmail
+	}
+
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("查询用户失败: %v", err)
+	}
+	if !utils.CheckPasswordHash(password, user.Password) {
+		return ErrWrongPassword
+	}
+	if strings.EqualFold(newEmail, user.Email) {
+		return ErrSameEmail
+	}
+	if err := s.ensureEmailAvailable(s.db, newEmail, userID); err != nil {
+		return err
+	}
+
+	token, err := s.createToken(user.ID, models.UserTokenEmailChange, newEmail)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("你好 %s，\n\n你申请将 Progress Wall 账号的邮箱修改为 %s。请点击以下链接确认（%d 小时内有效）：\n%s\n\n确认之前，账号仍使用原邮箱。\n",
+		user.Username, newEmail, int(emailTokenTTL.Hours()), s.confirmURL(token))
+	if err := s.mailer.Send(newEmail, "确认你的 Progress Wall 新邮箱", body); err != nil {
+		log.Printf("发送邮箱确认邮件失败: %v", err)
+	}
+
+	notice := fmt.Sprintf("你好 %s，\n\n有人申请将你的 Progress Wall 账号邮箱修改为 %s。新邮箱确认后修改才会生效。\n\n如果这不是你本人的操作，请尽快修改密码。\n",
+		user.Username, newEmail)
+	if err := s.mailer.Send(user.Email, "Progress Wall 邮箱修改提醒", notice); err != nil {
+		log.Printf("发送邮箱修改提醒失败: %v", err)
+	}
+	return nil
+}
+
+// ConfirmEmail 使用邮件中的令牌完成邮箱验证或邮箱修改
+func (s *EmailService) ConfirmEmail(token string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		userToken, err := useUserToken(tx, token, models.UserTokenEmailVerify, models.UserTokenEmailChange)
+		if err != nil {
+			if err == errTokenUnusable {
+				return ErrInvalidEmailToken
+			}
+			return err
+		}
+
+		var user models.User
+		if err := tx.First(&user, userToken.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidEmailToken
+			}
+			return fmt.Errorf("查询用户失败: %v", err)
+		}
+
+		if userToken.Purpose == models.UserTokenEmailVerify {
+			// 发出验证邮件后邮箱已被修改，旧链接不能再验证新邮箱
+			if userToken.Email != user.Email {
+				return ErrInvalidEmailToken
+			}
+			if err := tx.Model(&user).Update("email_verified", true).Error; err != nil {
+				return fmt.Errorf("更新邮箱验证状态失败: %v", err)
+			}
+			return nil
+		}
+
+		if err := s.ensureEmailAvailable(tx, userToken.Email, user.ID); err != nil {
+			return err
+		}
+		err = tx.Model(&user).Updates(map[string]interface{}{
+			"email":          userToken.Email,
+			"email_verified": true,
+		}).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return ErrEmailTaken
+			}
+			return fmt.Errorf("更新邮箱失败: %v", err)
+		}
+
+		// 邮箱已变更，作废其他待确认的邮箱链接
+		return tx.Where("user_id = ? AND purpose IN ? AND used_at IS NULL", user.ID,
+			[]models.UserTokenPurpose{models.UserTokenEmailVerify, models.UserTokenEmailChange}).
+			Delete(&models.UserToken{}).Error
+	})
+}
+
+// createToken 创建邮箱相关的一次性令牌，同一用途下之前未使用的令牌会被作废
+func (s *EmailService) createToken(userID uint, purpose models.UserTokenPurpose, email string) (string, error) {
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+			Delete(&models.UserToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.UserToken{
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: utils.HashToken(token),
+			Email:     email,
+			ExpiresAt: time.Now().Add(emailTokenTTL),
+		}).Error
+	})
+	if err != nil {
+		return "", fmt.Errorf("创建验证令牌失败: %v", err)
+	}
+	return token, nil
+}
+
+// ensureEmailAvailable 校验邮箱未被其他用户使用，不区分大小写，与 SSO 按邮箱关联账号的规则一致
+func (s *EmailService) ensureEmailAvailable(tx *gorm.DB, email string, excludeUserID uint) error {
+	var count int64
+	if err := tx.Model(&models.User{}).Where("LOWER(email) = ? AND id <> ?", strings.ToLower(email), excludeUserID).Count(&count).Error; err != nil {
+		return fmt.Errorf("查询用户失败: %v", err)
+	}
+	if count > 0 {
+		return ErrEmailTaken
+	}
+	return nil
+}
+
+// confirmURL 生成前端邮箱确认页面链接
+func (s *EmailService) confirmURL(token string) string {
+	return fmt.Sprintf("%s/verify-email?token=%s", s.frontendURL, token)
+}
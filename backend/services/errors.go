@@ -16,4 +16,114 @@ var (
 	ErrTaskNotFound       = errors.New("任务不存在")
 	ErrProjectNotFound    = errors.New("项目不存在")
 	ErrAccessDenied       = errors.New("没有访问权限")
+	ErrCommentNotFound    = errors.New("评论不存在")
+	ErrInvalidComment     = errors.New("评论内容不能为空")
+	ErrParentComment      = errors.New("回复的评论不存在或已删除")
+	ErrAttachmentNotFound = errors.New("附件不存在")
+	ErrFileTooLarge       = errors.New("文件大小超过限制")
+	ErrEmptyFile          = errors.New("不能上传空文件")
+	ErrLabelNotFound      = errors.New("标签不存在")
+	ErrLabelExists        = errors.New("同名标签已存在")
+	ErrLabelNameRequired  = errors.New("标签名称不能为空")
+	ErrLabelMismatch      = errors.New("标签与任务不属于同一项目")
+	ErrInvalidColor       = errors.New("颜色格式不正确，应为#RRGGBB")
+	ErrMemberNotFound     = errors.New("项目成员不存在")
+	ErrMemberExists       = errors.New("用户已是项目成员")
+	ErrNotTeamMember      = errors.New("用户不是项目所属团队的成员")
+	ErrLastProjectAdmin   = errors.New("不能移除或降级项目最后一位管理员")
+	ErrInvalidRole        = errors.New("无效的角色")
+	ErrInvitationNotFound = errors.New("邀请不存在")
+	ErrInvitationInvalid  = errors.New("邀请链接无效或已被撤销")
+	ErrInvitationExpired  = errors.New("邀请链接已过期")
+	ErrInvitationUsedUp   = errors.New("邀请链接已达到使用次数上限")
+	ErrInvitationEmail    = errors.New("该邀请仅限受邀邮箱使用")
+	ErrInvalidEmail       = errors.New("邮箱格式不正确")
+	ErrInvalidRefresh     = errors.New("刷新令牌无效或已过期")
+	ErrRefreshReused      = errors.New("刷新令牌已被使用，会话已失效，请重新登录")
+	ErrSessionNotFound    = errors.New("会话不存在或已失效")
+	ErrWrongPassword      = errors.New("当前密码不正确")
+	ErrSamePassword       = errors.New("新密码不能与当前密码相同")
+	ErrInvalidResetToken  = errors.New("重置链接无效或已过期")
+	ErrEmailNotVerified   = errors.New("邮箱尚未验证，请先完成邮箱验证")
+	ErrEmailTaken         = errors.New("该邮箱已被其他账号使用")
+	ErrSameEmail          = errors.New("新邮箱不能与当前邮箱相同")
+	ErrInvalidEmailToken  = errors.New("验证链接无效或已过期")
+	ErrMFAAlreadyEnabled  = errors.New("两步验证已启用")
+	ErrMFANotEnrolled     = errors.New("请先获取两步验证密钥")
+	ErrMFANotEnabled      = errors.New("两步验证未启用")
+	ErrInvalidMFACode     = errors.New("验证码不正确")
+	ErrMFARequired        = errors.New("管理员账号必须启用两步验证")
+	ErrInvalidMFAToken    = errors.New("两步验证已过期，请重新登录")
+
+	// 登录保护
+	ErrLoginLocked = errors.New("登录失败次数过多，请稍后再试")
+
+	// 个人访问令牌
+	ErrAccessTokenNotFound = errors.New("访问令牌不存在")
+	ErrInvalidAccessToken  = errors.New("访问令牌无效或已过期")
+	ErrInvalidTokenName    = errors.New("令牌名称不能为空且不能超过100个字符")
+	ErrInvalidTokenScope   = errors.New("无效的令牌权限范围")
+	ErrInvalidTokenExpiry  = errors.New("令牌有效期无效")
+
+	// 单点登录
+	ErrOIDCProviderNotFound = errors.New("未配置该单点登录方式")
+	ErrOIDCStateInvalid     = errors.New("单点登录请求无效或已过期，请重新登录")
+	ErrOIDCFailed           = errors.New("单点登录失败")
+	ErrOIDCEmailUnverified  = errors.New("身份提供方未提供已验证的邮箱")
+	ErrOIDCSignupDisabled   = errors.New("该邮箱尚未注册，请联系管理员")
+	ErrOIDCTicketInvalid    = errors.New("登录凭证无效或已过期，请重新登录")
+
+	// LDAP 目录
+	ErrLDAPDisabled    = errors.New("未配置LDAP目录")
+	ErrLDAPUnavailable = errors.New("目录服务暂不可用，请稍后重试")
+
+	// 用户管理
+	ErrInvalidUserStatus = errors.New("无效的用户状态")
+	ErrInvalidSystemRole = errors.New("无效的系统角色")
+	ErrModifySelf        = errors.New("不能禁用、降级或删除自己的账号")
+	ErrLastSysAdmin      = errors.New("不能禁用、降级或删除最后一位系统管理员")
+	ErrImpersonateAdmin  = errors.New("不能模拟登录系统管理员")
+
+	// 注销账号
+	ErrDeleteConfirmMismatch = errors.New("请输入当前用户名确认注销")
+	ErrSuccessorRequired     = errors.New("你拥有团队、项目或看板，或是项目唯一的管理员，请指定接手人")
+	ErrInvalidSuccessor      = errors.New("接手人不存在或不可用")
+	ErrSuccessorNotMember    = errors.New("接手人必须是相关团队的成员")
+
+	// 偏好设置
+	ErrInvalidTimezone   = errors.New("无效的时区")
+	ErrInvalidLocale     = errors.New("不支持的语言")
+	ErrInvalidDateFormat = errors.New("不支持的日期格式")
+
+	// 子任务与检查项
+	ErrParentTaskNotFound    = errors.New("父任务不存在")
+	ErrParentTaskMismatch    = errors.New("父任务必须属于同一项目")
+	ErrSubtaskCycle          = errors.New("不能将任务设为自身或其子任务的子任务")
+	ErrChecklistItemNotFound = errors.New("检查项不存在")
+	ErrInvalidChecklistItem  = errors.New("检查项内容不能为空且不能超过500个字符")
+	ErrNotProjectMember      = errors.New("用户不是该项目的成员")
+
+	// 任务关联
+	ErrInvalidLinkType  = errors.New("无效的关联类型")
+	ErrTaskLinkSelf     = errors.New("不能关联任务自身")
+	ErrTaskLinkMismatch = errors.New("只能关联同一项目的任务")
+	ErrTaskLinkExists   = errors.New("任务关联已存在")
+	ErrTaskLinkCycle    = errors.New("阻塞关系不能形成循环")
+	ErrTaskLinkNotFound = errors.New("任务关联不存在")
+	ErrTaskBlocked      = errors.New("任务仍被未完成的任务阻塞，不能移入完成列")
+
+	// 项目 key 与任务编号
+	ErrInvalidProjectKey     = errors.New("项目 key 必须以大写字母开头，由 2-10 位大写字母或数字组成")
+	ErrProjectKeyExists      = errors.New("项目 key 已被使用")
+	ErrColumnProjectMismatch = errors.New("列不属于指定的项目")
+
+	// 自定义字段
+	ErrCustomFieldNotFound       = errors.New("自定义字段不存在")
+	ErrCustomFieldExists         = errors.New("同名自定义字段已存在")
+	ErrCustomFieldNameRequired   = errors.New("自定义字段名称不能为空")
+	ErrInvalidCustomFieldType    = errors.New("无效的自定义字段类型")
+	ErrInvalidCustomFieldOptions = errors.New("单选和多选字段至少需要一个选项，选项不能为空或重复")
+	ErrCustomFieldOptionInUse    = errors.New("选项仍被任务使用，不能删除")
+	ErrInvalidCustomFieldValue   = errors.New("自定义字段的值与字段类型不符")
+	ErrCustomFieldRequired       = errors.New("必填的自定义字段不能为空")
 )
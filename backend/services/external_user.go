@@ -0,0 +1,162 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/models"
+	"progress-wall-backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// usernameInvalidChars 自动创建用户时从用户名中去除的字符
+var usernameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+// createExternalUser 为单点登录或目录用户创建本地账号，本地密码为随机值
+// base 为外部系统中的用户名，按本地规则清理后作为用户名
+func createExternalUser(tx *gorm.DB, base, email, nickname string) (*models.User, error) {
+	randomPassword, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := utils.HashPassword(randomPassword)
+	if err != nil {
+		return nil, errors.New("加密密码失败")
+	}
+
+	username, err := uniqueUsername(tx, base)
+	if err != nil {
+		return nil, err
+	}
+
+	if nickname == "" {
+		nickname = username
+	}
+
+	user := &models.User{
+		Username:      username,
+		Email:         email,
+		EmailVerified: true,
+		Password:      hashedPassword,
+		Nickname:      truncate(nickname, 50),
+		Status:        models.UserStatusEnabled,
+		SystemRole:    models.SystemRoleUser,
+	}
+	if err := tx.Create(user).Error; err != nil {
+		return nil, fmt.Errorf("创建用户失败: %v", err)
+	}
+	return user, nil
+}
+
+// uniqueUsername 由外部用户名生成符合本地规则（3-20个字符）且未被占用的用户名
+func uniqueUsername(tx *gorm.DB, base string) (string, error) {
+	base = usernameInvalidChars.ReplaceAllString(base, "")
+	if len(base) > 15 {
+		base = base[:15]
+	}
+	for len(base) < 3 {
+		base += "_"
+	}
+
+	candidate := base
+	for i := 0; i < 10; i++ {
+		var count int64
+		if err := tx.Unscoped().Model(&models.User{}).Where("username = ?", candidate).Count(&count).Error; err != nil {
+			return "", fmt.Errorf("查询用户失败: %v", err)
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		suffix, err := utils.GenerateSecureToken(3)
+		if err != nil {
+			return "", err
+		}
+		candidate = base + "_" + usernameInvalidChars.ReplaceAllString(suffix, "")
+	}
+	return "", ErrUserExists
+}
+
+// syncGroups 按外部用户组同步系统角色和团队成员身份，组名不区分大小写
+// 配置了管理员组时系统角色完全由用户组决定；团队映射只会加入团队或提升为管理员，不会移除成员
+func syncGroups(tx *gorm.DB, adminGroups []string, teamGroups string, user *models.User, groups []string) error {
+	inGroup := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		inGroup[strings.ToLower(g)] = true
+	}
+
+	if len(adminGroups) > 0 {
+		role := models.SystemRoleUser
+		for _, g := range adminGroups {
+			if inGroup[strings.ToLower(g)] {
+				role = models.SystemRoleAdmin
+				break
+			}
+		}
+		if user.SystemRole != role {
+			if err := tx.Model(user).UpdateColumn("system_role", role).Error; err != nil {
+				return fmt.Errorf("更新系统角色失败: %v", err)
+			}
+			user.SystemRole = role
+		}
+	}
+
+	mappings, err := config.ParseTeamGroups(teamGroups)
+	if err != nil {
+		return err
+	}
+	for _, m := range mappings {
+		if !inGroup[strings.ToLower(m.Group)] {
+			continue
+		}
+		if err := ensureGroupTeamMember(tx, m, user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureGroupTeamMember 确保用户是映射的团队成员，映射为管理员时提升角色
+func ensureGroupTeamMember(tx *gorm.DB, m config.TeamGroupMapping, user *models.User) error {
+	var team models.Team
+	if err := tx.Select("id").First(&team, m.TeamID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("用户组 %s 映射的团队 %d 不存在", m.Group, m.TeamID)
+			return nil
+		}
+		return fmt.Errorf("查询团队失败: %v", err)
+	}
+
+	role := models.TeamRoleMember
+	if m.Admin {
+		role = models.TeamRoleAdmin
+	}
+
+	var member models.TeamMember
+	err := tx.Where("team_id = ? AND user_id = ?", m.TeamID, user.ID).First(&member).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if err := tx.Create(&models.TeamMember{
+			TeamID:   m.TeamID,
+			UserID:   user.ID,
+			Role:     role,
+			JoinedAt: time.Now(),
+		}).Error; err != nil {
+			return fmt.Errorf("加入团队失败: %v", err)
+		}
+		return recordTeamActivity(tx, m.TeamID, Actor{ID: user.ID, Name: user.Username}, fmt.Sprintf("joined the team via group %s", m.Group))
+	case err != nil:
+		return fmt.Errorf("查询团队成员失败: %v", err)
+	case m.Admin && member.Role != models.TeamRoleAdmin:
+		if err := tx.Model(&member).Update("role", models.TeamRoleAdmin).Error; err != nil {
+			return fmt.Errorf("更新团队角色失败: %v", err)
+		}
+		return recordTeamActivity(tx, m.TeamID, Actor{ID: user.ID, Name: user.Username}, fmt.Sprintf("became a team admin via group %s", m.Group))
+	}
+	return nil
+}
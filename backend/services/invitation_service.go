@@ -0,0 +1,408 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/mailer"
+	"progress-wall-backend/models"
+	"progress-wall-backend/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultInvitationTTL 邀请默认有效期
+	defaultInvitationTTL = 7 * 24 * time.Hour
+	// maxInvitationTTL 邀请最长有效期
+	maxInvitationTTL = 30 * 24 * time.Hour
+)
+
+// InvitationService 团队/项目邀请服务
+type InvitationService struct {
+	db          *gorm.DB
+	mailer      mailer.Mailer
+	frontendURL string
+	cfg         *config.Config
+}
+
+// NewInvitationService 创建邀请服务
+func NewInvitationService(db *gorm.DB, mail mailer.Mailer, cfg *config.Config) *InvitationService {
+	return &InvitationService{
+		db:          db,
+		mailer:      mail,
+		frontendURL: strings.TrimRight(cfg.Server.FrontendURL, "/"),
+		cfg:         cfg,
+	}
+}
+
+// CreateInvitationInput 创建邀请参数
+type CreateInvitationInput struct {
+	TeamID    uint
+	ProjectID *uint // 为空表示团队邀请
+	Email     string
+	Role      int
+	ExpiresIn time.Duration // 为0时使用默认有效期
+	MaxUses   int           // 0表示不限次数；指定邮箱时默认1次
+}
+
+// CreateInvitationResult 创建邀请结果
+type CreateInvitationResult struct {
+	Invitation *models.Invitation
+	InviteURL  string
+	EmailSent  bool
+}
+
+// InvitationPreview 邀请预览信息，供未登录用户查看
+type InvitationPreview struct {
+	TeamName    string    `json:"team_name"`
+	ProjectName string    `json:"project_name,omitempty"`
+	Role        int       `json:"role"`
+	Email       string    `json:"email,omitempty"`
+	InviterName string    `json:"inviter_name"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// CreateInvitation 创建邀请；指定邮箱时同时发送邀请邮件
+// 邮件发送失败不会回滚邀请，调用方仍可通过返回的链接手动分享
+func (s *InvitationService) CreateInvitation(input CreateInvitationInput, inviter Actor) (*CreateInvitationResult, error) {
+	email := strings.ToLower(strings.TrimSpace(input.Email))
+	if email != "" && !utils.ValidateEmail(email) {
+		return nil, ErrInvalidEmail
+	}
+	if input.MaxUses < 0 {
+		input.MaxUses = 0
+	}
+	if email != "" && input.MaxUses == 0 {
+		input.MaxUses = 1
+	}
+	ttl := input.ExpiresIn
+	if ttl <= 0 {
+		ttl = defaultInvitationTTL
+	}
+	if ttl > maxInvitationTTL {
+		ttl = maxInvitationTTL
+	}
+
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &models.Invitation{
+		TokenHash: utils.HashToken(token),
+		TeamID:    input.TeamID,
+		ProjectID: input.ProjectID,
+		Email:     email,
+		Role:      input.Role,
+		InviterID: inviter.ID,
+		ExpiresAt: time.Now().Add(ttl),
+		MaxUses:   input.MaxUses,
+		Status:    models.InvitationStatusActive,
+	}
+
+	var targetName string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if input.ProjectID != nil {
+			if !validProjectRole(models.ProjectRole(input.Role)) {
+				return ErrInvalidRole
+			}
+			var project models.Project
+			if err := tx.First(&project, *input.ProjectID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrProjectNotFound
+				}
+				return fmt.Errorf("查询项目失败: %v", err)
+			}
+			invitation.TeamID = project.TeamID
+			targetName = project.Name
+		} else {
+			if !validTeamRole(models.TeamRole(input.Role)) {
+				return ErrInvalidRole
+			}
+			var team models.Team
+			if err := tx.First(&team, input.TeamID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrTeamNotFound
+				}
+				return fmt.Errorf("查询团队失败: %v", err)
+			}
+			targetName = team.Name
+		}
+
+		if err := tx.Create(invitation).Error; err != nil {
+			return fmt.Errorf("创建邀请失败: %v", err)
+		}
+		return recordInvitationActivity(tx, invitation, inviter, describeInvitee("created an invitation", email))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CreateInvitationResult{
+		Invitation: invitation,
+		InviteURL:  s.inviteURL(token),
+	}
+
+	if email != "" {
+		subject := fmt.Sprintf("%s 邀请你加入「%s」", inviter.Name, targetName)
+		// 受邀邮箱已有账号时按其偏好显示有效期，否则使用服务器默认时区
+		var inviteeID uint
+		s.db.Model(&models.User{}).Where("email = ?", email).Limit(1).Pluck("id", &inviteeID)
+		prefs, err := loadPreferences(s.db, s.cfg, inviteeID)
+		if err != nil {
+			return nil, err
+		}
+		body := fmt.Sprintf("你好，\n\n%s 邀请你加入 Progress Wall 上的「%s」。\n\n点击以下链接接受邀请（%s 前有效）：\n%s\n\n如果你还没有账号，可以在该页面直接注册。\n",
+			inviter.Name, targetName, prefs.FormatTime(invitation.ExpiresAt), result.InviteURL)
+		if err := s.mailer.Send(email, subject, body); err != nil {
+			log.Printf("发送邀请邮件失败: %v", err)
+		} else {
+			result.EmailSent = true
+		}
+	}
+
+	return result, nil
+}
+
+// ListInvitations 获取团队或项目的邀请列表（projectID 为空时返回团队邀请）
+func (s *InvitationService) ListInvitations(teamID uint, projectID *uint) ([]models.Invitation, error) {
+	query := s.db.Preload("Inviter", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "username", "nickname", "avatar")
+	})
+	if projectID != nil {
+		query = query.Where("project_id = ?", *projectID)
+	} else {
+		query = query.Where("team_id = ? AND project_id IS NULL", teamID)
+	}
+
+	var invitations []models.Invitation
+	if err := query.Order("created_at DESC").Find(&invitations).Error; err != nil {
+		return nil, fmt.Errorf("查询邀请列表失败: %v", err)
+	}
+	return invitations, nil
+}
+
+// RevokeInvitation 撤销邀请，邀请必须属于指定团队或项目
+func (s *InvitationService) RevokeInvitation(teamID uint, projectID *uint, invitationID uint, actor Actor) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("id = ?", invitationID)
+		if projectID != nil {
+			query = query.Where("project_id = ?", *projectID)
+		} else {
+			query = query.Where("team_id = ? AND project_id IS NULL", teamID)
+		}
+
+		var invitation models.Invitation
+		if err := query.First(&invitation).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvitationNotFound
+			}
+			return fmt.Errorf("查询邀请失败: %v", err)
+		}
+		if invitation.Status == models.InvitationStatusRevoked {
+			return nil
+		}
+
+		if err := tx.Model(&invitation).Update("status", models.InvitationStatusRevoked).Error; err != nil {
+			return fmt.Errorf("撤销邀请失败: %v", err)
+		}
+		return recordInvitationActivity(tx, &invitation, actor, describeInvitee("revoked an invitation", invitation.Email))
+	})
+}
+
+// PreviewInvitation 根据令牌查询邀请信息
+func (s *InvitationService) PreviewInvitation(token string) (*InvitationPreview, error) {
+	invitation, err := findUsableInvitation(s.db, token)
+	if err != nil {
+		return nil, err
+	}
+
+	var team models.Team
+	if err := s.db.Select("id", "name").First(&team, invitation.TeamID).Error; err != nil {
+		return nil, ErrInvitationInvalid
+	}
+	preview := &InvitationPreview{
+		TeamName:  team.Name,
+		Role:      invitation.Role,
+		Email:     invitation.Email,
+		ExpiresAt: invitation.ExpiresAt,
+	}
+	if invitation.ProjectID != nil {
+		var project models.Project
+		if err := s.db.Select("id", "name").First(&project, *invitation.ProjectID).Error; err != nil {
+			return nil, ErrInvitationInvalid
+		}
+		preview.ProjectName = project.Name
+	}
+	var inviter models.User
+	if err := s.db.Select("id", "username", "nickname").First(&inviter, invitation.InviterID).Error; err == nil {
+		preview.InviterName = inviter.Username
+		if inviter.Nickname != "" {
+			preview.InviterName = inviter.Nickname
+		}
+	}
+	return preview, nil
+}
+
+// AcceptInvitation 当前登录用户接受邀请
+func (s *InvitationService) AcceptInvitation(token string, userID uint) (*models.Invitation, error) {
+	var invitation *models.Invitation
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Select("id", "username", "email").First(&user, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return fmt.Errorf("查询用户失败: %v", err)
+		}
+
+		var err error
+		invitation, err = acceptInvitation(tx, token, &user)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// inviteURL 生成前端邀请页面链接
+func (s *InvitationService) inviteURL(token string) string {
+	return fmt.Sprintf("%s/invite/%s", s.frontendURL, token)
+}
+
+// acceptInvitation 在事务中校验邀请并将用户加入目标团队/项目
+// 项目邀请会在用户尚不是团队成员时先以普通成员身份加入项目所属团队
+// 注册流程（AuthService.Register）也通过此函数在创建用户的同一事务中接受邀请
+func acceptInvitation(tx *gorm.DB, token string, user *models.User) (*models.Invitation, error) {
+	invitation, err := findUsableInvitation(tx, token)
+	if err != nil {
+		return nil, err
+	}
+	if invitation.Email != "" && !strings.EqualFold(invitation.Email, user.Email) {
+		return nil, ErrInvitationEmail
+	}
+
+	var teamCount int64
+	if err := tx.Model(&models.TeamMember{}).
+		Where("team_id = ? AND user_id = ?", invitation.TeamID, user.ID).
+		Count(&teamCount).Error; err != nil {
+		return nil, fmt.Errorf("查询团队成员失败: %v", err)
+	}
+
+	if invitation.ProjectID == nil && teamCount > 0 {
+		return nil, ErrUserAlreadyMember
+	}
+	if invitation.ProjectID != nil {
+		var count int64
+		if err := tx.Model(&models.ProjectMember{}).
+			Where("project_id = ? AND user_id = ?", *invitation.ProjectID, user.ID).
+			Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("查询项目成员失败: %v", err)
+		}
+		if count > 0 {
+			return nil, ErrMemberExists
+		}
+	}
+
+	// 以条件更新的方式占用一次使用次数，避免并发接受超出上限
+	result := tx.Model(&models.Invitation{}).
+		Where("id = ? AND status = ? AND (max_uses = 0 OR use_count < max_uses)", invitation.ID, models.InvitationStatusActive).
+		UpdateColumn("use_count", gorm.Expr("use_count + 1"))
+	if result.Error != nil {
+		return nil, fmt.Errorf("更新邀请失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvitationUsedUp
+	}
+
+	now := time.Now()
+	if teamCount == 0 {
+		teamRole := models.TeamRoleMember
+		if invitation.ProjectID == nil {
+			teamRole = models.TeamRole(invitation.Role)
+		}
+		if err := tx.Create(&models.TeamMember{
+			TeamID:   invitation.TeamID,
+			UserID:   user.ID,
+			Role:     teamRole,
+			JoinedAt: now,
+		}).Error; err != nil {
+			return nil, fmt.Errorf("加入团队失败: %v", err)
+		}
+		if err := recordTeamActivity(tx, invitation.TeamID, Actor{ID: user.ID, Name: user.Username}, "joined the team via invitation"); err != nil {
+			return nil, err
+		}
+	}
+
+	if invitation.ProjectID != nil {
+		if err := tx.Create(&models.ProjectMember{
+			ProjectID: *invitation.ProjectID,
+			UserID:    user.ID,
+			Role:      models.ProjectRole(invitation.Role),
+			JoinedAt:  now,
+		}).Error; err != nil {
+			return nil, fmt.Errorf("加入项目失败: %v", err)
+		}
+		if err := recordInvitationActivity(tx, invitation, Actor{ID: user.ID, Name: user.Username}, "joined the project via invitation"); err != nil {
+			return nil, err
+		}
+	}
+
+	invitation.UseCount++
+	return invitation, nil
+}
+
+// findUsableInvitation 根据令牌查询有效、未过期且未用尽的邀请
+func findUsableInvitation(tx *gorm.DB, token string) (*models.Invitation, error) {
+	if token == "" {
+		return nil, ErrInvitationInvalid
+	}
+
+	var invitation models.Invitation
+	err := tx.Where("token_hash = ? AND status = ?", utils.HashToken(token), models.InvitationStatusActive).
+		First(&invitation).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvitationInvalid
+		}
+		return nil, fmt.Errorf("查询邀请失败: %v", err)
+	}
+	if time.Now().After(invitation.ExpiresAt) {
+		return nil, ErrInvitationExpired
+	}
+	if invitation.MaxUses > 0 && invitation.UseCount >= invitation.MaxUses {
+		return nil, ErrInvitationUsedUp
+	}
+	return &invitation, nil
+}
+
+// recordInvitationActivity 记录邀请相关活动，项目邀请记录到项目，团队邀请记录到团队
+func recordInvitationActivity(tx *gorm.DB, invitation *models.Invitation, actor Actor, description string) error {
+	if invitation.ProjectID == nil {
+		return recordTeamActivity(tx, invitation.TeamID, actor, description)
+	}
+	return tx.Create(&models.ActivityLog{
+		UserID:         actor.ID,
+		Username:       actor.Name,
+		ImpersonatorID: actor.ImpersonatorID,
+		ActionType:     models.ActionUpdate,
+		EntityType:     models.EntityProject,
+		EntityID:       *invitation.ProjectID,
+		ProjectID:      invitation.ProjectID,
+		Description:    description,
+	}).Error
+}
+
+// describeInvitee 为活动描述附加受邀邮箱
+func describeInvitee(action, email string) string {
+	if email == "" {
+		return action + " link"
+	}
+	return fmt.Sprintf("%s for %s", action, email)
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/models"
+	"progress-wall-backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// keyActivationDelay 新密钥发布后延迟多久开始签名
+// 多实例部署时各实例每分钟重新加载密钥，延迟保证所有实例和 JWKS 使用方先拿到新公钥
+const keyActivationDelay = 10 * time.Minute
+
+// KeyService JWT签名密钥管理服务，负责生成、轮换和加载非对称签名密钥
+type KeyService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+// NewKeyService 创建签名密钥服务
+func NewKeyService(db *gorm.DB, cfg *config.Config) *KeyService {
+	return &KeyService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// Load 从数据库加载签名密钥和验证密钥，没有可用的签名密钥时立即生成
+func (s *KeyService) Load() error {
+	if s.cfg.JWT.Algorithm == config.JWTAlgorithmHS256 {
+		utils.SetKeyRing(nil, nil)
+		return nil
+	}
+
+	signing, verify, err := s.loadKeys()
+	if err != nil {
+		return err
+	}
+	if signing == nil {
+		if err := s.rotate(true); err != nil {
+			return err
+		}
+		if signing, verify, err = s.loadKeys(); err != nil {
+			return err
+		}
+		if signing == nil {
+			return errors.New("生成签名密钥失败")
+		}
+	}
+
+	utils.SetKeyRing(signing, verify)
+	return nil
+}
+
+// RotateIfDue 当前签名密钥超过轮换周期时生成新密钥
+func (s *KeyService) RotateIfDue() error {
+	if s.cfg.JWT.Algorithm == config.JWTAlgorithmHS256 || s.cfg.JWT.KeyRotationDays <= 0 {
+		return nil
+	}
+
+	var latest models.SigningKey
+	err := s.db.Where("retired_at IS NULL").Order("activates_at DESC, id DESC").First(&latest).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return s.rotate(true)
+		}
+		return fmt.Errorf("查询签名密钥失败: %v", err)
+	}
+	if latest.Algorithm == s.cfg.JWT.Algorithm &&
+		time.Since(latest.ActivatesAt) < time.Duration(s.cfg.JWT.KeyRotationDays)*24*time.Hour {
+		return nil
+	}
+	return s.rotate(false)
+}
+
+// Rotate 立即开始轮换：生成新密钥，旧密钥在新密钥生效后停止签名
+func (s *KeyService) Rotate() error {
+	if s.cfg.JWT.Algorithm == config.JWTAlgorithmHS256 {
+		return nil
+	}
+	if err := s.rotate(false); err != nil {
+		return err
+	}
+	return s.Load()
+}
+
+// PurgeExpired 删除已过期的验证密钥
+func (s *KeyService) PurgeExpired() (int64, error) {
+	result := s.db.Where("expires_at < ?", time.Now()).Delete(&models.SigningKey{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("清理签名密钥失败: %v", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// rotate 生成新的签名密钥，并让当前未停用的密钥在新密钥生效时停止签名
+// immediate 为 true 时新密钥立即生效（首次启动或现有密钥不可用）
+func (s *KeyService) rotate(immediate bool) error {
+	signer, err := utils.GenerateKeyPair(s.cfg.JWT.Algorithm)
+	if err != nil {
+		return err
+	}
+	privateKey, err := utils.EncryptPrivateKey(s.cfg.JWT.Secret, signer)
+	if err != nil {
+		return fmt.Errorf("加密签名密钥失败: %v", err)
+	}
+	publicKey, err := utils.MarshalPublicKey(signer.Public())
+	if err != nil {
+		return err
+	}
+	kid, err := utils.GenerateSecureToken(12)
+	if err != nil {
+		return err
+	}
+
+	activatesAt := time.Now()
+	if !immediate {
+		activatesAt = activatesAt.Add(keyActivationDelay)
+	}
+	// 旧密钥签发的令牌最长在访问令牌有效期内仍需验证，额外保留一小时供 JWKS 缓存
+	expiresAt := activatesAt.Add(time.Duration(s.cfg.JWT.AccessExpireMinutes)*time.Minute + time.Hour)
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var current []models.SigningKey
+		if err := tx.Where("retired_at IS NULL").Find(&current).Error; err != nil {
+			return fmt.Errorf("查询签名密钥失败: %v", err)
+		}
+		for _, key := range current {
+			// 已有待生效的新密钥说明其他实例刚完成轮换
+			if !immediate && key.ActivatesAt.After(time.Now()) {
+				return nil
+			}
+			result := tx.Model(&models.SigningKey{}).
+				Where("id = ? AND retired_at IS NULL", key.ID).
+				Updates(map[string]interface{}{"retired_at": activatesAt, "expires_at": expiresAt})
+			if result.Error != nil {
+				return fmt.Errorf("停用签名密钥失败: %v", result.Error)
+			}
+			if result.RowsAffected == 0 {
+				return nil
+			}
+		}
+
+		if err := tx.Create(&models.SigningKey{
+			Kid:         kid,
+			Algorithm:   s.cfg.JWT.Algorithm,
+			PrivateKey:  privateKey,
+			PublicKey:   publicKey,
+			ActivatesAt: activatesAt,
+		}).Error; err != nil {
+			return fmt.Errorf("保存签名密钥失败: %v", err)
+		}
+		log.Printf("已生成新的JWT签名密钥 kid=%s alg=%s，生效时间 %s", kid, s.cfg.JWT.Algorithm, activatesAt.Format(time.RFC3339))
+		return nil
+	})
+}
+
+// loadKeys 读取未过期的密钥，返回当前签名密钥和全部验证密钥
+func (s *KeyService) loadKeys() (*utils.JWTKey, []*utils.JWTKey, error) {
+	var keys []models.SigningKey
+	err := s.db.Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Order("activates_at DESC, id DESC").
+		Find(&keys).Error
+	if err != nil {
+		return nil, nil, fmt.Errorf("查询签名密钥失败: %v", err)
+	}
+
+	now := time.Now()
+	var signing *utils.JWTKey
+	verify := make([]*utils.JWTKey, 0, len(keys))
+	for _, key := range keys {
+		publicKey, err := utils.ParsePublicKey(key.PublicKey)
+		if err != nil {
+			log.Printf("解析签名密钥 %s 失败: %v", key.Kid, err)
+			continue
+		}
+		jwtKey := &utils.JWTKey{Kid: key.Kid, Algorithm: key.Algorithm, PublicKey: publicKey}
+		verify = append(verify, jwtKey)
+
+		// 按生效时间倒序，第一个已生效且未停用、算法与配置一致的密钥用于签名
+		usable := key.Algorithm == s.cfg.JWT.Algorithm && !key.ActivatesAt.After(now) &&
+			(key.RetiredAt == nil || key.RetiredAt.After(now))
+		if signing != nil || !usable {
+			continue
+		}
+		privateKey, err := utils.DecryptPrivateKey(s.cfg.JWT.Secret, key.PrivateKey)
+		if err != nil {
+			log.Printf("加载签名密钥 %s 失败: %v", key.Kid, err)
+			continue
+		}
+		jwtKey.PrivateKey = privateKey
+		signing = jwtKey
+	}
+	return signing, verify, nil
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"progress-wall-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// colorPattern 标签颜色格式（十六进制 #RRGGBB）
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// LabelService 项目标签服务
+type LabelService struct {
+	db *gorm.DB
+}
+
+// NewLabelService 创建标签服务
+func NewLabelService(db *gorm.DB) *LabelService {
+	return &LabelService{
+		db: db,
+	}
+}
+
+// GetProjectLabels 获取项目的所有标签
+func (s *LabelService) GetProjectLabels(projectID uint) ([]models.Label, error) {
+	var labels []models.Label
+	if err := s.db.Where("project_id = ?", projectID).Order("name ASC").Find(&labels).Error; err != nil {
+		return nil, fmt.Errorf("查询标签列表失败: %v", err)
+	}
+	return labels, nil
+}
+
+// CreateLabel 创建项目标签
+func (s *LabelService) CreateLabel(projectID uint, name, color string, actor Actor) (*models.Label, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrLabelNameRequired
+	}
+	if color == "" {
+		color = "#3498db"
+	}
+	if !colorPattern.MatchString(color) {
+		return nil, ErrInvalidColor
+	}
+
+	label := &models.Label{
+		Name:      name,
+		Color:     color,
+		ProjectID: projectID,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.ensureUniqueName(tx, projectID, name, 0); err != nil {
+			return err
+		}
+		if err := tx.Create(label).Error; err != nil {
+			return fmt.Errorf("创建标签失败: %v", err)
+		}
+		return tx.Create(&models.ActivityLog{
+			UserID:         actor.ID,
+			Username:       actor.Name,
+			ImpersonatorID: actor.ImpersonatorID,
+			ActionType:     models.ActionUpdate,
+			EntityType:     models.EntityLabel,
+			EntityID:       label.ID,
+			ProjectID:      &projectID,
+			Description:    fmt.Sprintf("created label \"%s\"", label.Name),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return label, nil
+}
+
+// UpdateLabel 更新项目标签的名称或颜色
+func (s *LabelService) UpdateLabel(projectID, labelID uint, name, color *string, actor Actor) (*models.Label, error) {
+	var label models.Label
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.getLabel(tx, projectID, labelID, &label); err != nil {
+			return err
+		}
+
+		updates := make(map[string]interface{})
+		if name != nil {
+			trimmed := strings.TrimSpace(*name)
+			if trimmed == "" {
+				return ErrLabelNameRequired
+			}
+			if err := s.ensureUniqueName(tx, projectID, trimmed, labelID); err != nil {
+				return err
+			}
+			updates["name"] = trimmed
+		}
+		if color != nil {
+			if !colorPattern.MatchString(*color) {
+				return ErrInvalidColor
+			}
+			updates["color"] = *color
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+
+		if err := tx.Model(&label).Updates(updates).Error; err != nil {
+			return fmt.Errorf("更新标签失败: %v", err)
+		}
+		if err := tx.First(&label, label.ID).Error; err != nil {
+			return fmt.Errorf("查询标签失败: %v", err)
+		}
+		return tx.Create(&models.ActivityLog{
+			UserID:         actor.ID,
+			Username:       actor.Name,
+			ImpersonatorID: actor.ImpersonatorID,
+			ActionType:     models.ActionUpdate,
+			EntityType:     models.EntityLabel,
+			EntityID:       label.ID,
+			ProjectID:      &projectID,
+			Description:    fmt.Sprintf("updated label \"%s\"", label.Name),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &label, nil
+}
+
+// DeleteLabel 删除项目标签，同时移除其与任务的关联
+func (s *LabelService) DeleteLabel(projectID, labelID uint, actor Actor) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var label models.Label
+		if err := s.getLabel(tx, projectID, labelID, &label); err != nil {
+			return err
+		}
+
+		if err := tx.Where("label_id = ?", labelID).Delete(&models.TaskLabel{}).Error; err != nil {
+			return fmt.Errorf("移除任务标签失败: %v", err)
+		}
+		if err := tx.Delete(&label).Error; err != nil {
+			return fmt.Errorf("删除标签失败: %v", err)
+		}
+		return tx.Create(&models.ActivityLog{
+			UserID:         actor.ID,
+			Username:       actor.Name,
+			ImpersonatorID: actor.ImpersonatorID,
+			ActionType:     models.ActionUpdate,
+			EntityType:     models.EntityLabel,
+			EntityID:       label.ID,
+			ProjectID:      &projectID,
+			Description:    fmt.Sprintf("deleted label \"%s\"", label.Name),
+		}).Error
+	})
+}
+
+// AddLabelToTask 为任务添加标签，标签必须属于任务所在项目
+func (s *LabelService) AddLabelToTask(taskID, labelID uint, actor Actor) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		task, label, err := s.getTaskAndLabel(tx, taskID, labelID)
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.TaskLabel{}).
+			Where("task_id = ? AND label_id = ?", taskID, labelID).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("查询任务标签失败: %v", err)
+		}
+		if count > 0 {
+			return nil
+		}
+
+		if err := tx.Create(&models.TaskLabel{TaskID: taskID, LabelID: labelID}).Error; err != nil {
+			return fmt.Errorf("添加任务标签失败: %v", err)
+		}
+		return recordTaskActivity(tx, task, &models.ActivityLog{
+			UserID:         actor.ID,
+			Username:       actor.Name,
+			ImpersonatorID: actor.ImpersonatorID,
+			ActionType:     models.ActionUpdate,
+			EntityType:     models.EntityLabel,
+			EntityID:       label.ID,
+			Description:    fmt.Sprintf("added label \"%s\"", label.Name),
+		})
+	})
+}
+
+// RemoveLabelFromTask 移除任务上的标签
+func (s *LabelService) RemoveLabelFromTask(taskID, labelID uint, actor Actor) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		task, label, err := s.getTaskAndLabel(tx, taskID, labelID)
+		if err != nil {
+			return err
+		}
+
+		result := tx.Where("task_id = ? AND label_id = ?", taskID, labelID).Delete(&models.TaskLabel{})
+		if result.Error != nil {
+			return fmt.Errorf("移除任务标签失败: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		return recordTaskActivity(tx, task, &models.ActivityLog{
+			UserID:         actor.ID,
+			Username:       actor.Name,
+			ImpersonatorID: actor.ImpersonatorID,
+			ActionType:     models.ActionUpdate,
+			EntityType:     models.EntityLabel,
+			EntityID:       label.ID,
+			Description:    fmt.Sprintf("removed label \"%s\"", label.Name),
+		})
+	})
+}
+
+// getLabel 查询属于指定项目的标签
+func (s *LabelService) getLabel(tx *gorm.DB, projectID, labelID uint, label *models.Label) error {
+	if err := tx.Where("id = ? AND project_id = ?", labelID, projectID).First(label).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrLabelNotFound
+		}
+		return fmt.Errorf("查询标签失败: %v", err)
+	}
+	return nil
+}
+
+// getTaskAndLabel 查询任务和标签，并校验二者属于同一项目
+func (s *LabelService) getTaskAndLabel(tx *gorm.DB, taskID, labelID uint) (*models.Task, *models.Label, error) {
+	var task models.Task
+	if err := tx.First(&task, taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrTaskNotFound
+		}
+		return nil, nil, fmt.Errorf("查询任务失败: %v", err)
+	}
+
+	var label models.Label
+	if err := tx.First(&label, labelID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrLabelNotFound
+		}
+		return nil, nil, fmt.Errorf("查询标签失败: %v", err)
+	}
+
+	if label.ProjectID != task.ProjectID {
+		return nil, nil, ErrLabelMismatch
+	}
+	return &task, &label, nil
+}
+
+// ensureUniqueName 校验项目内标签名称唯一（excludeID 为更新时排除的自身ID）
+func (s *LabelService) ensureUniqueName(tx *gorm.DB, projectID uint, name string, excludeID uint) error {
+	var count int64
+	if err := tx.Model(&models.Label{}).
+		Where("project_id = ? AND name = ? AND id <> ?", projectID, name, excludeID).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("查询标签失败: %v", err)
+	}
+	if count > 0 {
+		return ErrLabelExists
+	}
+	return nil
+}
@@ -0,0 +1,373 @@
+package services
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/models"
+
+	"github.com/go-ldap/ldap/v3"
+	"gorm.io/gorm"
+)
+
+const (
+	ldapTimeout  = 10 * time.Second
+	ldapPageSize = 500
+)
+
+// errLDAPInvalidCredentials 目录中不存在该用户或密码错误，由调用方映射为登录失败
+var errLDAPInvalidCredentials = errors.New("目录用户名或密码错误")
+
+// LDAPService LDAP 目录认证与用户同步服务
+// 目录用户通过 UserIdentity（Provider 为 ldap，Subject 为小写的目录用户名）与本地用户关联
+type LDAPService struct {
+	db  *gorm.DB
+	cfg config.LDAPConfig
+}
+
+// NewLDAPService 创建目录服务
+func NewLDAPService(db *gorm.DB, cfg *config.Config) *LDAPService {
+	return &LDAPService{
+		db:  db,
+		cfg: cfg.LDAP,
+	}
+}
+
+// Enabled 是否配置了 LDAP
+func (s *LDAPService) Enabled() bool {
+	return s.cfg.Enabled()
+}
+
+// directoryUser 目录中的用户条目
+type directoryUser struct {
+	DN       string
+	Username string
+	Email    string
+	Name     string
+	Groups   []string // 所属组的完整DN和CN
+}
+
+// LDAPSyncResult 一次用户同步的统计结果
+type LDAPSyncResult struct {
+	Total    int `json:"total"`    // 目录中的用户数
+	Created  int `json:"created"`  // 新建的本地用户数
+	Disabled int `json:"disabled"` // 因已从目录移除而禁用的用户数
+	Failed   int `json:"failed"`   // 同步失败的条目数
+}
+
+// isDirectoryUser 判断本地用户是否关联了目录账号
+func (s *LDAPService) isDirectoryUser(userID uint) (bool, error) {
+	var count int64
+	if err := s.db.Model(&models.UserIdentity{}).
+		Where("user_id = ? AND provider = ?", userID, models.LDAPIdentityProvider).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("查询外部身份失败: %v", err)
+	}
+	return count > 0, nil
+}
+
+// Login 以目录中的账号密码登录，返回关联的本地用户（首次登录时自动创建）
+func (s *LDAPService) Login(username, password string) (*models.User, error) {
+	entry, err := s.authenticate(username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *models.User
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		user, _, err = s.upsertUser(tx, entry)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.UserIdentity{}).
+			Where("provider = ? AND subject = ?", models.LDAPIdentityProvider, strings.ToLower(entry.Username)).
+			UpdateColumn("last_login_at", time.Now()).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Sync 从目录同步全部用户：创建新用户、更新邮箱昵称和用户组，
+// 并禁用已从目录中移除的用户（被禁用的用户重新出现在目录中时需管理员手动启用）
+func (s *LDAPService) Sync() (*LDAPSyncResult, error) {
+	if !s.Enabled() {
+		return nil, ErrLDAPDisabled
+	}
+
+	conn, err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	res, err := conn.SearchWithPaging(ldap.NewSearchRequest(
+		s.cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		s.cfg.SyncFilter, s.attributes(), nil,
+	), ldapPageSize)
+	if err != nil {
+		return nil, fmt.Errorf("搜索目录用户失败: %v", err)
+	}
+	// 目录返回空结果多半是配置或服务异常，此时不能把所有目录用户都禁用
+	if len(res.Entries) == 0 {
+		return nil, errors.New("目录中未找到任何用户，已跳过同步")
+	}
+
+	result := &LDAPSyncResult{Total: len(res.Entries)}
+	seen := make(map[string]bool, len(res.Entries))
+	for _, e := range res.Entries {
+		entry := s.toDirectoryUser(e)
+		if entry.Username == "" {
+			result.Failed++
+			continue
+		}
+		seen[strings.ToLower(entry.Username)] = true
+
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			_, created, err := s.upsertUser(tx, entry)
+			if created {
+				result.Created++
+			}
+			return err
+		})
+		if err != nil {
+			log.Printf("同步目录用户 %s 失败: %v", entry.DN, err)
+			result.Failed++
+		}
+	}
+
+	var identities []models.UserIdentity
+	if err := s.db.Where("provider = ?", models.LDAPIdentityProvider).Find(&identities).Error; err != nil {
+		return nil, fmt.Errorf("查询外部身份失败: %v", err)
+	}
+	for _, identity := range identities {
+		if seen[identity.Subject] {
+			continue
+		}
+		disabled, err := s.disableUser(identity)
+		if err != nil {
+			log.Printf("禁用目录用户 %s 失败: %v", identity.Subject, err)
+			result.Failed++
+			continue
+		}
+		if disabled {
+			result.Disabled++
+		}
+	}
+
+	return result, nil
+}
+
+// disableUser 禁用已从目录移除的用户并吊销其登录会话
+func (s *LDAPService) disableUser(identity models.UserIdentity) (bool, error) {
+	disabled := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND status = ?", identity.UserID, models.UserStatusEnabled).
+			UpdateColumn("status", models.UserStatusDisabled)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		disabled = true
+
+		if err := revokeUserSessions(tx, identity.UserID, ""); err != nil {
+			return err
+		}
+		return recordAudit(tx, &models.AuditLog{
+			Action:       models.AuditLDAPUserDisabled,
+			TargetUserID: &identity.UserID,
+			Subject:      identity.Subject,
+			Detail:       "目录中已不存在该用户",
+		})
+	})
+	return disabled, err
+}
+
+// upsertUser 按目录条目查找或创建本地用户，更新资料并同步用户组
+// 尚未关联时按邮箱关联已有用户（目录中的邮箱由管理员维护，视为已验证）
+func (s *LDAPService) upsertUser(tx *gorm.DB, entry *directoryUser) (*models.User, bool, error) {
+	subject := strings.ToLower(entry.Username)
+	email := strings.ToLower(strings.TrimSpace(entry.Email))
+	created := false
+
+	var user models.User
+	var identity models.UserIdentity
+	err := tx.Where("provider = ? AND subject = ?", models.LDAPIdentityProvider, subject).First(&identity).Error
+	switch {
+	case err == nil:
+		if err := tx.First(&user, identity.UserID).Error; err != nil {
+			return nil, false, fmt.Errorf("查询用户失败: %v", err)
+		}
+		if err := s.updateProfile(tx, &user, email, entry.Name); err != nil {
+			return nil, false, err
+		}
+		if email != "" && identity.Email != email {
+			if err := tx.Model(&identity).UpdateColumn("email", email).Error; err != nil {
+				return nil, false, fmt.Errorf("更新外部身份失败: %v", err)
+			}
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if email == "" {
+			return nil, false, fmt.Errorf("目录用户 %s 缺少邮箱属性", entry.DN)
+		}
+		err := tx.Where("LOWER(email) = ?", email).First(&user).Error
+		switch {
+		case err == nil:
+			if !user.EmailVerified {
+				if err := tx.Model(&user).UpdateColumn("email_verified", true).Error; err != nil {
+					return nil, false, fmt.Errorf("更新用户失败: %v", err)
+				}
+				user.EmailVerified = true
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			newUser, err := createExternalUser(tx, entry.Username, email, entry.Name)
+			if err != nil {
+				return nil, false, err
+			}
+			user = *newUser
+			created = true
+		default:
+			return nil, false, fmt.Errorf("查询用户失败: %v", err)
+		}
+		if err := tx.Create(&models.UserIdentity{
+			UserID:   user.ID,
+			Provider: models.LDAPIdentityProvider,
+			Subject:  subject,
+			Email:    email,
+		}).Error; err != nil {
+			return nil, false, fmt.Errorf("保存外部身份失败: %v", err)
+		}
+	default:
+		return nil, false, fmt.Errorf("查询外部身份失败: %v", err)
+	}
+
+	if err := syncGroups(tx, s.cfg.AdminGroups, s.cfg.TeamGroups, &user, entry.Groups); err != nil {
+		return nil, false, err
+	}
+	return &user, created, nil
+}
+
+// updateProfile 同步目录中的邮箱和昵称，邮箱已被其他用户占用时保留原邮箱
+func (s *LDAPService) updateProfile(tx *gorm.DB, user *models.User, email, name string) error {
+	updates := map[string]interface{}{}
+	if email != "" && !strings.EqualFold(user.Email, email) {
+		var count int64
+		if err := tx.Model(&models.User{}).Where("LOWER(email) = ? AND id <> ?", email, user.ID).Count(&count).Error; err != nil {
+			return fmt.Errorf("查询用户失败: %v", err)
+		}
+		if count == 0 {
+			updates["email"] = email
+			updates["email_verified"] = true
+		} else {
+			log.Printf("目录用户 %s 的邮箱 %s 已被其他用户使用，未同步", user.Username, email)
+		}
+	}
+	if name = truncate(name, 50); name != "" && user.Nickname != name {
+		updates["nickname"] = name
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	if err := tx.Model(user).Updates(updates).Error; err != nil {
+		return fmt.Errorf("更新用户失败: %v", err)
+	}
+	return nil
+}
+
+// authenticate 用服务账号查找登录名对应的目录条目，再以该条目的DN和密码绑定校验密码
+func (s *LDAPService) authenticate(username, password string) (*directoryUser, error) {
+	// 空密码绑定在多数目录中是匿名绑定，总会成功，必须拒绝
+	if password == "" {
+		return nil, errLDAPInvalidCredentials
+	}
+
+	conn, err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	filter := strings.ReplaceAll(s.cfg.UserFilter, "{username}", ldap.EscapeFilter(username))
+	res, err := conn.Search(ldap.NewSearchRequest(
+		s.cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, int(ldapTimeout.Seconds()), false,
+		filter, s.attributes(), nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("搜索目录用户失败: %v", err)
+	}
+	if len(res.Entries) != 1 {
+		return nil, errLDAPInvalidCredentials
+	}
+
+	entry := s.toDirectoryUser(res.Entries[0])
+	if entry.Username == "" {
+		return nil, fmt.Errorf("目录用户 %s 缺少 %s 属性", entry.DN, s.cfg.UsernameAttr)
+	}
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, errLDAPInvalidCredentials
+		}
+		return nil, fmt.Errorf("目录绑定失败: %v", err)
+	}
+	return entry, nil
+}
+
+// connect 连接目录服务器并以服务账号绑定（未配置服务账号时匿名访问）
+func (s *LDAPService) connect() (*ldap.Conn, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: s.cfg.InsecureSkipVerify}
+	if u, err := url.Parse(s.cfg.URL); err == nil {
+		tlsConfig.ServerName = u.Hostname()
+	}
+
+	conn, err := ldap.DialURL(s.cfg.URL, ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("连接目录服务器失败: %v", err)
+	}
+	conn.SetTimeout(ldapTimeout)
+
+	if s.cfg.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("目录服务器 StartTLS 失败: %v", err)
+		}
+	}
+	if s.cfg.BindDN != "" {
+		if err := conn.Bind(s.cfg.BindDN, s.cfg.BindPassword); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("目录服务账号绑定失败: %v", err)
+		}
+	}
+	return conn, nil
+}
+
+func (s *LDAPService) attributes() []string {
+	return []string{s.cfg.UsernameAttr, s.cfg.EmailAttr, s.cfg.NameAttr, s.cfg.GroupAttr}
+}
+
+// toDirectoryUser 解析目录条目，组同时记录完整DN和CN，便于配置时任选其一
+func (s *LDAPService) toDirectoryUser(e *ldap.Entry) *directoryUser {
+	entry := &directoryUser{
+		DN:       e.DN,
+		Username: e.GetEqualFoldAttributeValue(s.cfg.UsernameAttr),
+		Email:    e.GetEqualFoldAttributeValue(s.cfg.EmailAttr),
+		Name:     e.GetEqualFoldAttributeValue(s.cfg.NameAttr),
+	}
+	for _, groupDN := range e.GetEqualFoldAttributeValues(s.cfg.GroupAttr) {
+		entry.Groups = append(entry.Groups, groupDN)
+		if dn, err := ldap.ParseDN(groupDN); err == nil && len(dn.RDNs) > 0 && len(dn.RDNs[0].Attributes) > 0 {
+			entry.Groups = append(entry.Groups, dn.RDNs[0].Attributes[0].Value)
+		}
+	}
+	return entry
+}
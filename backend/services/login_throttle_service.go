@@ -0,0 +1,234 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// failureResetWindow 超过该时间没有新的失败记录时，失败计数重新开始
+const failureResetWindow = 24 * time.Hour
+
+// LoginLockedError 登录被临时锁定，RetryAfter 为剩余锁定时间
+// errors.Is(err, ErrLoginLocked) 成立
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return ErrLoginLocked.Error()
+}
+
+func (e *LoginLockedError) Is(target error) bool {
+	return target == ErrLoginLocked
+}
+
+// LoginThrottleService 登录失败保护服务
+// 分别按账号和IP统计连续失败次数，达到阈值后按指数退避临时锁定。
+// 用户不存在时按登录标识计数，锁定行为与真实账号一致，避免通过锁定响应判断用户名是否存在
+type LoginThrottleService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+// NewLoginThrottleService 创建登录失败保护服务
+func NewLoginThrottleService(db *gorm.DB, cfg *config.Config) *LoginThrottleService {
+	return &LoginThrottleService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// AccountSubject 返回账号维度的计数标识
+// 用户存在时按用户ID计数，这样用户名和邮箱两种登录方式共享同一个计数
+func AccountSubject(user *models.User, identifier string) string {
+	if user != nil && user.ID != 0 {
+		return fmt.Sprintf("user:%d", user.ID)
+	}
+	return "name:" + strings.ToLower(strings.TrimSpace(identifier))
+}
+
+// Check 检查账号或IP是否处于锁定期
+func (s *LoginThrottleService) Check(account, ip string) error {
+	var throttles []models.LoginThrottle
+	err := s.db.Where("(scope = ? AND subject = ?) OR (scope = ? AND subject = ?)",
+		models.ThrottleScopeAccount, account, models.ThrottleScopeIP, ip).
+		Find(&throttles).Error
+	if err != nil {
+		return fmt.Errorf("查询登录限制失败: %v", err)
+	}
+
+	var retryAfter time.Duration
+	now := time.Now()
+	for _, t := range throttles {
+		if t.LockedUntil != nil && t.LockedUntil.After(now) && t.LockedUntil.Sub(now) > retryAfter {
+			retryAfter = t.LockedUntil.Sub(now)
+		}
+	}
+	if retryAfter > 0 {
+		return &LoginLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// RecordFailure 记录一次登录失败，达到阈值时锁定并写入审计日志
+// userID 为空表示登录标识对应的用户不存在；本次失败触发锁定时返回 LoginLockedError
+func (s *LoginThrottleService) RecordFailure(account string, userID *uint, client ClientInfo) error {
+	accountLock, err := s.recordFailure(models.ThrottleScopeAccount, account, s.cfg.Auth.LoginMaxAttempts)
+	if err != nil {
+		return err
+	}
+	ipLock, err := s.recordFailure(models.ThrottleScopeIP, client.IP, s.cfg.Auth.LoginIPMaxAttempts)
+	if err != nil {
+		return err
+	}
+
+	if accountLock != nil {
+		s.audit(models.AuditLoginLocked, userID, account, client, accountLock)
+	}
+	if ipLock != nil {
+		s.audit(models.AuditLoginIPLocked, nil, client.IP, client, ipLock)
+	}
+
+	var retryAfter time.Duration
+	for _, lock := range []*models.LoginThrottle{accountLock, ipLock} {
+		if lock != nil && time.Until(*lock.LockedUntil) > retryAfter {
+			retryAfter = time.Until(*lock.LockedUntil)
+		}
+	}
+	if retryAfter > 0 {
+		return &LoginLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// Reset 登录成功后清除账号维度的失败计数
+// IP维度不清零，避免攻击者用自己的账号登录成功来重置所在IP的计数
+func (s *LoginThrottleService) Reset(account string) error {
+	err := s.db.Where("scope = ? AND subject = ?", models.ThrottleScopeAccount, account).
+		Delete(&models.LoginThrottle{}).Error
+	if err != nil {
+		return fmt.Errorf("清除登录限制失败: %v", err)
+	}
+	return nil
+}
+
+// UnlockUser 管理员解除用户的登录锁定
+func (s *LoginThrottleService) UnlockUser(userID, actorID uint, client ClientInfo) error {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("查询用户失败: %v", err)
+	}
+
+	subject := AccountSubject(&user, "")
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("scope = ? AND subject = ?", models.ThrottleScopeAccount, subject).
+			Delete(&models.LoginThrottle{}).Error; err != nil {
+			return fmt.Errorf("清除登录限制失败: %v", err)
+		}
+		return recordAudit(tx, &models.AuditLog{
+			Action:       models.AuditLoginUnlocked,
+			ActorID:      &actorID,
+			TargetUserID: &user.ID,
+			Subject:      subject,
+			IP:           client.IP,
+			UserAgent:    client.UserAgent,
+			Detail:       fmt.Sprintf("管理员解除用户 %s 的登录锁定", user.Username),
+		})
+	})
+}
+
+// recordFailure 累加失败次数，达到阈值时按指数退避设置锁定截止时间
+// 返回非空表示本次失败触发了锁定
+func (s *LoginThrottleService) recordFailure(scope, subject string, maxAttempts int) (*models.LoginThrottle, error) {
+	if subject == "" || maxAttempts <= 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	var throttle models.LoginThrottle
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 计数记录不存在时先创建，并发请求下由唯一索引保证只有一条
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.LoginThrottle{
+			Scope:         scope,
+			Subject:       subject,
+			LastFailureAt: now,
+		}).Error; err != nil {
+			return err
+		}
+
+		// 在数据库中原子累加，距上次失败超过重置窗口时重新计数
+		if err := tx.Model(&models.LoginThrottle{}).
+			Where("scope = ? AND subject = ?", scope, subject).
+			Updates(map[string]interface{}{
+				"failures":        gorm.Expr("CASE WHEN last_failure_at < ? THEN 1 ELSE failures + 1 END", now.Add(-failureResetWindow)),
+				"last_failure_at": now,
+			}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("scope = ? AND subject = ?", scope, subject).First(&throttle).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("记录登录失败次数失败: %v", err)
+	}
+
+	if throttle.Failures < maxAttempts {
+		return nil, nil
+	}
+
+	lockedUntil := now.Add(s.lockoutDuration(throttle.Failures - maxAttempts))
+	if err := s.db.Model(&throttle).Update("locked_until", lockedUntil).Error; err != nil {
+		return nil, fmt.Errorf("记录登录锁定失败: %v", err)
+	}
+	throttle.LockedUntil = &lockedUntil
+	return &throttle, nil
+}
+
+// lockoutDuration 第 n 次（从0开始）超出阈值时的锁定时长，每次翻倍直至上限
+func (s *LoginThrottleService) lockoutDuration(n int) time.Duration {
+	base := time.Duration(s.cfg.Auth.LoginLockoutMinutes) * time.Minute
+	max := time.Duration(s.cfg.Auth.LoginMaxLockoutMinutes) * time.Minute
+	if base <= 0 {
+		base = time.Minute
+	}
+	if max < base {
+		max = base
+	}
+
+	d := base
+	for i := 0; i < n && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
+
+// audit 写入锁定审计日志，失败时不影响登录流程
+func (s *LoginThrottleService) audit(action string, userID *uint, subject string, client ClientInfo, throttle *models.LoginThrottle) {
+	err := recordAudit(s.db, &models.AuditLog{
+		Action:       action,
+		TargetUserID: userID,
+		Subject:      subject,
+		IP:           client.IP,
+		UserAgent:    client.UserAgent,
+		Detail: fmt.Sprintf("连续登录失败 %d 次，锁定至 %s",
+			throttle.Failures, throttle.LockedUntil.Format(time.RFC3339)),
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/models"
+	"progress-wall-backend/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// mfaIssuer 认证器App中显示的发行方名称
+	mfaIssuer = "Progress Wall"
+	// recoveryCodeCount 每次生成的恢复码数量
+	recoveryCodeCount = 10
+	// recoveryCodeAlphabet 恢复码字符集（去除易混淆的 0/O、1/I/L）
+	recoveryCodeAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+)
+
+// MFAService TOTP两步验证服务
+type MFAService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+// NewMFAService 创建两步验证服务
+func NewMFAService(db *gorm.DB, cfg *config.Config) *MFAService {
+	return &MFAService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// MFAEnrollment 两步验证登记信息
+type MFAEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// Enroll 生成新的待确认TOTP密钥，确认前不会影响登录
+func (s *MFAService) Enroll(userID uint) (*MFAEnrollment, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(user).Update("totp_secret", secret).Error; err != nil {
+		return nil, fmt.Errorf("保存两步验证密钥失败: %v", err)
+	}
+
+	return &MFAEnrollment{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(mfaIssuer, user.Username, secret),
+	}, nil
+}
+
+// Confirm 使用认证器生成的验证码确认启用两步验证，返回一次性恢复码
+func (s *MFAService) Confirm(userID uint, code string) ([]string, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+
+	step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	var codes []string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"totp_enabled":   true,
+			"totp_last_step": step,
+		}).Error; err != nil {
+			return fmt.Errorf("启用两步验证失败: %v", err)
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码全部作废
+func (s *MFAService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrMFANotEnabled
+	}
+
+	var codes []string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := verifyTOTPCode(tx, user, code); err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable 关闭两步验证，需要同时提供密码和验证码（或恢复码）
+func (s *MFAService) Disable(userID uint, password, code string) error {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return ErrMFANotEnabled
+	}
+	if mfaRequired(s.cfg, user) {
+		return ErrMFARequired
+	}
+	if !utils.CheckPasswordHash(password, user.Password) {
+		return ErrWrongPassword
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := verifySecondFactor(tx, user, code); err != nil {
+			return err
+		}
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"totp_enabled":   false,
+			"totp_secret":    "",
+			"totp_last_step": 0,
+		}).Error; err != nil {
+			return fmt.Errorf("关闭两步验证失败: %v", err)
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&models.MFARecoveryCode{}).Error
+	})
+}
+
+// getUser 查询用户
+func (s *MFAService) getUser(userID uint) (*models.User, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("查询用户失败: %v", err)
+	}
+	return &user, nil
+}
+
+// mfaRequired 判断策略是否要求该用户启用两步验证
+func mfaRequired(cfg *config.Config, user *models.User) bool {
+	return cfg.Auth.RequireAdminMFA && user.SystemRole == models.SystemRoleAdmin
+}
+
+// verifySecondFactor 校验TOTP验证码或恢复码
+func verifySecondFactor(tx *gorm.DB, user *models.User, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == utils.TOTPDigits {
+		return verifyTOTPCode(tx, user, code)
+	}
+	return useRecoveryCode(tx, user.ID, code)
+}
+
+// verifyTOTPCode 校验TOTP验证码，同一时间步的验证码只能使用一次
+func verifyTOTPCode(tx *gorm.DB, user *models.User, code string) error {
+	step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	result := tx.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", user.ID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return fmt.Errorf("更新两步验证状态失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// useRecoveryCode 核销一个恢复码
+func useRecoveryCode(tx *gorm.DB, userID uint, code string) error {
+	normalized := normalizeRecoveryCode(code)
+	if normalized == "" {
+		return ErrInvalidMFACode
+	}
+
+	result := tx.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, utils.HashToken(normalized)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("更新恢复码失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// replaceRecoveryCodes 删除旧恢复码并生成新的一组，返回明文恢复码
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+		return nil, fmt.Errorf("清理恢复码失败: %v", err)
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]models.MFARecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, models.MFARecoveryCode{
+			UserID:   userID,
+			CodeHash: utils.HashToken(normalizeRecoveryCode(code)),
+		})
+	}
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, fmt.Errorf("保存恢复码失败: %v", err)
+	}
+	return codes, nil
+}
+
+// generateRecoveryCode 生成 XXXXX-XXXXX 格式的恢复码
+func generateRecoveryCode() (string, error) {
+	// 丢弃超出字符集整数倍的字节，避免取模带来的分布偏差
+	limit := byte(256 / len(recoveryCodeAlphabet) * len(recoveryCodeAlphabet))
+	var b strings.Builder
+	buf := make([]byte, 1)
+	for n := 0; n < 10; {
+		if _, err := rand.Read(buf); err != nil {
+			return "", fmt.Errorf("生成恢复码失败: %v", err)
+		}
+		if buf[0] >= limit {
+			continue
+		}
+		if n == 5 {
+			b.WriteByte('-')
+		}
+		b.WriteByte(recoveryCodeAlphabet[int(buf[0])%len(recoveryCodeAlphabet)])
+		n++
+	}
+	return b.String(), nil
+}
+
+// normalizeRecoveryCode 统一恢复码格式（忽略大小写、空格和连字符）
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	return code
+}
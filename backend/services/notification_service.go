@@ -57,5 +57,5 @@ func printTaskNotification(req TaskNotificationService) {
 	fmt.Printf("任务ID：%d\n", req.TaskID)
 	fmt.Printf("任务标题：%s\n", req.TaskTitle)
 	fmt.Printf("通知类型：%s\n", req.NotificationType)
-	fmt.Println("======================================\n")
+	fmt.Println("======================================")
 }
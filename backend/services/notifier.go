@@ -0,0 +1,223 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/mailer"
+	"progress-wall-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// 任务通知类型
+const (
+	NotificationDeadline = "TASK_DEADLINE_APPROACHING"
+	NotificationComment  = "TASK_COMMENTED"
+	NotificationMove     = "TASK_MOVED"
+)
+
+// TaskNotification 推送给通知服务的单条通知
+type TaskNotification struct {
+	UserID           uint   `json:"user_id"`
+	TaskID           uint   `json:"task_id"`
+	TaskKey          string `json:"task_key"`
+	TaskTitle        string `json:"task_title"`
+	NotificationType string `json:"notification_type"`
+	Detail           string `json:"detail,omitempty"`
+	DueDate          string `json:"due_date,omitempty"`
+	Timezone         string `json:"timezone"`
+	Locale           string `json:"locale"`
+}
+
+// Notifier 向任务的负责人和关注者推送通知
+type Notifier struct {
+	db     *gorm.DB
+	cfg    *config.Config
+	client *http.Client
+	mailer mailer.Mailer // 设置后截止提醒同时发送邮件
+}
+
+// NewNotifier 创建通知推送器
+func NewNotifier(db *gorm.DB, cfg *config.Config) *Notifier {
+	return &Notifier{
+		db:  db,
+		cfg: cfg,
+		// 设置超时，避免通知服务无响应时请求无限阻塞
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// NotifyTask 向任务的负责人和关注者（不含 excludeUserID）逐个推送通知，关闭了站内通知的用户不推送
+// 截止提醒还会向开启了邮件通知、邮箱已验证的用户发送邮件
+// 任务没有负责人和关注者时通知创建者。全部发送失败时返回错误
+func (n *Notifier) NotifyTask(task *models.Task, notificationType, detail string, excludeUserID uint) error {
+	recipients, err := taskRecipients(n.db, task)
+	if err != nil {
+		return err
+	}
+	if task.Key == "" {
+		if err := fillTaskKeys(n.db, []*models.Task{task}); err != nil {
+			return err
+		}
+	}
+
+	var sent int
+	var lastErr error
+	for _, userID := range recipients {
+		if userID == excludeUserID {
+			continue
+		}
+		prefs, err := loadPreferences(n.db, n.cfg, userID)
+		if err != nil {
+			return err
+		}
+		sendEmail := n.mailer != nil && notificationType == NotificationDeadline && prefs.EmailNotifications
+		if !prefs.InAppNotifications && !sendEmail {
+			continue
+		}
+
+		notification := TaskNotification{
+			UserID:           userID,
+			TaskID:           task.ID,
+			TaskKey:          task.Key,
+			TaskTitle:        task.Title,
+			NotificationType: notificationType,
+			Detail:           detail,
+			Timezone:         prefs.Timezone,
+			Locale:           prefs.Locale,
+		}
+		// 截止时间按接收人的时区和日期格式显示
+		if task.DueDate != nil {
+			notification.DueDate = prefs.FormatTime(*task.DueDate)
+		}
+		if prefs.InAppNotifications {
+			if err := n.send(notification); err != nil {
+				log.Printf("任务 %d 通知用户 %d 失败: %v", task.ID, userID, err)
+				lastErr = err
+			} else {
+				sent++
+			}
+		}
+		if sendEmail {
+			if err := n.sendDeadlineEmail(notification); err != nil {
+				log.Printf("任务 %d 向用户 %d 发送截止提醒邮件失败: %v", task.ID, userID, err)
+				lastErr = err
+			} else {
+				sent++
+			}
+		}
+	}
+
+	if sent == 0 && lastErr != nil {
+		return lastErr
+	}
+	return nil
+}
+
+// NotifyTaskAsync 在后台推送通知，用于请求处理中，不阻塞响应
+func (n *Notifier) NotifyTaskAsync(task models.Task, notificationType, detail string, excludeUserID uint) {
+	go func() {
+		if err := n.NotifyTask(&task, notificationType, detail, excludeUserID); err != nil {
+			log.Printf("任务 %d 通知发送失败: %v", task.ID, err)
+		}
+	}()
+}
+
+// sendDeadlineEmail 按接收人的语言发送截止提醒邮件，邮箱未验证的用户跳过
+func (n *Notifier) sendDeadlineEmail(notification TaskNotification) error {
+	var user models.User
+	if err := n.db.Select("id", "email", "email_verified").First(&user, notification.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("查询用户失败: %v", err)
+	}
+	if user.Email == "" || !user.EmailVerified {
+		return nil
+	}
+
+	var subject, body string
+	if notification.Locale == models.LocaleEnUS {
+		subject = fmt.Sprintf("Task due soon: %s %s", notification.TaskKey, notification.TaskTitle)
+		body = fmt.Sprintf("Task %s \"%s\" is due at %s (%s).\n\nView it in Progress Wall: %s\n",
+			notification.TaskKey, notification.TaskTitle, notification.DueDate, notification.Timezone, n.cfg.Server.FrontendURL)
+	} else {
+		subject = fmt.Sprintf("任务即将截止：%s %s", notification.TaskKey, notification.TaskTitle)
+		body = fmt.Sprintf("任务 %s「%s」将于 %s（%s）截止。\n\n前往 Progress Wall 查看：%s\n",
+			notification.TaskKey, notification.TaskTitle, notification.DueDate, notification.Timezone, n.cfg.Server.FrontendURL)
+	}
+	return n.mailer.Send(user.Email, subject, body)
+}
+
+// send 推送单条通知，最多重试 3 次，每次间隔递增（1s, 2s）
+func (n *Notifier) send(notification TaskNotification) error {
+	jsonData, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("序列化通知数据失败: %v", err)
+	}
+
+	const maxRetries = 3
+	for i := 0; ; i++ {
+		err = n.post(jsonData)
+		if err == nil {
+			return nil
+		}
+		if i == maxRetries-1 {
+			return fmt.Errorf("通知发送失败，已重试 %d 次: %v", maxRetries, err)
+		}
+		time.Sleep(time.Duration(i+1) * time.Second)
+	}
+}
+
+// post 单次发送请求
+func (n *Notifier) post(jsonData []byte) error {
+	url := fmt.Sprintf("%s/api/notifications", n.cfg.Notification.URL)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("调用通知服务失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// 200-299 为成功
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("通知服务返回错误状态码: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// taskRecipients 任务通知的接收人：负责人和关注者（去重），都没有时为任务创建者
+func taskRecipients(db *gorm.DB, task *models.Task) ([]uint, error) {
+	var assignees, watchers []uint
+	if err := db.Model(&models.TaskAssignee{}).Where("task_id = ?", task.ID).Order("id ASC").Pluck("user_id", &assignees).Error; err != nil {
+		return nil, fmt.Errorf("查询任务负责人失败: %v", err)
+	}
+	if err := db.Model(&models.TaskWatcher{}).Where("task_id = ?", task.ID).Order("id ASC").Pluck("user_id", &watchers).Error; err != nil {
+		return nil, fmt.Errorf("查询任务关注者失败: %v", err)
+	}
+
+	seen := make(map[uint]bool)
+	var recipients []uint
+	for _, userID := range append(assignees, watchers...) {
+		if !seen[userID] {
+			seen[userID] = true
+			recipients = append(recipients, userID)
+		}
+	}
+	if len(recipients) == 0 {
+		recipients = append(recipients, task.CreatorID)
+	}
+	return recipients, nil
+}
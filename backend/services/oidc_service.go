@@ -0,0 +1,309 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/mailer"
+	"progress-wall-backend/models"
+	"progress-wall-backend/oidc"
+	"progress-wall-backend/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	OIDCStateTTL  = 10 * time.Minute // 单点登录请求有效期
+	oidcTicketTTL = 2 * time.Minute  // 回调后前端换取登录令牌的凭证有效期
+)
+
+// OIDCService OpenID Connect 单点登录服务
+// 登录流程：BeginLogin 跳转到提供方 → HandleCallback 校验回调并签发一次性凭证 → ExchangeTicket 换取登录令牌
+type OIDCService struct {
+	db          *gorm.DB
+	cfg         *config.Config
+	authService *AuthService
+	providers   map[string]*oidc.Provider
+}
+
+// NewOIDCService 创建单点登录服务
+func NewOIDCService(db *gorm.DB, cfg *config.Config, mail mailer.Mailer) *OIDCService {
+	providers := make(map[string]*oidc.Provider, len(cfg.OIDC.Providers))
+	for _, p := range cfg.OIDC.Providers {
+		redirectURL := fmt.Sprintf("%s/api/auth/oidc/%s/callback", cfg.OIDC.RedirectBaseURL, p.Name)
+		providers[p.Name] = oidc.NewProvider(p, redirectURL)
+	}
+	return &OIDCService{
+		db:          db,
+		cfg:         cfg,
+		authService: NewAuthService(db, cfg, mail),
+		providers:   providers,
+	}
+}
+
+// OIDCProviderInfo 登录页展示的单点登录方式
+type OIDCProviderInfo struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	LoginURL    string `json:"login_url"`
+}
+
+// ListProviders 获取已配置的单点登录方式
+func (s *OIDCService) ListProviders() []OIDCProviderInfo {
+	result := make([]OIDCProviderInfo, 0, len(s.cfg.OIDC.Providers))
+	for _, p := range s.cfg.OIDC.Providers {
+		result = append(result, OIDCProviderInfo{
+			Name:        p.Name,
+			DisplayName: p.DisplayName,
+			LoginURL:    fmt.Sprintf("/api/auth/oidc/%s/login", p.Name),
+		})
+	}
+	return result
+}
+
+// BeginLogin 创建单点登录请求，返回提供方授权地址和需要写入浏览器 Cookie 的 state
+func (s *OIDCService) BeginLogin(ctx context.Context, name, redirectPath string) (string, string, error) {
+	provider, ok := s.providers[name]
+	if !ok {
+		return "", "", ErrOIDCProviderNotFound
+	}
+
+	state, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := utils.GenerateSecureToken(16)
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := utils.GenerateSecureToken(48)
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		log.Printf("单点登录 %s 初始化失败: %v", name, err)
+		return "", "", ErrOIDCFailed
+	}
+
+	// 顺带清理已过期的登录请求
+	if err := s.db.Where("expires_at < ?", time.Now()).Delete(&models.OIDCLoginState{}).Error; err != nil {
+		return "", "", fmt.Errorf("清理单点登录请求失败: %v", err)
+	}
+	if err := s.db.Create(&models.OIDCLoginState{
+		StateHash:    utils.HashToken(state),
+		Provider:     name,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		RedirectPath: safeRedirectPath(redirectPath),
+		ExpiresAt:    time.Now().Add(OIDCStateTTL),
+	}).Error; err != nil {
+		return "", "", fmt.Errorf("保存单点登录请求失败: %v", err)
+	}
+
+	return authURL, state, nil
+}
+
+// HandleCallback 处理提供方回调：校验 state、换取并校验ID令牌、关联或创建本地用户并同步用户组，
+// 返回前端用于换取登录令牌的一次性凭证和登录后跳转路径
+// cookieState 为发起登录时写入浏览器的 state，用于防止登录CSRF
+func (s *OIDCService) HandleCallback(ctx context.Context, name, code, state, cookieState string) (string, string, error) {
+	provider, ok := s.providers[name]
+	if !ok {
+		return "", "", ErrOIDCProviderNotFound
+	}
+	if state == "" || state != cookieState || code == "" {
+		return "", "", ErrOIDCStateInvalid
+	}
+
+	loginState, err := s.consumeState(name, state)
+	if err != nil {
+		return "", "", err
+	}
+
+	token, err := provider.Exchange(ctx, code, loginState.CodeVerifier)
+	if err != nil {
+		log.Printf("单点登录 %s 回调失败: %v", name, err)
+		return "", "", ErrOIDCFailed
+	}
+	identity, err := provider.Identify(ctx, token, loginState.Nonce)
+	if err != nil {
+		log.Printf("单点登录 %s 回调失败: %v", name, err)
+		return "", "", ErrOIDCFailed
+	}
+
+	ticket, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		user, err := s.resolveUser(tx, provider.Config(), identity)
+		if err != nil {
+			return err
+		}
+		if user.Status != models.UserStatusEnabled {
+			return ErrUserDisabled
+		}
+		p := provider.Config()
+		if err := syncGroups(tx, p.AdminGroups, p.TeamGroups, user, identity.Groups); err != nil {
+			return err
+		}
+		return tx.Create(&models.UserToken{
+			UserID:    user.ID,
+			Purpose:   models.UserTokenOIDCLogin,
+			TokenHash: utils.HashToken(ticket),
+			ExpiresAt: time.Now().Add(oidcTicketTTL),
+		}).Error
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return ticket, loginState.RedirectPath, nil
+}
+
+// ExchangeTicket 使用回调签发的一次性凭证完成登录，签发与密码登录相同的令牌
+func (s *OIDCService) ExchangeTicket(ticket string, client ClientInfo) (*LoginResult, error) {
+	var user models.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		userToken, err := useUserToken(tx, ticket, models.UserTokenOIDCLogin)
+		if err != nil {
+			if err == errTokenUnusable {
+				return ErrOIDCTicketInvalid
+			}
+			return err
+		}
+		if err := tx.First(&user, userToken.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOIDCTicketInvalid
+			}
+			return fmt.Errorf("查询用户失败: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if user.Status != models.UserStatusEnabled {
+		return nil, ErrUserDisabled
+	}
+
+	// 本地启用了两步验证的账号，单点登录后仍需完成两步验证
+	if user.TOTPEnabled {
+		mfaToken, err := utils.GenerateMFAToken(user.ID, user.Username, s.cfg)
+		if err != nil {
+			return nil, ErrGenerateToken
+		}
+		return &LoginResult{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		}, nil
+	}
+
+	return s.authService.completeLogin(&user, client)
+}
+
+// consumeState 一次性取出单点登录请求
+func (s *OIDCService) consumeState(name, state string) (*models.OIDCLoginState, error) {
+	var loginState models.OIDCLoginState
+	err := s.db.Where("state_hash = ? AND provider = ?", utils.HashToken(state), name).First(&loginState).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOIDCStateInvalid
+		}
+		return nil, fmt.Errorf("查询单点登录请求失败: %v", err)
+	}
+
+	// 删除成功才算取得该请求，防止同一回调被重复处理
+	result := s.db.Delete(&models.OIDCLoginState{}, loginState.ID)
+	if result.Error != nil {
+		return nil, fmt.Errorf("删除单点登录请求失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 || time.Now().After(loginState.ExpiresAt) {
+		return nil, ErrOIDCStateInvalid
+	}
+	return &loginState, nil
+}
+
+// resolveUser 查找外部身份关联的本地用户；尚未关联时按已验证的邮箱关联已有用户或创建新用户
+func (s *OIDCService) resolveUser(tx *gorm.DB, p config.OIDCProviderConfig, identity *oidc.Identity) (*models.User, error) {
+	now := time.Now()
+
+	var link models.UserIdentity
+	err := tx.Where("provider = ? AND subject = ?", p.Name, identity.Subject).First(&link).Error
+	if err == nil {
+		var user models.User
+		if err := tx.First(&user, link.UserID).Error; err == nil {
+			if err := tx.Model(&link).Updates(map[string]interface{}{"email": identity.Email, "last_login_at": now}).Error; err != nil {
+				return nil, fmt.Errorf("更新外部身份失败: %v", err)
+			}
+			return &user, nil
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("查询用户失败: %v", err)
+		}
+		// 关联的用户已被删除，移除失效的关联后按新用户处理
+		if err := tx.Delete(&link).Error; err != nil {
+			return nil, fmt.Errorf("删除外部身份失败: %v", err)
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("查询外部身份失败: %v", err)
+	}
+
+	email := strings.ToLower(strings.TrimSpace(identity.Email))
+	if email == "" || !identity.EmailVerified {
+		return nil, ErrOIDCEmailUnverified
+	}
+
+	var user models.User
+	err = tx.Where("LOWER(email) = ?", email).First(&user).Error
+	switch {
+	case err == nil:
+		// 提供方已验证该邮箱，同步标记本地邮箱已验证
+		if !user.EmailVerified {
+			if err := tx.Model(&user).UpdateColumn("email_verified", true).Error; err != nil {
+				return nil, fmt.Errorf("更新用户失败: %v", err)
+			}
+			user.EmailVerified = true
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if !p.AllowSignup {
+			return nil, ErrOIDCSignupDisabled
+		}
+		base := identity.PreferredUsername
+		if base == "" {
+			base, _, _ = strings.Cut(email, "@")
+		}
+		created, err := createExternalUser(tx, base, email, identity.Name)
+		if err != nil {
+			return nil, err
+		}
+		user = *created
+	default:
+		return nil, fmt.Errorf("查询用户失败: %v", err)
+	}
+
+	if err := tx.Create(&models.UserIdentity{
+		UserID:      user.ID,
+		Provider:    p.Name,
+		Subject:     identity.Subject,
+		Email:       email,
+		LastLoginAt: &now,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("保存外部身份失败: %v", err)
+	}
+	return &user, nil
+}
+
+// safeRedirectPath 只允许站内相对路径，防止开放重定向
+func safeRedirectPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.Contains(path, "\\") || len(path) > 255 {
+		return "/"
+	}
+	return path
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/mailer"
+	"progress-wall-backend/models"
+	"progress-wall-backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// passwordResetTTL 找回密码链接有效期
+const passwordResetTTL = time.Hour
+
+// PasswordService 修改密码与找回密码服务
+type PasswordService struct {
+	db             *gorm.DB
+	mailer         mailer.Mailer
+	sessionService *SessionService
+	frontendURL    string
+}
+
+// NewPasswordService 创建密码服务
+func NewPasswordService(db *gorm.DB, mail mailer.Mailer, cfg *config.Config) *PasswordService {
+	return &PasswordService{
+		db:             db,
+		mailer:         mail,
+		sessionService: NewSessionService(db, cfg),
+		frontendURL:    strings.TrimRight(cfg.Server.FrontendURL, "/"),
+	}
+}
+
+// ChangePassword 校验当前密码后修改密码
+// 修改成功后用户的所有会话都会被吊销，并为当前客户端签发新的会话
+func (s *PasswordService) ChangePassword(userID uint, currentPassword, newPassword string, client ClientInfo) (*TokenPair, error) {
+	var user models.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("查询用户失败: %v", err)
+	}
+
+	if !utils.CheckPasswordHash(currentPassword, user.Password) {
+		return nil, ErrWrongPassword
+	}
+	if currentPassword == newPassword {
+		return nil, ErrSamePassword
+	}
+	if err := utils.ValidatePasswordStrength(newPassword); err != nil {
+		return nil, ErrInvalidPassword
+	}
+
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		return setPassword(tx, userID, newPassword)
+	}); err != nil {
+		return nil, err
+	}
+
+	return s.sessionService.CreateSession(&user, client)
+}
+
+// RequestPasswordReset 发送找回密码邮件
+// 无论邮箱是否存在都返回成功，避免泄露账号是否注册
+func (s *PasswordService) RequestPasswordReset(email string) error {
+	email = strings.TrimSpace(email)
+
+	var user models.User
+	result := s.db.Where("email = ? AND status = ?", email, models.UserStatusEnabled).Limit(1).Find(&user)
+	if result.Error != nil {
+		return fmt.Errorf("查询用户失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	return s.sendResetLink(&user)
+}
+
+// sendResetLink 生成找回密码链接并发送到用户邮箱，之前未使用的链接全部作废
+func (s *PasswordService) sendResetLink(user *models.User) error {
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND purpose = ? AND used_at IS NULL", user.ID, models.UserTokenPasswordReset).
+			Delete(&models.UserToken{}).Error; err != nil {
+			return fmt.Errorf("清理重置令牌失败: %v", err)
+		}
+		return tx.Create(&models.UserToken{
+			UserID:    user.ID,
+			Purpose:   models.UserTokenPasswordReset,
+			TokenHash: utils.HashToken(token),
+			ExpiresAt: time.Now().Add(passwordResetTTL),
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("创建重置令牌失败: %v", err)
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", s.frontendURL, token)
+	body := fmt.Sprintf("你好 %s，\n\n我们收到了重置你 Progress Wall 账号密码的请求。点击以下链接设置新密码（%d 分钟内有效，仅可使用一次）：\n%s\n\n如果这不是你本人的操作，请忽略此邮件，你的密码不会被修改。\n",
+		user.Username, int(passwordResetTTL.Minutes()), link)
+	if err := s.mailer.Send(user.Email, "重置 Progress Wall 密码", body); err != nil {
+		log.Printf("发送找回密码邮件失败: %v", err)
+	}
+	return nil
+}
+
+// ResetPassword 使用找回密码令牌设置新密码，令牌只能使用一次
+func (s *PasswordService) ResetPassword(token, newPassword string) error {
+	if err := utils.ValidatePasswordStrength(newPassword); err != nil {
+		return ErrInvalidPassword
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		userToken, err := useUserToken(tx, token, models.UserTokenPasswordReset)
+		if err != nil {
+			if err == errTokenUnusable {
+				return ErrInvalidResetToken
+			}
+			return err
+		}
+		return setPassword(tx, userToken.UserID, newPassword)
+	})
+}
+
+// errTokenUnusable 一次性令牌不存在、已使用或已过期
+var errTokenUnusable = errors.New("token unusable")
+
+// useUserToken 校验并核销一次性令牌，令牌用途必须是 purposes 之一
+func useUserToken(tx *gorm.DB, token string, purposes ...models.UserTokenPurpose) (*models.UserToken, error) {
+	if token == "" {
+		return nil, errTokenUnusable
+	}
+
+	var userToken models.UserToken
+	err := tx.Where("token_hash = ? AND purpose IN ?", utils.HashToken(token), purposes).First(&userToken).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errTokenUnusable
+		}
+		return nil, fmt.Errorf("查询令牌失败: %v", err)
+	}
+	if userToken.UsedAt != nil || time.Now().After(userToken.ExpiresAt) {
+		return nil, errTokenUnusable
+	}
+
+	// 条件更新防止同一令牌被并发使用两次
+	result := tx.Model(&userToken).Where("used_at IS NULL").Update("used_at", time.Now())
+	if result.Error != nil {
+		return nil, fmt.Errorf("更新令牌失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, errTokenUnusable
+	}
+	return &userToken, nil
+}
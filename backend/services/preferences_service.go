@@ -0,0 +1,143 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// PreferencesService 用户偏好设置服务
+type PreferencesService struct {
+	db          *gorm.DB
+	cfg         *config.Config
+	permService *PermissionService
+}
+
+// NewPreferencesService 创建偏好设置服务
+func NewPreferencesService(db *gorm.DB, cfg *config.Config) *PreferencesService {
+	return &PreferencesService{
+		db:          db,
+		cfg:         cfg,
+		permService: NewPermissionService(db),
+	}
+}
+
+// UpdatePreferencesInput 偏好设置更新内容，nil 表示不修改；DefaultBoardID 为0时清除默认看板
+type UpdatePreferencesInput struct {
+	Timezone           *string
+	Locale             *string
+	DateFormat         *string
+	DefaultBoardID     *uint
+	EmailNotifications *bool
+	InAppNotifications *bool
+}
+
+// GetPreferences 获取用户偏好设置，未保存过时返回默认值
+func (s *PreferencesService) GetPreferences(userID uint) (*models.UserPreferences, error) {
+	return loadPreferences(s.db, s.cfg, userID)
+}
+
+// UpdatePreferences 更新用户偏好设置
+func (s *PreferencesService) UpdatePreferences(userID uint, input UpdatePreferencesInput) (*models.UserPreferences, error) {
+	prefs, err := loadPreferences(s.db, s.cfg, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Timezone != nil {
+		if !validTimezone(*input.Timezone) {
+			return nil, ErrInvalidTimezone
+		}
+		prefs.Timezone = *input.Timezone
+	}
+	if input.Locale != nil {
+		if *input.Locale != models.LocaleZhCN && *input.Locale != models.LocaleEnUS {
+			return nil, ErrInvalidLocale
+		}
+		prefs.Locale = *input.Locale
+	}
+	if input.DateFormat != nil {
+		if _, ok := models.DateFormats[*input.DateFormat]; !ok {
+			return nil, ErrInvalidDateFormat
+		}
+		prefs.DateFormat = *input.DateFormat
+	}
+	if input.DefaultBoardID != nil {
+		if *input.DefaultBoardID == 0 {
+			prefs.DefaultBoardID = nil
+		} else {
+			if err := s.checkBoardAccess(userID, *input.DefaultBoardID); err != nil {
+				return nil, err
+			}
+			prefs.DefaultBoardID = input.DefaultBoardID
+		}
+	}
+	if input.EmailNotifications != nil {
+		prefs.EmailNotifications = *input.EmailNotifications
+	}
+	if input.InAppNotifications != nil {
+		prefs.InAppNotifications = *input.InAppNotifications
+	}
+
+	if err := s.db.Save(prefs).Error; err != nil {
+		return nil, fmt.Errorf("保存偏好设置失败: %v", err)
+	}
+	return prefs, nil
+}
+
+// checkBoardAccess 确认默认看板存在且用户可以访问
+func (s *PreferencesService) checkBoardAccess(userID, boardID uint) error {
+	var board models.Board
+	if err := s.db.Select("id", "project_id").First(&board, boardID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrBoardNotFound
+		}
+		return fmt.Errorf("查询看板失败: %v", err)
+	}
+	canAccess, err := s.permService.CanAccessProject(userID, board.ProjectID)
+	if err != nil {
+		return err
+	}
+	if !canAccess {
+		return ErrAccessDenied
+	}
+	return nil
+}
+
+// loadPreferences 查询用户偏好设置，未保存过时返回默认值（未写入数据库）
+// 保存的时区为空或已失效时改用服务器默认时区，避免时间显示依赖主机时区
+func loadPreferences(db *gorm.DB, cfg *config.Config, userID uint) (*models.UserPreferences, error) {
+	var prefs models.UserPreferences
+	result := db.Where("user_id = ?", userID).Limit(1).Find(&prefs)
+	if result.Error != nil {
+		return nil, fmt.Errorf("查询偏好设置失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		prefs = models.UserPreferences{
+			UserID:             userID,
+			Timezone:           cfg.Server.Timezone,
+			Locale:             models.LocaleZhCN,
+			DateFormat:         "YYYY-MM-DD",
+			EmailNotifications: true,
+			InAppNotifications: true,
+		}
+	}
+	if !validTimezone(prefs.Timezone) {
+		prefs.Timezone = cfg.Server.Timezone
+	}
+	return &prefs, nil
+}
+
+// validTimezone 是否为可加载的 IANA 时区名称，不接受空值和依赖主机的 Local
+func validTimezone(name string) bool {
+	if name == "" || name == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"progress-wall-backend/models"
 
@@ -58,6 +59,7 @@ func (s *ProjectService) GetProjectsByUserID(userID uint) ([]models.Project, err
 
 // CreateProject creates a new project under a team and assigns the creator as ProjectAdmin.
 // It executes the creation and member assignment within a transaction.
+// When no key is given, one is derived from the project name.
 func (s *ProjectService) CreateProject(project *models.Project) error {
 	tx := s.db.Begin()
 	defer func() {
@@ -70,8 +72,23 @@ func (s *ProjectService) CreateProject(project *models.Project) error {
 		return err
 	}
 
+	if project.Key == "" {
+		key, err := uniqueProjectKey(tx, models.DefaultProjectKey(project.Name))
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		project.Key = key
+	} else if err := checkProjectKey(tx, project.Key, 0); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	if err := tx.Create(project).Error; err != nil {
 		tx.Rollback()
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrProjectKeyExists
+		}
 		return err
 	}
 
@@ -104,8 +121,17 @@ func (s *ProjectService) GetTeamProjects(teamID uint) ([]models.Project, error)
 
 // UpdateProject updates the fields of a project identified by projectID.
 func (s *ProjectService) UpdateProject(projectID uint, updates map[string]interface{}) error {
+	if key, ok := updates["project_key"].(string); ok {
+		if err := checkProjectKey(s.db, key, projectID); err != nil {
+			return err
+		}
+	}
+
 	result := s.db.Model(&models.Project{}).Where("id = ?", projectID).Updates(updates)
 	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return ErrProjectKeyExists
+		}
 		return fmt.Errorf("更新项目失败: %v", result.Error)
 	}
 	if result.RowsAffected == 0 {
@@ -125,3 +151,227 @@ func (s *ProjectService) DeleteProject(projectID uint) error {
 	}
 	return nil
 }
+
+// GetProjectMembers retrieves all members of a project with their user info.
+func (s *ProjectService) GetProjectMembers(projectID uint) ([]models.ProjectMember, error) {
+	var members []models.ProjectMember
+	err := s.db.Where("project_id = ?", projectID).
+		Preload("User").
+		Order("role DESC, joined_at ASC").
+		Find(&members).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询项目成员失败: %v", err)
+	}
+	return members, nil
+}
+
+// AddProjectMember adds a user to a project.
+// The user must already be a member of the project's parent team.
+func (s *ProjectService) AddProjectMember(projectID, userID uint, role models.ProjectRole, actor Actor) (*models.ProjectMember, error) {
+	if !validProjectRole(role) {
+		return nil, ErrInvalidRole
+	}
+
+	member := &models.ProjectMember{
+		ProjectID: projectID,
+		UserID:    userID,
+		Role:      role,
+		JoinedAt:  time.Now(),
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var project models.Project
+		if err := tx.First(&project, projectID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrProjectNotFound
+			}
+			return fmt.Errorf("查询项目失败: %v", err)
+		}
+
+		var user models.User
+		if err := tx.Select("id", "username").First(&user, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return fmt.Errorf("查询用户失败: %v", err)
+		}
+
+		var count int64
+		if err := tx.Model(&models.TeamMember{}).
+			Where("team_id = ? AND user_id = ?", project.TeamID, userID).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("查询团队成员失败: %v", err)
+		}
+		if count == 0 {
+			return ErrNotTeamMember
+		}
+
+		if err := tx.Model(&models.ProjectMember{}).
+			Where("project_id = ? AND user_id = ?", projectID, userID).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("查询项目成员失败: %v", err)
+		}
+		if count > 0 {
+			return ErrMemberExists
+		}
+
+		if err := tx.Create(member).Error; err != nil {
+			return fmt.Errorf("添加项目成员失败: %v", err)
+		}
+
+		return tx.Create(&models.ActivityLog{
+			UserID:         actor.ID,
+			Username:       actor.Name,
+			ImpersonatorID: actor.ImpersonatorID,
+			ActionType:     models.ActionUpdate,
+			EntityType:     models.EntityProject,
+			EntityID:       projectID,
+			ProjectID:      &projectID,
+			Description:    fmt.Sprintf("added %s to the project", user.Username),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return member, nil
+}
+
+// UpdateProjectMemberRole changes the role of a project member.
+// The last remaining admin cannot be demoted.
+func (s *ProjectService) UpdateProjectMemberRole(projectID, userID uint, role models.ProjectRole, actor Actor) error {
+	if !validProjectRole(role) {
+		return ErrInvalidRole
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		member, err := s.getProjectMember(tx, projectID, userID)
+		if err != nil {
+			return err
+		}
+		if member.Role == role {
+			return nil
+		}
+		if member.Role == models.ProjectRoleAdmin {
+			if err := ensureAnotherAdmin(tx, projectID); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(member).Update("role", role).Error; err != nil {
+			return fmt.Errorf("更新成员角色失败: %v", err)
+		}
+
+		roleName := "member"
+		if role == models.ProjectRoleAdmin {
+			roleName = "admin"
+		}
+		return tx.Create(&models.ActivityLog{
+			UserID:         actor.ID,
+			Username:       actor.Name,
+			ImpersonatorID: actor.ImpersonatorID,
+			ActionType:     models.ActionUpdate,
+			EntityType:     models.EntityProject,
+			EntityID:       projectID,
+			ProjectID:      &projectID,
+			Description:    fmt.Sprintf("changed the role of %s to %s", member.User.Username, roleName),
+		}).Error
+	})
+}
+
+// RemoveProjectMember removes a user from a project.
+// The last remaining admin cannot be removed.
+func (s *ProjectService) RemoveProjectMember(projectID, userID uint, actor Actor) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		member, err := s.getProjectMember(tx, projectID, userID)
+		if err != nil {
+			return err
+		}
+		if member.Role == models.ProjectRoleAdmin {
+			if err := ensureAnotherAdmin(tx, projectID); err != nil {
+				return err
+			}
+		}
+
+		// project_members doubles as the many2many join table for Project.Members,
+		// which ignores soft deletes, so the row is removed permanently.
+		if err := tx.Unscoped().Delete(member).Error; err != nil {
+			return fmt.Errorf("移除项目成员失败: %v", err)
+		}
+
+		return tx.Create(&models.ActivityLog{
+			UserID:         actor.ID,
+			Username:       actor.Name,
+			ImpersonatorID: actor.ImpersonatorID,
+			ActionType:     models.ActionUpdate,
+			EntityType:     models.EntityProject,
+			EntityID:       projectID,
+			ProjectID:      &projectID,
+			Description:    fmt.Sprintf("removed %s from the project", member.User.Username),
+		}).Error
+	})
+}
+
+// getProjectMember retrieves the membership row of a user in a project.
+func (s *ProjectService) getProjectMember(tx *gorm.DB, projectID, userID uint) (*models.ProjectMember, error) {
+	var member models.ProjectMember
+	err := tx.Where("project_id = ? AND user_id = ?", projectID, userID).
+		Preload("User").
+		First(&member).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMemberNotFound
+		}
+		return nil, fmt.Errorf("查询项目成员失败: %v", err)
+	}
+	return &member, nil
+}
+
+// ensureAnotherAdmin returns ErrLastProjectAdmin if the project has at most one admin.
+func ensureAnotherAdmin(tx *gorm.DB, projectID uint) error {
+	var admins int64
+	if err := tx.Model(&models.ProjectMember{}).
+		Where("project_id = ? AND role = ?", projectID, models.ProjectRoleAdmin).
+		Count(&admins).Error; err != nil {
+		return fmt.Errorf("查询项目管理员失败: %v", err)
+	}
+	if admins <= 1 {
+		return ErrLastProjectAdmin
+	}
+	return nil
+}
+
+// validProjectRole reports whether role is a known project role.
+func validProjectRole(role models.ProjectRole) bool {
+	return role == models.ProjectRoleMember || role == models.ProjectRoleAdmin
+}
+
+// checkProjectKey validates the key format and makes sure no other project
+// (including soft-deleted ones, which still hold their key) uses it.
+func checkProjectKey(db *gorm.DB, key string, projectID uint) error {
+	if !models.ValidProjectKey(key) {
+		return ErrInvalidProjectKey
+	}
+	var count int64
+	if err := db.Unscoped().Model(&models.Project{}).
+		Where("project_key = ? AND id <> ?", key, projectID).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("查询项目失败: %v", err)
+	}
+	if count > 0 {
+		return ErrProjectKeyExists
+	}
+	return nil
+}
+
+// uniqueProjectKey returns base, or base followed by the smallest number that makes it unused.
+func uniqueProjectKey(db *gorm.DB, base string) (string, error) {
+	key := base
+	for n := 2; ; n++ {
+		err := checkProjectKey(db, key, 0)
+		if err != ErrProjectKeyExists {
+			return key, err
+		}
+		key = fmt.Sprintf("%s%d", base, n)
+	}
+}
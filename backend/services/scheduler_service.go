@@ -1,13 +1,12 @@
 package services
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
 	"log"
-	"net/http"
 	"time"
 
+	"progress-wall-backend/config"
+	"progress-wall-backend/mailer"
 	"progress-wall-backend/models"
 
 	"gorm.io/gorm"
@@ -17,15 +16,23 @@ import (
 
 // Scheduler 定时任务调度器
 type Scheduler struct {
-	db                  *gorm.DB // SQLite数据库连接
-	notificationBaseURL string   // 通知服务(B8)基础URL
+	db               *gorm.DB     // SQLite数据库连接
+	notifier         *Notifier    // 任务通知推送
+	keyService       *KeyService  // JWT签名密钥轮换
+	ldapService      *LDAPService // LDAP 目录用户同步
+	ldapSyncInterval int          // 目录同步周期（分钟）
 }
 
-// NewScheduler 创建调度器实例
-func NewScheduler(db *gorm.DB, notificationBaseURL string) *Scheduler {
+// NewScheduler 创建调度器实例，mail 用于发送截止提醒邮件
+func NewScheduler(db *gorm.DB, cfg *config.Config, keyService *KeyService, mail mailer.Mailer) *Scheduler {
+	notifier := NewNotifier(db, cfg)
+	notifier.mailer = mail
 	return &Scheduler{
-		db:                  db,
-		notificationBaseURL: notificationBaseURL,
+		db:               db,
+		notifier:         notifier,
+		keyService:       keyService,
+		ldapService:      NewLDAPService(db, cfg),
+		ldapSyncInterval: cfg.LDAP.SyncIntervalMinutes,
 	}
 }
 
@@ -39,6 +46,28 @@ func (s *Scheduler) Start() *cron.Cron {
 		log.Fatalf("注册定时任务失败: %v", err)
 	}
 
+	// 每天清理已过期的登录会话记录
+	if _, err := c.AddFunc("30 3 * * *", s.purgeExpiredSessions); err != nil {
+		log.Fatalf("注册定时任务失败: %v", err)
+	}
+
+	// 每天清理已失效的登录失败计数
+	if _, err := c.AddFunc("40 3 * * *", s.purgeLoginThrottles); err != nil {
+		log.Fatalf("注册定时任务失败: %v", err)
+	}
+
+	// 每分钟检查签名密钥是否需要轮换，并重新加载其他实例生成的密钥
+	if _, err := c.AddFunc("* * * * *", s.refreshSigningKeys); err != nil {
+		log.Fatalf("注册定时任务失败: %v", err)
+	}
+
+	// 按配置周期从LDAP目录同步用户
+	if s.ldapService.Enabled() && s.ldapSyncInterval > 0 {
+		if _, err := c.AddFunc(fmt.Sprintf("@every %dm", s.ldapSyncInterval), s.syncDirectoryUsers); err != nil {
+			log.Fatalf("注册定时任务失败: %v", err)
+		}
+	}
+
 	c.Start()
 	log.Println("定时任务调度器已启动，每小时执行一次")
 	return c
@@ -96,83 +125,65 @@ func (s *Scheduler) queryPendingTasks() ([]models.Task, error) {
 	return tasks, err
 }
 
-// sendNotification 调用通知服务发送提醒（带重试和超时机制）
+// sendNotification 向任务的负责人和关注者发送截止提醒
 func (s *Scheduler) sendNotification(task models.Task) error {
-	// 构建通知 payload（按需求包含必要字段）
-	payload := struct {
-		UserID           uint   `json:"user_id"`
-		TaskID           uint   `json:"task_id"`
-		TaskTitle        string `json:"task_title"`
-		NotificationType string `json:"notification_type"`
-	}{
-		UserID:           task.CreatorID,
-		TaskID:           task.ID,
-		TaskTitle:        task.Title,
-		NotificationType: "TASK_DEADLINE_APPROACHING", // 固定通知类型
-	}
-
-	// 序列化 payload 为 JSON
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("序列化通知数据失败: %v", err)
-	}
-
-	// 重试逻辑：最多重试 3 次，每次间隔递增（1s, 2s, 3s）
-	maxRetries := 3
-	for i := 0; i < maxRetries; i++ {
-		// 执行单次发送尝试
-		err := s.sendWithTimeout(jsonData)
-		if err == nil {
-			// 发送成功，直接返回
-			return nil
-		}
+	return s.notifier.NotifyTask(&task, NotificationDeadline, "", 0)
+}
 
-		// 最后一次重试失败，返回错误
-		if i == maxRetries-1 {
-			return fmt.Errorf("通知发送失败，已重试 %d 次: %v", maxRetries, err)
-		}
+// updateAlertStatus 更新任务的提醒状态（避免重复发送）
+func (s *Scheduler) updateAlertStatus(taskID uint) error {
+	return s.db.Model(&models.Task{}).
+		Where("id = ?", taskID).
+		Update("deadline_alert_sent", 1).Error
+}
 
-		// 非最后一次失败，等待后重试（间隔递增）
-		retryDelay := time.Duration(i+1) * time.Second
-		time.Sleep(retryDelay)
+// purgeExpiredSessions 删除已过期的刷新令牌记录
+// 已轮换但未过期的记录需要保留，用于检测刷新令牌重用
+func (s *Scheduler) purgeExpiredSessions() {
+	result := s.db.Where("expires_at < ?", time.Now()).Delete(&models.Session{})
+	if result.Error != nil {
+		log.Printf("清理过期会话失败: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("已清理 %d 条过期会话记录", result.RowsAffected)
 	}
-
-	return nil
 }
 
-// sendWithTimeout 单次发送请求（带超时设置）
-func (s *Scheduler) sendWithTimeout(jsonData []byte) error {
-	// 创建带超时的 HTTP 客户端（超时时间 5 秒，可根据需求调整）
-	client := &http.Client{
-		Timeout: 5 * time.Second, // 关键：设置 HTTP 超时，避免请求无限阻塞
+// purgeLoginThrottles 删除已过重置窗口且不在锁定期的登录失败计数
+func (s *Scheduler) purgeLoginThrottles() {
+	now := time.Now()
+	result := s.db.Where("last_failure_at < ? AND (locked_until IS NULL OR locked_until < ?)", now.Add(-failureResetWindow), now).
+		Delete(&models.LoginThrottle{})
+	if result.Error != nil {
+		log.Printf("清理登录失败计数失败: %v", result.Error)
+		return
 	}
-
-	// 构建请求
-	url := fmt.Sprintf("%s/api/notifications", s.notificationBaseURL)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("创建请求失败: %v", err)
+	if result.RowsAffected > 0 {
+		log.Printf("已清理 %d 条登录失败计数", result.RowsAffected)
 	}
-	req.Header.Set("Content-Type", "application/json")
+}
 
-	// 发送请求
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("调用通知服务失败: %v", err)
+// refreshSigningKeys 按周期轮换JWT签名密钥并重新加载，同时清理已过期的验证密钥
+func (s *Scheduler) refreshSigningKeys() {
+	if err := s.keyService.RotateIfDue(); err != nil {
+		log.Printf("轮换签名密钥失败: %v", err)
 	}
-	defer resp.Body.Close()
-
-	// 检查响应状态码（200-299 为成功）
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("通知服务返回错误状态码: %d", resp.StatusCode)
+	if err := s.keyService.Load(); err != nil {
+		log.Printf("加载签名密钥失败: %v", err)
+	}
+	if _, err := s.keyService.PurgeExpired(); err != nil {
+		log.Printf("清理签名密钥失败: %v", err)
 	}
-
-	return nil
 }
 
-// updateAlertStatus 更新任务的提醒状态（避免重复发送）
-func (s *Scheduler) updateAlertStatus(taskID uint) error {
-	return s.db.Model(&models.Task{}).
-		Where("id = ?", taskID).
-		Update("deadline_alert_sent", 1).Error
+// syncDirectoryUsers 从LDAP目录同步用户和团队成员身份
+func (s *Scheduler) syncDirectoryUsers() {
+	result, err := s.ldapService.Sync()
+	if err != nil {
+		log.Printf("同步目录用户失败: %v", err)
+		return
+	}
+	log.Printf("目录用户同步完成: 共 %d 个，新建 %d 个，禁用 %d 个，失败 %d 个",
+		result.Total, result.Created, result.Disabled, result.Failed)
 }
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/dto"
+	"progress-wall-backend/models"
+	"progress-wall-backend/utils"
+
+	"gorm.io/gorm"
+)
+
+// lastSeenInterval 最近使用时间的更新间隔，避免每个请求都写数据库
+const lastSeenInterval = time.Minute
+
+// SessionService 登录会话服务，负责签发、轮换和吊销刷新令牌
+type SessionService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+// NewSessionService 创建会话服务
+func NewSessionService(db *gorm.DB, cfg *config.Config) *SessionService {
+	return &SessionService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// ClientInfo 发起请求的客户端信息
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// TokenPair 访问令牌和刷新令牌
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int // 访问令牌有效期（秒）
+}
+
+// CreateSession 为用户创建新的登录会话并签发令牌
+func (s *SessionService) CreateSession(user *models.User, client ClientInfo) (*TokenPair, error) {
+	familyID, err := utils.GenerateSecureToken(16)
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(s.db, user, familyID, time.Now(), client)
+}
+
+// Refresh 使用刷新令牌换取新的令牌对，旧刷新令牌随即失效
+// 已轮换过的刷新令牌再次出现说明令牌可能被盗用，此时吊销整个会话族
+func (s *SessionService) Refresh(refreshToken string, client ClientInfo) (*TokenPair, error) {
+	session, err := s.findByToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, ErrInvalidRefresh
+	}
+	if session.RotatedAt != nil {
+		if err := s.revokeFamily(session.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshReused
+	}
+
+	var user models.User
+	if err := s.db.First(&user, session.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidRefresh
+		}
+		return nil, fmt.Errorf("查询用户失败: %v", err)
+	}
+	if user.Status != models.UserStatusEnabled {
+		return nil, ErrUserDisabled
+	}
+
+	var pair *TokenPair
+	reused := false
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// 条件更新保证同一刷新令牌只能被轮换一次
+		result := tx.Model(&models.Session{}).
+			Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", session.ID).
+			Update("rotated_at", time.Now())
+		if result.Error != nil {
+			return fmt.Errorf("更新会话失败: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			reused = true
+			return ErrRefreshReused
+		}
+
+		var err error
+		pair, err = s.issue(tx, &user, session.FamilyID, session.LoginAt, client)
+		return err
+	})
+	if reused {
+		if err := s.revokeFamily(session.FamilyID); err != nil {
+			return nil, err
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// RevokeByRefreshToken 吊销刷新令牌所属的整个会话族（退出登录）
+func (s *SessionService) RevokeByRefreshToken(refreshToken string) error {
+	session, err := s.findByToken(refreshToken)
+	if err != nil {
+		return err
+	}
+	return s.revokeFamily(session.FamilyID)
+}
+
+// SessionState 访问令牌所属会话的状态
+type SessionState struct {
+	Active             bool
+	MustChangePassword bool // 用户需要先修改密码才能继续使用
+	MFASetupRequired   bool // 策略要求启用两步验证但用户尚未启用
+}
+
+// ValidateSession 检查访问令牌所属会话是否仍然有效，并更新最近使用时间
+// 用户被禁用或删除后其会话立即视为无效，不必等待访问令牌过期
+func (s *SessionService) ValidateSession(userID uint, sessionID string) (*SessionState, error) {
+	if sessionID == "" {
+		return &SessionState{}, nil
+	}
+
+	var row struct {
+		ID                 uint
+		LastSeenAt         time.Time
+		MustChangePassword bool
+		SystemRole         models.SystemRole
+		TOTPEnabled        bool `gorm:"column:totp_enabled"`
+	}
+	result := s.db.Table("sessions").
+		Select("sessions.id, sessions.last_seen_at, users.must_change_password, users.system_role, users.totp_enabled").
+		Joins("JOIN users ON users.id = sessions.user_id AND users.deleted_at IS NULL AND users.status = ?", models.UserStatusEnabled).
+		Where("sessions.family_id = ? AND sessions.user_id = ? AND sessions.rotated_at IS NULL AND sessions.revoked_at IS NULL", sessionID, userID).
+		Limit(1).
+		Scan(&row)
+	if result.Error != nil {
+		return nil, fmt.Errorf("查询会话失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return &SessionState{}, nil
+	}
+
+	now := time.Now()
+	if now.Sub(row.LastSeenAt) > lastSeenInterval {
+		if err := s.db.Model(&models.Session{}).Where("id = ?", row.ID).UpdateColumn("last_seen_at", now).Error; err != nil {
+			return nil, fmt.Errorf("更新会话失败: %v", err)
+		}
+	}
+	return &SessionState{
+		Active:             true,
+		MustChangePassword: row.MustChangePassword,
+		MFASetupRequired:   !row.TOTPEnabled && mfaRequired(s.cfg, &models.User{SystemRole: row.SystemRole}),
+	}, nil
+}
+
+// ListSessions 获取用户当前有效的登录会话，currentID 为发起请求的会话
+func (s *SessionService) ListSessions(userID uint, currentID string) ([]dto.SessionResponse, error) {
+	var sessions []models.Session
+	err := s.db.Where("user_id = ? AND rotated_at IS NULL AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询会话列表失败: %v", err)
+	}
+
+	result := make([]dto.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, dto.SessionResponse{
+			ID:           session.FamilyID,
+			IP:           session.IP,
+			UserAgent:    session.UserAgent,
+			CreatedAt:    session.LoginAt,
+			LastSeenAt:   session.LastSeenAt,
+			ExpiresAt:    session.ExpiresAt,
+			Current:      session.FamilyID == currentID,
+			Impersonated: session.ImpersonatorID != nil,
+		})
+	}
+	return result, nil
+}
+
+// RevokeSession 吊销用户的指定会话
+func (s *SessionService) RevokeSession(userID uint, sessionID string) error {
+	if _, err := s.activeSession(userID, sessionID); err != nil {
+		return err
+	}
+	return s.revokeFamily(sessionID)
+}
+
+// RevokeOtherSessions 吊销用户除当前会话外的所有会话
+func (s *SessionService) RevokeOtherSessions(userID uint, currentID string) error {
+	return revokeUserSessions(s.db, userID, currentID)
+}
+
+// activeSession 查询会话族中当前有效的记录
+func (s *SessionService) activeSession(userID uint, sessionID string) (*models.Session, error) {
+	var session models.Session
+	err := s.db.Where("family_id = ? AND user_id = ? AND rotated_at IS NULL AND revoked_at IS NULL", sessionID, userID).
+		First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("查询会话失败: %v", err)
+	}
+	return &session, nil
+}
+
+// issue 在会话族中签发新的刷新令牌记录和访问令牌
+func (s *SessionService) issue(tx *gorm.DB, user *models.User, familyID string, loginAt time.Time, client ClientInfo) (*TokenPair, error) {
+	refreshToken, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &models.Session{
+		FamilyID:   familyID,
+		UserID:     user.ID,
+		TokenHash:  utils.HashToken(refreshToken),
+		IP:         client.IP,
+		UserAgent:  truncate(client.UserAgent, 255),
+		LoginAt:    loginAt,
+		LastSeenAt: time.Now(),
+		ExpiresAt:  time.Now().AddDate(0, 0, s.cfg.JWT.RefreshExpireDays),
+	}
+	if err := tx.Create(session).Error; err != nil {
+		return nil, fmt.Errorf("创建会话失败: %v", err)
+	}
+
+	accessToken, err := utils.GenerateToken(user.ID, user.Username, familyID, s.cfg)
+	if err != nil {
+		return nil, ErrGenerateToken
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    s.cfg.JWT.AccessExpireMinutes * 60,
+	}, nil
+}
+
+// findByToken 根据刷新令牌查询会话记录
+func (s *SessionService) findByToken(refreshToken string) (*models.Session, error) {
+	if refreshToken == "" {
+		return nil, ErrInvalidRefresh
+	}
+	var session models.Session
+	if err := s.db.Where("token_hash = ?", utils.HashToken(refreshToken)).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidRefresh
+		}
+		return nil, fmt.Errorf("查询会话失败: %v", err)
+	}
+	return &session, nil
+}
+
+// revokeFamily 吊销会话族下的所有刷新令牌
+func (s *SessionService) revokeFamily(familyID string) error {
+	err := s.db.Model(&models.Session{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("吊销会话失败: %v", err)
+	}
+	return nil
+}
+
+// revokeUserSessions 吊销用户的所有会话，exceptID 不为空时保留该会话
+// 修改密码等操作在自身事务中调用，保证密码与会话状态一致
+func revokeUserSessions(tx *gorm.DB, userID uint, exceptID string) error {
+	query := tx.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL", userID)
+	if exceptID != "" {
+		query = query.Where("family_id <> ?", exceptID)
+	}
+	if err := query.Update("revoked_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("吊销会话失败: %v", err)
+	}
+	return nil
+}
+
+// truncate 按字节截断字符串，避免超出字段长度（保证不截断多字节字符）
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && (s[n]&0xC0) == 0x80 {
+		n--
+	}
+	return s[:n]
+}
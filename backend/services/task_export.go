@@ -0,0 +1,179 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"progress-wall-backend/models"
+)
+
+// taskStatusNames 导出时显示的任务状态
+var taskStatusNames = map[models.TaskStatus]string{
+	models.TaskStatusTodo:       "待办",
+	models.TaskStatusInProgress: "进行中",
+	models.TaskStatusCompleted:  "已完成",
+	models.TaskStatusCancelled:  "已取消",
+	models.TaskStatusArchived:   "已归档",
+}
+
+// taskPriorityNames 导出时显示的任务优先级
+var taskPriorityNames = map[models.TaskPriority]string{
+	models.TaskPriorityLow:    "低",
+	models.TaskPriorityMedium: "中",
+	models.TaskPriorityHigh:   "高",
+	models.TaskPriorityUrgent: "紧急",
+}
+
+// ExportTasksCSV 按筛选条件导出项目的全部任务，每个自定义字段一列；时间按 userID 的偏好时区和日期格式显示
+// 返回文件名和 CSV 内容（带 UTF-8 BOM，便于 Excel 识别编码）
+func (s *TaskService) ExportTasksCSV(projectRef string, filter TaskFilter, userID uint) (string, []byte, error) {
+	project, err := s.findProject(projectRef)
+	if err != nil {
+		return "", nil, err
+	}
+	db, err := s.filterTasks(project, filter)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var tasks []models.Task
+	if err := db.Preload("Assignees").
+		Preload("Column").
+		Preload("CustomFields").
+		Order("number ASC").
+		Find(&tasks).Error; err != nil {
+		return "", nil, fmt.Errorf("查询任务失败: %v", err)
+	}
+	fields, err := projectCustomFields(s.db, project.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	usernames, err := s.customFieldUsernames(tasks, fields)
+	if err != nil {
+		return "", nil, err
+	}
+	prefs, err := loadPreferences(s.db, s.cfg, userID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("\xEF\xBB\xBF")
+	w := csv.NewWriter(&buf)
+
+	header := []string{"编号", "标题", "状态", "优先级", "列", "负责人", "截止时间", "创建时间"}
+	for _, field := range fields {
+		header = append(header, csvSafe(field.Name))
+	}
+	if err := w.Write(header); err != nil {
+		return "", nil, fmt.Errorf("生成 CSV 失败: %v", err)
+	}
+
+	for _, task := range tasks {
+		assignees := make([]string, len(task.Assignees))
+		for i, user := range task.Assignees {
+			assignees[i] = csvSafe(user.Username)
+		}
+		var dueDate string
+		if task.DueDate != nil {
+			dueDate = prefs.FormatTime(*task.DueDate)
+		}
+		record := []string{
+			models.TaskKey(project.Key, task.Number),
+			csvSafe(task.Title),
+			taskStatusNames[task.Status],
+			taskPriorityNames[task.Priority],
+			csvSafe(task.Column.Name),
+			strings.Join(assignees, ", "),
+			dueDate,
+			prefs.FormatTime(task.CreatedAt),
+		}
+
+		values := make(map[uint]interface{}, len(task.CustomFields))
+		for _, value := range task.CustomFields {
+			values[value.FieldID] = value.Value
+		}
+		for i := range fields {
+			record = append(record, formatFieldValue(&fields[i], values[fields[i].ID], usernames))
+		}
+		if err := w.Write(record); err != nil {
+			return "", nil, fmt.Errorf("生成 CSV 失败: %v", err)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", nil, fmt.Errorf("生成 CSV 失败: %v", err)
+	}
+	return fmt.Sprintf("%s-tasks.csv", project.Key), buf.Bytes(), nil
+}
+
+// customFieldUsernames 查询用户类型字段中引用的用户名
+func (s *TaskService) customFieldUsernames(tasks []models.Task, fields []models.CustomField) (map[uint]string, error) {
+	userFields := make(map[uint]bool)
+	for _, field := range fields {
+		if field.Type == models.CustomFieldUser {
+			userFields[field.ID] = true
+		}
+	}
+
+	var userIDs []uint
+	for _, task := range tasks {
+		for _, value := range task.CustomFields {
+			if id, ok := value.Value.(float64); ok && userFields[value.FieldID] {
+				userIDs = append(userIDs, uint(id))
+			}
+		}
+	}
+	usernames := make(map[uint]string)
+	if len(userIDs) == 0 {
+		return usernames, nil
+	}
+
+	var users []models.User
+	if err := s.db.Unscoped().Select("id", "username").Where("id IN ?", uniqueIDs(userIDs)).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("查询用户失败: %v", err)
+	}
+	for _, user := range users {
+		usernames[user.ID] = user.Username
+	}
+	return usernames, nil
+}
+
+// formatFieldValue 将字段值转换为 CSV 中显示的文本，多选项以分号分隔
+func formatFieldValue(field *models.CustomField, value interface{}, usernames map[uint]string) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return csvSafe(v)
+	case bool:
+		if v {
+			return "是"
+		}
+		return "否"
+	case float64:
+		if field.Type == models.CustomFieldUser {
+			return csvSafe(usernames[uint(v)])
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		options := make([]string, 0, len(v))
+		for _, option := range v {
+			options = append(options, csvSafe(fmt.Sprint(option)))
+		}
+		return strings.Join(options, "; ")
+	}
+	return csvSafe(fmt.Sprint(value))
+}
+
+// csvSafe 以 = + - @ 制表符或回车开头的文本前加单引号，避免在电子表格中被当作公式执行
+func csvSafe(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
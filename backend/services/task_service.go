@@ -1,8 +1,16 @@
 package services
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"progress-wall-backend/config"
+	"progress-wall-backend/dto"
 	"progress-wall-backend/models"
 
 	"gorm.io/gorm"
@@ -10,13 +18,17 @@ import (
 
 // TaskService 任务服务
 type TaskService struct {
-	db *gorm.DB
+	db       *gorm.DB
+	cfg      *config.Config
+	notifier *Notifier
 }
 
 // NewTaskService 创建任务服务
-func NewTaskService(db *gorm.DB) *TaskService {
+func NewTaskService(db *gorm.DB, cfg *config.Config) *TaskService {
 	return &TaskService{
-		db: db,
+		db:       db,
+		cfg:      cfg,
+		notifier: NewNotifier(db, cfg),
 	}
 }
 
@@ -24,10 +36,19 @@ func NewTaskService(db *gorm.DB) *TaskService {
 func (s *TaskService) GetTaskByID(taskID uint) (*models.Task, error) {
 	var task models.Task
 	result := s.db.
-		Preload("Assignee").
+		Preload("Assignees").
+		Preload("Watchers").
 		Preload("Creator").
 		Preload("Column").
 		Preload("Labels").
+		Preload("Checklist", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Preload("Checklist.Assignee").
+		Preload("Subtasks", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id ASC")
+		}).
+		Preload("CustomFields").
 		First(&task, taskID)
 
 	if result.Error != nil {
@@ -37,9 +58,50 @@ func (s *TaskService) GetTaskByID(taskID uint) (*models.Task, error) {
 		return nil, errors.New("查询任务失败")
 	}
 
+	tasks := []*models.Task{&task}
+	for i := range task.Subtasks {
+		tasks = append(tasks, &task.Subtasks[i])
+	}
+	if err := fillTaskProgress(s.db, tasks); err != nil {
+		return nil, err
+	}
+	if err := fillTaskKeys(s.db, tasks); err != nil {
+		return nil, err
+	}
+
 	return &task, nil
 }
 
+// GetSubtasks 获取任务的直接子任务
+func (s *TaskService) GetSubtasks(taskID uint) ([]models.Task, error) {
+	if err := s.db.Select("id").First(&models.Task{}, taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, fmt.Errorf("查询任务失败: %v", err)
+	}
+
+	var subtasks []models.Task
+	if err := s.db.Where("parent_task_id = ?", taskID).
+		Preload("Assignees").
+		Order("id ASC").
+		Find(&subtasks).Error; err != nil {
+		return nil, fmt.Errorf("查询子任务失败: %v", err)
+	}
+
+	tasks := make([]*models.Task, len(subtasks))
+	for i := range subtasks {
+		tasks[i] = &subtasks[i]
+	}
+	if err := fillTaskProgress(s.db, tasks); err != nil {
+		return nil, err
+	}
+	if err := fillTaskKeys(s.db, tasks); err != nil {
+		return nil, err
+	}
+	return subtasks, nil
+}
+
 // GetTasksByColumnID 获取列的所有任务
 func (s *TaskService) GetTasksByColumnID(columnID uint) ([]models.Task, error) {
 	var tasks []models.Task
@@ -52,51 +114,488 @@ func (s *TaskService) GetTasksByColumnID(columnID uint) ([]models.Task, error) {
 		return nil, errors.New("查询任务列表失败")
 	}
 
+	refs := make([]*models.Task, len(tasks))
+	for i := range tasks {
+		refs[i] = &tasks[i]
+	}
+	if err := fillTaskKeys(s.db, refs); err != nil {
+		return nil, err
+	}
 	return tasks, nil
 }
 
-// CreateTask 创建任务
-func (s *TaskService) CreateTask(task *models.Task) error {
-	// 获取当前列的最大position
-	var maxPosition int
-	s.db.Model(&models.Task{}).
-		Where("column_id = ?", task.ColumnID).
-		Select("COALESCE(MAX(position), -1)").
-		Scan(&maxPosition)
-	task.Position = maxPosition + 1
+// GetTaskByNumber 根据项目（ID 或 key）和项目内编号获取任务，如 WEB 和 123
+func (s *TaskService) GetTaskByNumber(projectRef string, number uint) (*models.Task, error) {
+	project, err := s.findProject(projectRef)
+	if err != nil {
+		return nil, err
+	}
+
+	var task models.Task
+	if err := s.db.Select("id").Where("project_id = ? AND number = ?", project.ID, number).First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, fmt.Errorf("查询任务失败: %v", err)
+	}
+	return s.GetTaskByID(task.ID)
+}
+
+// taskSearchLimit 搜索结果的最大条数
+const taskSearchLimit = 50
 
-	if err := s.db.Create(task).Error; err != nil {
-		return fmt.Errorf("创建任务失败: %v", err)
+// TaskFilter 项目内任务的筛选条件
+type TaskFilter struct {
+	Query        string          // 标题关键字或任务编号（WEB-123、#123 或 123）
+	CustomFields map[uint]string // 自定义字段ID到筛选值
+}
+
+// SearchTasks 在项目内搜索任务，按标题模糊匹配；查询词是任务编号时同时按编号匹配，并按自定义字段筛选
+func (s *TaskService) SearchTasks(projectRef string, filter TaskFilter) ([]models.Task, error) {
+	project, err := s.findProject(projectRef)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	db, err := s.filterTasks(project, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []models.Task
+	if err := db.Preload("Assignees").
+		Preload("Column").
+		Preload("CustomFields").
+		Order("number DESC").
+		Limit(taskSearchLimit).
+		Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("搜索任务失败: %v", err)
+	}
+
+	refs := make([]*models.Task, len(tasks))
+	for i := range tasks {
+		refs[i] = &tasks[i]
+	}
+	if err := fillTaskKeys(s.db, refs); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// filterTasks 构造项目内按条件筛选任务的查询
+func (s *TaskService) filterTasks(project *models.Project, filter TaskFilter) (*gorm.DB, error) {
+	db := s.db.Where("project_id = ?", project.ID)
+	if query := strings.TrimSpace(filter.Query); query != "" {
+		match := s.db.Where("LOWER(title) LIKE ? ESCAPE '!'", "%"+escapeLike(strings.ToLower(query))+"%")
+		if number, ok := parseTaskNumber(query, project.Key); ok {
+			match = match.Or("number = ?", number)
+		}
+		db = db.Where(match)
+	}
+	return applyCustomFieldFilters(db, project.ID, filter.CustomFields)
 }
 
-// UpdateTask 更新任务
-func (s *TaskService) UpdateTask(taskID uint, updates map[string]interface{}) error {
-	result := s.db.Model(&models.Task{}).Where("id = ?", taskID).Updates(updates)
+// findProject 根据项目ID或 key（不区分大小写）查询项目
+func (s *TaskService) findProject(ref string) (*models.Project, error) {
+	query := s.db.Select("id", "project_key")
+	if id, err := strconv.ParseUint(ref, 10, 32); err == nil {
+		query = query.Where("id = ?", id)
+	} else {
+		query = query.Where("project_key = ?", strings.ToUpper(ref))
+	}
+
+	var project models.Project
+	if err := query.First(&project).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, fmt.Errorf("查询项目失败: %v", err)
+	}
+	return &project, nil
+}
+
+// parseTaskNumber 从查询词中解析项目内编号，WEB-123 形式的 key 必须是当前项目的
+func parseTaskNumber(query, projectKey string) (uint, bool) {
+	query = strings.ToUpper(query)
+	if i := strings.LastIndex(query, "-"); i > 0 {
+		if query[:i] != projectKey {
+			return 0, false
+		}
+		query = query[i+1:]
+	} else {
+		query = strings.TrimPrefix(query, "#")
+	}
+	number, err := strconv.ParseUint(query, 10, 32)
+	if err != nil || number == 0 {
+		return 0, false
+	}
+	return uint(number), true
+}
+
+// CreateTask 创建任务，所属项目由列所在的看板决定，task.ProjectID 非0时必须与之一致；
+// 设置了 ParentTaskID 时父任务必须属于同一项目；负责人必须是项目成员；
+// customFields 为自定义字段ID到值的映射，项目的必填字段都必须填写
+func (s *TaskService) CreateTask(task *models.Task, assigneeIDs []uint, customFields map[uint]json.RawMessage) error {
+	assigneeIDs = uniqueIDs(assigneeIDs)
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var projectIDs []uint
+		if err := tx.Model(&models.Column{}).
+			Joins("JOIN boards ON boards.id = columns.board_id AND boards.deleted_at IS NULL").
+			Where("columns.id = ?", task.ColumnID).
+			Pluck("boards.project_id", &projectIDs).Error; err != nil {
+			return fmt.Errorf("查询列失败: %v", err)
+		}
+		if len(projectIDs) == 0 {
+			return ErrColumnNotFound
+		}
+		if task.ProjectID != 0 && task.ProjectID != projectIDs[0] {
+			return ErrColumnProjectMismatch
+		}
+		task.ProjectID = projectIDs[0]
+
+		if task.ParentTaskID != nil {
+			if _, err := s.loadParentTask(tx, *task.ParentTaskID, task.ProjectID); err != nil {
+				return err
+			}
+		}
+		for _, userID := range assigneeIDs {
+			if err := s.checkProjectMember(userID, task.ProjectID); err != nil {
+				return err
+			}
+		}
+
+		// 获取当前列的最大position
+		var maxPosition int
+		tx.Model(&models.Task{}).
+			Where("column_id = ?", task.ColumnID).
+			Select("COALESCE(MAX(position), -1)").
+			Scan(&maxPosition)
+		task.Position = maxPosition + 1
+
+		// 先递增项目的任务计数器再读回：UPDATE 在 MySQL 中锁住项目行、在 SQLite 中锁住整个库，
+		// 并发创建的任务在事务提交前依次等待，不会取得相同的编号
+		result := tx.Model(&models.Project{}).Where("id = ?", task.ProjectID).
+			UpdateColumn("task_seq", gorm.Expr("task_seq + 1"))
+		if result.Error != nil {
+			return fmt.Errorf("分配任务编号失败: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrProjectNotFound
+		}
+		var project models.Project
+		if err := tx.Select("project_key", "task_seq").First(&project, task.ProjectID).Error; err != nil {
+			return fmt.Errorf("分配任务编号失败: %v", err)
+		}
+		task.Number = project.TaskSeq
+		task.Key = models.TaskKey(project.Key, project.TaskSeq)
+
+		if err := tx.Create(task).Error; err != nil {
+			return fmt.Errorf("创建任务失败: %v", err)
+		}
+		for _, userID := range assigneeIDs {
+			if err := tx.Create(&models.TaskAssignee{TaskID: task.ID, UserID: userID}).Error; err != nil {
+				return fmt.Errorf("添加任务负责人失败: %v", err)
+			}
+		}
+		if _, err := setTaskCustomFields(tx, task, customFields, true); err != nil {
+			return err
+		}
+		if err := tx.Where("task_id = ?", task.ID).Find(&task.CustomFields).Error; err != nil {
+			return fmt.Errorf("查询自定义字段值失败: %v", err)
+		}
+		if len(assigneeIDs) > 0 {
+			return tx.Where("id IN ?", assigneeIDs).Find(&task.Assignees).Error
+		}
+		return nil
+	})
+}
+
+// TaskUpdateInput 任务更新内容，为 nil 的部分保持不变
+type TaskUpdateInput struct {
+	Fields       map[string]interface{}   // tasks 表中的普通字段
+	ParentTaskID *uint                    // 为0时解除父子关系
+	AssigneeIDs  *[]uint                  // 替换全部负责人
+	CustomFields map[uint]json.RawMessage // 只修改出现的自定义字段，值为 null 时清空
+}
+
+// UpdateTask 在同一事务中更新任务字段、父任务、负责人和自定义字段，任一部分校验失败时都不会写入
+func (s *TaskService) UpdateTask(taskID uint, input TaskUpdateInput, actor Actor) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if input.ParentTaskID != nil {
+			if err := s.setParentTask(tx, taskID, input.ParentTaskID); err != nil {
+				return err
+			}
+		}
+		if input.AssigneeIDs != nil {
+			if err := s.setAssignees(tx, taskID, *input.AssigneeIDs, actor); err != nil {
+				return err
+			}
+		}
+		if input.CustomFields != nil {
+			if err := s.setCustomFields(tx, taskID, input.CustomFields, actor); err != nil {
+				return err
+			}
+		}
+		if len(input.Fields) == 0 && (input.ParentTaskID != nil || input.AssigneeIDs != nil || input.CustomFields != nil) {
+			return nil
+		}
+		return s.updateTaskFields(tx, taskID, input.Fields)
+	})
+}
+
+// updateTaskFields 更新任务的普通字段，状态或自动完成变化时同步父任务的完成状态
+func (s *TaskService) updateTaskFields(tx *gorm.DB, taskID uint, updates map[string]interface{}) error {
+	result := tx.Model(&models.Task{}).Where("id = ?", taskID).Updates(updates)
 	if result.Error != nil {
 		return fmt.Errorf("更新任务失败: %v", result.Error)
 	}
 	if result.RowsAffected == 0 {
 		return ErrTaskNotFound
 	}
-	return nil
+
+	_, statusChanged := updates["status"]
+	_, autoCompleteChanged := updates["auto_complete"]
+	if !statusChanged && !autoCompleteChanged {
+		return nil
+	}
+
+	var task models.Task
+	if err := tx.Select("id", "parent_task_id").First(&task, taskID).Error; err != nil {
+		return fmt.Errorf("查询任务失败: %v", err)
+	}
+	// 开启自动完成时检查任务自身；状态变化时检查父任务，两者可能同时发生
+	if autoCompleteChanged {
+		if err := s.syncParentCompletion(tx, &task.ID); err != nil {
+			return err
+		}
+	}
+	return s.syncParentCompletion(tx, task.ParentTaskID)
 }
 
-// DeleteTask 删除任务（软删除）
+// DeleteTask 删除任务（软删除），子任务保留并解除父子关系
 func (s *TaskService) DeleteTask(taskID uint) error {
-	result := s.db.Delete(&models.Task{}, taskID)
-	if result.Error != nil {
-		return fmt.Errorf("删除任务失败: %v", result.Error)
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var task models.Task
+		if err := tx.Select("id", "parent_task_id").First(&task, taskID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTaskNotFound
+			}
+			return fmt.Errorf("查询任务失败: %v", err)
+		}
+
+		if err := tx.Delete(&task).Error; err != nil {
+			return fmt.Errorf("删除任务失败: %v", err)
+		}
+		if err := tx.Model(&models.Task{}).
+			Where("parent_task_id = ?", taskID).
+			Update("parent_task_id", nil).Error; err != nil {
+			return fmt.Errorf("解除子任务关系失败: %v", err)
+		}
+		if err := tx.Where("task_id = ?", taskID).Delete(&models.ChecklistItem{}).Error; err != nil {
+			return fmt.Errorf("删除检查项失败: %v", err)
+		}
+		if err := tx.Where("source_task_id = ? OR target_task_id = ?", taskID, taskID).Delete(&models.TaskLink{}).Error; err != nil {
+			return fmt.Errorf("删除任务关联失败: %v", err)
+		}
+		if err := tx.Where("task_id = ?", taskID).Delete(&models.TaskAssignee{}).Error; err != nil {
+			return fmt.Errorf("删除任务负责人失败: %v", err)
+		}
+		if err := tx.Where("task_id = ?", taskID).Delete(&models.TaskWatcher{}).Error; err != nil {
+			return fmt.Errorf("删除任务关注者失败: %v", err)
+		}
+		if err := tx.Where("task_id = ?", taskID).Delete(&models.CustomFieldValue{}).Error; err != nil {
+			return fmt.Errorf("删除自定义字段值失败: %v", err)
+		}
+		// 删除未完成的子任务后，其余子任务可能已全部完成
+		return s.syncParentCompletion(tx, task.ParentTaskID)
+	})
+}
+
+// setParentTask 设置或清除（parentID 为 nil 或0）任务的父任务
+func (s *TaskService) setParentTask(tx *gorm.DB, taskID uint, parentID *uint) error {
+	var task models.Task
+	if err := tx.Select("id", "project_id", "parent_task_id").First(&task, taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTaskNotFound
+		}
+		return fmt.Errorf("查询任务失败: %v", err)
 	}
-	if result.RowsAffected == 0 {
-		return ErrTaskNotFound
+
+	if parentID != nil && *parentID == 0 {
+		parentID = nil
+	}
+	if parentID != nil {
+		if *parentID == taskID {
+			return ErrSubtaskCycle
+		}
+		parent, err := s.loadParentTask(tx, *parentID, task.ProjectID)
+		if err != nil {
+			return err
+		}
+		// 父任务的祖先中不能出现当前任务
+		visited := map[uint]bool{parent.ID: true}
+		for ancestorID := parent.ParentTaskID; ancestorID != nil && !visited[*ancestorID]; {
+			if *ancestorID == taskID {
+				return ErrSubtaskCycle
+			}
+			visited[*ancestorID] = true
+			var ancestor models.Task
+			if err := tx.Select("id", "parent_task_id").First(&ancestor, *ancestorID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					break
+				}
+				return fmt.Errorf("查询父任务失败: %v", err)
+			}
+			ancestorID = ancestor.ParentTaskID
+		}
+	}
+
+	if err := tx.Model(&models.Task{}).Where("id = ?", taskID).Update("parent_task_id", parentID).Error; err != nil {
+		return fmt.Errorf("更新父任务失败: %v", err)
+	}
+	// 移出子任务后原父任务的其余子任务可能已全部完成
+	if err := s.syncParentCompletion(tx, task.ParentTaskID); err != nil {
+		return err
+	}
+	return s.syncParentCompletion(tx, parentID)
+}
+
+// loadParentTask 查询父任务并确认与子任务属于同一项目
+func (s *TaskService) loadParentTask(tx *gorm.DB, parentID, projectID uint) (*models.Task, error) {
+	var parent models.Task
+	if err := tx.Select("id", "project_id", "parent_task_id").First(&parent, parentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrParentTaskNotFound
+		}
+		return nil, fmt.Errorf("查询父任务失败: %v", err)
+	}
+	if parent.ProjectID != projectID {
+		return nil, ErrParentTaskMismatch
+	}
+	return &parent, nil
+}
+
+// syncParentCompletion 父任务开启了自动完成且子任务（已取消的除外）全部完成时，将父任务标记为已完成，并逐级向上检查
+func (s *TaskService) syncParentCompletion(tx *gorm.DB, parentID *uint) error {
+	visited := make(map[uint]bool)
+	for parentID != nil && !visited[*parentID] {
+		visited[*parentID] = true
+
+		var parent models.Task
+		if err := tx.Select("id", "status", "auto_complete", "parent_task_id").First(&parent, *parentID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return fmt.Errorf("查询父任务失败: %v", err)
+		}
+		if !parent.AutoComplete || taskClosed(parent.Status) {
+			return nil
+		}
+
+		progress := &models.Task{ID: parent.ID}
+		if err := fillTaskProgress(tx, []*models.Task{progress}); err != nil {
+			return err
+		}
+		if progress.SubtaskTotal == 0 || progress.SubtaskDone < progress.SubtaskTotal {
+			return nil
+		}
+
+		if err := tx.Model(&models.Task{}).Where("id = ?", parent.ID).
+			Update("status", models.TaskStatusCompleted).Error; err != nil {
+			return fmt.Errorf("更新父任务状态失败: %v", err)
+		}
+		parentID = parent.ParentTaskID
+	}
+	return nil
+}
+
+// taskClosed 任务是否已完成、已取消或已归档
+func taskClosed(status models.TaskStatus) bool {
+	return status == models.TaskStatusCompleted ||
+		status == models.TaskStatusCancelled ||
+		status == models.TaskStatusArchived
+}
+
+// fillTaskProgress 统计任务的检查项和子任务完成情况（已取消的子任务不计入）
+func fillTaskProgress(db *gorm.DB, tasks []*models.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	taskIDs := make([]uint, len(tasks))
+	for i, task := range tasks {
+		taskIDs[i] = task.ID
+	}
+
+	type progress struct {
+		TaskID uint
+		Total  int
+		Done   int
+	}
+
+	var checklist []progress
+	if err := db.Model(&models.ChecklistItem{}).
+		Select("task_id, COUNT(*) AS total, SUM(CASE WHEN done THEN 1 ELSE 0 END) AS done").
+		Where("task_id IN ?", taskIDs).
+		Group("task_id").
+		Scan(&checklist).Error; err != nil {
+		return fmt.Errorf("统计检查项失败: %v", err)
+	}
+
+	var subtasks []progress
+	if err := db.Model(&models.Task{}).
+		Select("parent_task_id AS task_id, COUNT(*) AS total, SUM(CASE WHEN status IN ? THEN 1 ELSE 0 END) AS done",
+			[]models.TaskStatus{models.TaskStatusCompleted, models.TaskStatusArchived}).
+		Where("parent_task_id IN ? AND status <> ?", taskIDs, models.TaskStatusCancelled).
+		Group("parent_task_id").
+		Scan(&subtasks).Error; err != nil {
+		return fmt.Errorf("统计子任务失败: %v", err)
+	}
+
+	checklistByTask := make(map[uint]progress, len(checklist))
+	for _, p := range checklist {
+		checklistByTask[p.TaskID] = p
+	}
+	subtasksByTask := make(map[uint]progress, len(subtasks))
+	for _, p := range subtasks {
+		subtasksByTask[p.TaskID] = p
+	}
+	for _, task := range tasks {
+		task.ChecklistTotal = checklistByTask[task.ID].Total
+		task.ChecklistDone = checklistByTask[task.ID].Done
+		task.SubtaskTotal = subtasksByTask[task.ID].Total
+		task.SubtaskDone = subtasksByTask[task.ID].Done
+	}
+	return nil
+}
+
+// fillTaskKeys 根据任务所属项目的 key 填充任务编号
+func fillTaskKeys(db *gorm.DB, tasks []*models.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	projectIDs := make([]uint, len(tasks))
+	for i, task := range tasks {
+		projectIDs[i] = task.ProjectID
+	}
+
+	var projects []models.Project
+	if err := db.Unscoped().Select("id", "project_key").
+		Where("id IN ?", uniqueIDs(projectIDs)).
+		Find(&projects).Error; err != nil {
+		return fmt.Errorf("查询项目 key 失败: %v", err)
+	}
+	keys := make(map[uint]string, len(projects))
+	for _, project := range projects {
+		keys[project.ID] = project.Key
+	}
+	for _, task := range tasks {
+		task.Key = models.TaskKey(keys[task.ProjectID], task.Number)
 	}
 	return nil
 }
 
 // MoveTask 移动任务到新列和新位置
-func (s *TaskService) MoveTask(taskID uint, newColumnID uint, newOrder int, userId uint, userName string) error {
+// 被未完成任务阻塞的任务移入完成列时，按配置拒绝（返回阻塞任务和 ErrTaskBlocked）或照常移动并返回阻塞任务作为警告
+func (s *TaskService) MoveTask(taskID uint, newColumnID uint, newOrder int, actor Actor) ([]dto.TaskLinkResponse, error) {
 	tx := s.db.Begin()
 	defer tx.Rollback()
 
@@ -110,15 +609,15 @@ func (s *TaskService) MoveTask(taskID uint, newColumnID uint, newOrder int, user
 	var task models.Task
 	if err := tx.Preload("Column").First(&task, taskID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return ErrTaskNotFound
+			return nil, ErrTaskNotFound
 		}
-		return fmt.Errorf("查询任务失败: %v", err)
+		return nil, fmt.Errorf("查询任务失败: %v", err)
 	}
 
 	// 获取看板ID（通过Column）
 	var column models.Column
 	if err := tx.First(&column, task.ColumnID).Error; err != nil {
-		return fmt.Errorf("查询列失败: %v", err)
+		return nil, fmt.Errorf("查询列失败: %v", err)
 	}
 	boardID := column.BoardID
 
@@ -129,24 +628,37 @@ func (s *TaskService) MoveTask(taskID uint, newColumnID uint, newOrder int, user
 	// 获取新列名称
 	var newColumn models.Column
 	if err := tx.First(&newColumn, newColumnID).Error; err != nil {
-		return fmt.Errorf("查询新列失败: %v", err)
+		return nil, fmt.Errorf("查询新列失败: %v", err)
 	}
 	newColumnName := newColumn.Name
 
+	// 移入完成列时检查阻塞任务
+	var blockers []dto.TaskLinkResponse
+	if newColumn.IsDone && oldColumnID != newColumnID {
+		var err error
+		blockers, err = s.openBlockers(tx, task.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(blockers) > 0 && s.cfg.Task.BlockedMovePolicy != config.BlockedMoveWarn {
+			return blockers, ErrTaskBlocked
+		}
+	}
+
 	// 如果移动到不同列，需要更新两个列中的任务位置
 	if oldColumnID != newColumnID {
 		// 从旧列中移除：将旧列中位置大于当前任务位置的所有任务位置减1
 		if err := tx.Model(&models.Task{}).
 			Where("column_id = ? AND position > ?", oldColumnID, oldPosition).
 			Update("position", gorm.Expr("position - 1")).Error; err != nil {
-			return fmt.Errorf("更新旧列任务位置失败: %v", err)
+			return nil, fmt.Errorf("更新旧列任务位置失败: %v", err)
 		}
 
 		// 在新列中插入：将新列中位置大于等于newOrder的所有任务位置加1
 		if err := tx.Model(&models.Task{}).
 			Where("column_id = ? AND position >= ?", newColumnID, newOrder).
 			Update("position", gorm.Expr("position + 1")).Error; err != nil {
-			return fmt.Errorf("更新新列任务位置失败: %v", err)
+			return nil, fmt.Errorf("更新新列任务位置失败: %v", err)
 		}
 
 		// 更新任务的列ID和位置
@@ -155,23 +667,27 @@ func (s *TaskService) MoveTask(taskID uint, newColumnID uint, newOrder int, user
 				"column_id": newColumnID,
 				"position":  newOrder,
 			}).Error; err != nil {
-			return fmt.Errorf("更新任务位置失败: %v", err)
+			return nil, fmt.Errorf("更新任务位置失败: %v", err)
 		}
 
 		// 记录跨列移动日志
+		if err := fillTaskKeys(tx, []*models.Task{&task}); err != nil {
+			return nil, err
+		}
 		log := models.ActivityLog{
-			UserID:      userId,
-			Username:    userName,
-			ActionType:  models.ActionMove,
-			EntityType:  models.EntityTask,
-			EntityID:    task.ID,
-			BoardID:     &boardID,
-			TaskID:      &task.ID,
-			ProjectID:   &task.ProjectID,
-			Description: fmt.Sprintf("moved this task from \"%s\" to \"%s\"", oldColumnName, newColumnName),
+			UserID:         actor.ID,
+			Username:       actor.Name,
+			ImpersonatorID: actor.ImpersonatorID,
+			ActionType:     models.ActionMove,
+			EntityType:     models.EntityTask,
+			EntityID:       task.ID,
+			BoardID:        &boardID,
+			TaskID:         &task.ID,
+			ProjectID:      &task.ProjectID,
+			Description:    fmt.Sprintf("moved %s from \"%s\" to \"%s\"", task.Key, oldColumnName, newColumnName),
 		}
 		if err := s.createActivityLog(tx, &log); err != nil {
-			return fmt.Errorf("创建活动日志失败: %v", err)
+			return nil, fmt.Errorf("创建活动日志失败: %v", err)
 		}
 
 	} else {
@@ -181,20 +697,20 @@ func (s *TaskService) MoveTask(taskID uint, newColumnID uint, newOrder int, user
 			if err := tx.Model(&models.Task{}).
 				Where("column_id = ? AND position > ? AND position <= ?", newColumnID, oldPosition, newOrder).
 				Update("position", gorm.Expr("position - 1")).Error; err != nil {
-				return fmt.Errorf("更新任务位置失败: %v", err)
+				return nil, fmt.Errorf("更新任务位置失败: %v", err)
 			}
 		} else if oldPosition > newOrder {
 			// 向前移动：将位置在 [newOrder, oldPosition) 之间的任务位置加1
 			if err := tx.Model(&models.Task{}).
 				Where("column_id = ? AND position >= ? AND position < ?", newColumnID, newOrder, oldPosition).
 				Update("position", gorm.Expr("position + 1")).Error; err != nil {
-				return fmt.Errorf("更新任务位置失败: %v", err)
+				return nil, fmt.Errorf("更新任务位置失败: %v", err)
 			}
 		}
 
 		// 更新任务位置
 		if err := tx.Model(&models.Task{}).Where("id = ?", task.ID).Update("position", newOrder).Error; err != nil {
-			return fmt.Errorf("更新任务位置失败: %v", err)
+			return nil, fmt.Errorf("更新任务位置失败: %v", err)
 		}
 		
 		// 同列移动暂不记录日志
@@ -202,12 +718,703 @@ func (s *TaskService) MoveTask(taskID uint, newColumnID uint, newOrder int, user
 
 	// 提交事务并验证
 	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("提交事务失败: %v", err)
+		return nil, fmt.Errorf("提交事务失败: %v", err)
 	}
-	return nil
+
+	if oldColumnID != newColumnID {
+		s.notifier.NotifyTaskAsync(task, NotificationMove,
+			fmt.Sprintf("%s moved this task from \"%s\" to \"%s\"", actor.Name, oldColumnName, newColumnName), actor.ID)
+	}
+	return blockers, nil
 }
 
 // createActivityLog 创建活动日志的内部辅助方法
 func (s *TaskService) createActivityLog(tx *gorm.DB, log *models.ActivityLog) error {
 	return tx.Create(log).Error
 }
+
+// ChecklistItemInput 检查项内容，更新时 nil 表示不修改；AssigneeID 为0时清除负责人
+type ChecklistItemInput struct {
+	Content    *string
+	Done       *bool
+	Position   *int
+	AssigneeID *uint
+	DueDate    *time.Time
+}
+
+// GetChecklist 获取任务的检查项
+func (s *TaskService) GetChecklist(taskID uint) ([]models.ChecklistItem, error) {
+	if err := s.db.Select("id").First(&models.Task{}, taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, fmt.Errorf("查询任务失败: %v", err)
+	}
+
+	var items []models.ChecklistItem
+	if err := s.db.Where("task_id = ?", taskID).
+		Preload("Assignee").
+		Order("position ASC").
+		Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("查询检查项失败: %v", err)
+	}
+	return items, nil
+}
+
+// AddChecklistItem 在任务检查项末尾添加一项
+func (s *TaskService) AddChecklistItem(taskID uint, input ChecklistItemInput, actor Actor) (*models.ChecklistItem, error) {
+	if input.Content == nil {
+		return nil, ErrInvalidChecklistItem
+	}
+	content, err := normalizeChecklistContent(*input.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	item := &models.ChecklistItem{
+		TaskID:  taskID,
+		Content: content,
+		DueDate: input.DueDate,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		task, err := s.getTaskSummary(tx, taskID)
+		if err != nil {
+			return err
+		}
+		if input.AssigneeID != nil && *input.AssigneeID != 0 {
+			if err := s.checkProjectMember(*input.AssigneeID, task.ProjectID); err != nil {
+				return err
+			}
+			item.AssigneeID = input.AssigneeID
+		}
+		if input.Done != nil && *input.Done {
+			now := time.Now()
+			item.Done = true
+			item.CompletedAt = &now
+		}
+
+		var maxPosition int
+		if err := tx.Model(&models.ChecklistItem{}).
+			Where("task_id = ?", taskID).
+			Select("COALESCE(MAX(position), -1)").
+			Scan(&maxPosition).Error; err != nil {
+			return fmt.Errorf("查询检查项失败: %v", err)
+		}
+		item.Position = maxPosition + 1
+
+		if err := tx.Create(item).Error; err != nil {
+			return fmt.Errorf("创建检查项失败: %v", err)
+		}
+		return recordTaskActivity(tx, task, &models.ActivityLog{
+			UserID:         actor.ID,
+			Username:       actor.Name,
+			ImpersonatorID: actor.ImpersonatorID,
+			ActionType:     models.ActionUpdate,
+			EntityType:     models.EntityTask,
+			EntityID:       task.ID,
+			Description:    fmt.Sprintf("added checklist item \"%s\"", truncate(item.Content, 100)),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// UpdateChecklistItem 修改检查项内容、完成状态、负责人、截止时间或位置
+func (s *TaskService) UpdateChecklistItem(taskID, itemID uint, input ChecklistItemInput, actor Actor) (*models.ChecklistItem, error) {
+	var item models.ChecklistItem
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		task, err := s.getTaskSummary(tx, taskID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("id = ? AND task_id = ?", itemID, taskID).First(&item).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrChecklistItemNotFound
+			}
+			return fmt.Errorf("查询检查项失败: %v", err)
+		}
+
+		updates := make(map[string]interface{})
+		if input.Content != nil {
+			content, err := normalizeChecklistContent(*input.Content)
+			if err != nil {
+				return err
+			}
+			updates["content"] = content
+		}
+		if input.AssigneeID != nil {
+			if *input.AssigneeID == 0 {
+				updates["assignee_id"] = nil
+			} else {
+				if err := s.checkProjectMember(*input.AssigneeID, task.ProjectID); err != nil {
+					return err
+				}
+				updates["assignee_id"] = *input.AssigneeID
+			}
+		}
+		if input.DueDate != nil {
+			updates["due_date"] = *input.DueDate
+		}
+		toggled := input.Done != nil && *input.Done != item.Done
+		if toggled {
+			updates["done"] = *input.Done
+			if *input.Done {
+				updates["completed_at"] = time.Now()
+			} else {
+				updates["completed_at"] = nil
+			}
+		}
+		if input.Position != nil {
+			if err := s.moveChecklistItem(tx, &item, *input.Position); err != nil {
+				return err
+			}
+		}
+
+		if len(updates) > 0 {
+			if err := tx.Model(&item).Updates(updates).Error; err != nil {
+				return fmt.Errorf("更新检查项失败: %v", err)
+			}
+		}
+		if toggled {
+			action := "completed"
+			if !*input.Done {
+				action = "reopened"
+			}
+			if err := recordTaskActivity(tx, task, &models.ActivityLog{
+				UserID:         actor.ID,
+				Username:       actor.Name,
+				ImpersonatorID: actor.ImpersonatorID,
+				ActionType:     models.ActionUpdate,
+				EntityType:     models.EntityTask,
+				EntityID:       task.ID,
+				Description:    fmt.Sprintf("%s checklist item \"%s\"", action, truncate(item.Content, 100)),
+			}); err != nil {
+				return err
+			}
+		}
+		return tx.Preload("Assignee").First(&item, item.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// DeleteChecklistItem 删除检查项
+func (s *TaskService) DeleteChecklistItem(taskID, itemID uint, actor Actor) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		task, err := s.getTaskSummary(tx, taskID)
+		if err != nil {
+			return err
+		}
+		var item models.ChecklistItem
+		if err := tx.Where("id = ? AND task_id = ?", itemID, taskID).First(&item).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrChecklistItemNotFound
+			}
+			return fmt.Errorf("查询检查项失败: %v", err)
+		}
+
+		if err := tx.Delete(&item).Error; err != nil {
+			return fmt.Errorf("删除检查项失败: %v", err)
+		}
+		if err := tx.Model(&models.ChecklistItem{}).
+			Where("task_id = ? AND position > ?", taskID, item.Position).
+			Update("position", gorm.Expr("position - 1")).Error; err != nil {
+			return fmt.Errorf("更新检查项位置失败: %v", err)
+		}
+		return recordTaskActivity(tx, task, &models.ActivityLog{
+			UserID:         actor.ID,
+			Username:       actor.Name,
+			ImpersonatorID: actor.ImpersonatorID,
+			ActionType:     models.ActionUpdate,
+			EntityType:     models.EntityTask,
+			EntityID:       task.ID,
+			Description:    fmt.Sprintf("removed checklist item \"%s\"", truncate(item.Content, 100)),
+		})
+	})
+}
+
+// moveChecklistItem 将检查项移动到新位置，并调整其他检查项的位置
+func (s *TaskService) moveChecklistItem(tx *gorm.DB, item *models.ChecklistItem, newPosition int) error {
+	var count int64
+	if err := tx.Model(&models.ChecklistItem{}).Where("task_id = ?", item.TaskID).Count(&count).Error; err != nil {
+		return fmt.Errorf("查询检查项失败: %v", err)
+	}
+	if newPosition < 0 {
+		newPosition = 0
+	}
+	if newPosition > int(count)-1 {
+		newPosition = int(count) - 1
+	}
+	if newPosition == item.Position {
+		return nil
+	}
+
+	shift := tx.Model(&models.ChecklistItem{})
+	if item.Position < newPosition {
+		shift = shift.Where("task_id = ? AND position > ? AND position <= ?", item.TaskID, item.Position, newPosition).
+			Update("position", gorm.Expr("position - 1"))
+	} else {
+		shift = shift.Where("task_id = ? AND position >= ? AND position < ?", item.TaskID, newPosition, item.Position).
+			Update("position", gorm.Expr("position + 1"))
+	}
+	if shift.Error != nil {
+		return fmt.Errorf("更新检查项位置失败: %v", shift.Error)
+	}
+
+	if err := tx.Model(item).Update("position", newPosition).Error; err != nil {
+		return fmt.Errorf("更新检查项位置失败: %v", err)
+	}
+	return nil
+}
+
+// getTaskSummary 查询任务的ID、项目和所在列
+func (s *TaskService) getTaskSummary(tx *gorm.DB, taskID uint) (*models.Task, error) {
+	var task models.Task
+	if err := tx.Select("id", "project_id", "column_id").First(&task, taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, fmt.Errorf("查询任务失败: %v", err)
+	}
+	return &task, nil
+}
+
+// checkProjectMember 负责人和关注者必须能访问任务所在项目
+func (s *TaskService) checkProjectMember(userID, projectID uint) error {
+	canAccess, err := NewPermissionService(s.db).CanAccessProject(userID, projectID)
+	if err != nil {
+		return err
+	}
+	if !canAccess {
+		return ErrNotProjectMember
+	}
+	return nil
+}
+
+// normalizeChecklistContent 去除首尾空白并校验检查项内容长度
+func normalizeChecklistContent(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if content == "" || utf8.RuneCountInString(content) > 500 {
+		return "", ErrInvalidChecklistItem
+	}
+	return content, nil
+}
+
+// GetTaskLinks 获取任务的全部关联，类型按当前任务的角度表示
+func (s *TaskService) GetTaskLinks(taskID uint) ([]dto.TaskLinkResponse, error) {
+	if err := s.db.Select("id").First(&models.Task{}, taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, fmt.Errorf("查询任务失败: %v", err)
+	}
+
+	var links []models.TaskLink
+	if err := s.db.Where("source_task_id = ? OR target_task_id = ?", taskID, taskID).
+		Preload("SourceTask.Column").
+		Preload("TargetTask.Column").
+		Order("id ASC").
+		Find(&links).Error; err != nil {
+		return nil, fmt.Errorf("查询任务关联失败: %v", err)
+	}
+
+	var tasks []*models.Task
+	for i := range links {
+		tasks = append(tasks, &links[i].SourceTask, &links[i].TargetTask)
+	}
+	if err := fillTaskKeys(s.db, tasks); err != nil {
+		return nil, err
+	}
+
+	responses := make([]dto.TaskLinkResponse, 0, len(links))
+	for _, link := range links {
+		// 关联的另一端任务已删除时不返回
+		if link.SourceTask.ID == 0 || link.TargetTask.ID == 0 {
+			continue
+		}
+		responses = append(responses, taskLinkResponse(&link, taskID))
+	}
+	return responses, nil
+}
+
+// CreateTaskLink 为任务添加关联，linkType 可以是 blocks、blocked_by、relates_to、duplicates 或 duplicated_by
+func (s *TaskService) CreateTaskLink(taskID, otherTaskID uint, linkType string, actor Actor) (*dto.TaskLinkResponse, error) {
+	link := &models.TaskLink{
+		SourceTaskID: taskID,
+		TargetTaskID: otherTaskID,
+		LinkType:     linkType,
+		CreatorID:    actor.ID,
+	}
+	switch linkType {
+	case models.TaskLinkBlocks, models.TaskLinkRelatesTo, models.TaskLinkDuplicates:
+	case models.TaskLinkBlockedBy:
+		link.SourceTaskID, link.TargetTaskID, link.LinkType = otherTaskID, taskID, models.TaskLinkBlocks
+	case models.TaskLinkDuplicatedBy:
+		link.SourceTaskID, link.TargetTaskID, link.LinkType = otherTaskID, taskID, models.TaskLinkDuplicates
+	default:
+		return nil, ErrInvalidLinkType
+	}
+	if taskID == otherTaskID {
+		return nil, ErrTaskLinkSelf
+	}
+
+	var response dto.TaskLinkResponse
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var task, other models.Task
+		if err := tx.First(&task, taskID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTaskNotFound
+			}
+			return fmt.Errorf("查询任务失败: %v", err)
+		}
+		if err := tx.Preload("Column").First(&other, otherTaskID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTaskNotFound
+			}
+			return fmt.Errorf("查询任务失败: %v", err)
+		}
+		if task.ProjectID != other.ProjectID {
+			return ErrTaskLinkMismatch
+		}
+		if err := fillTaskKeys(tx, []*models.Task{&task, &other}); err != nil {
+			return err
+		}
+
+		// relates_to 没有方向，两个方向都算已存在
+		existing := tx.Model(&models.TaskLink{}).Where("source_task_id = ? AND target_task_id = ? AND link_type = ?",
+			link.SourceTaskID, link.TargetTaskID, link.LinkType)
+		if link.LinkType == models.TaskLinkRelatesTo {
+			existing = existing.Or("source_task_id = ? AND target_task_id = ? AND link_type = ?",
+				link.TargetTaskID, link.SourceTaskID, link.LinkType)
+		}
+		var count int64
+		if err := existing.Count(&count).Error; err != nil {
+			return fmt.Errorf("查询任务关联失败: %v", err)
+		}
+		if count > 0 {
+			return ErrTaskLinkExists
+		}
+
+		if link.LinkType == models.TaskLinkBlocks {
+			cyclic, err := s.blockingPathExists(tx, link.TargetTaskID, link.SourceTaskID)
+			if err != nil {
+				return err
+			}
+			if cyclic {
+				return ErrTaskLinkCycle
+			}
+		}
+
+		if err := tx.Create(link).Error; err != nil {
+			return fmt.Errorf("创建任务关联失败: %v", err)
+		}
+		if link.SourceTaskID == taskID {
+			link.SourceTask, link.TargetTask = task, other
+		} else {
+			link.SourceTask, link.TargetTask = other, task
+		}
+		response = taskLinkResponse(link, taskID)
+
+		return recordTaskActivity(tx, &task, &models.ActivityLog{
+			UserID:         actor.ID,
+			Username:       actor.Name,
+			ImpersonatorID: actor.ImpersonatorID,
+			ActionType:     models.ActionUpdate,
+			EntityType:     models.EntityTask,
+			EntityID:       task.ID,
+			Description:    fmt.Sprintf("linked this task: %s %s \"%s\"", response.Type, other.Key, truncate(other.Title, 100)),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// DeleteTaskLink 删除任务的关联，关联的任一端任务都可以删除
+func (s *TaskService) DeleteTaskLink(taskID, linkID uint, actor Actor) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var link models.TaskLink
+		if err := tx.Where("id = ? AND (source_task_id = ? OR target_task_id = ?)", linkID, taskID, taskID).
+			First(&link).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTaskLinkNotFound
+			}
+			return fmt.Errorf("查询任务关联失败: %v", err)
+		}
+		var task models.Task
+		if err := tx.First(&task, taskID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTaskNotFound
+			}
+			return fmt.Errorf("查询任务失败: %v", err)
+		}
+
+		if err := tx.Delete(&link).Error; err != nil {
+			return fmt.Errorf("删除任务关联失败: %v", err)
+		}
+
+		otherID := link.TargetTaskID
+		if otherID == taskID {
+			otherID = link.SourceTaskID
+		}
+		var other models.Task
+		if err := tx.Unscoped().Select("project_id", "number").First(&other, otherID).Error; err != nil {
+			return fmt.Errorf("查询任务失败: %v", err)
+		}
+		if err := fillTaskKeys(tx, []*models.Task{&other}); err != nil {
+			return err
+		}
+		return recordTaskActivity(tx, &task, &models.ActivityLog{
+			UserID:         actor.ID,
+			Username:       actor.Name,
+			ImpersonatorID: actor.ImpersonatorID,
+			ActionType:     models.ActionUpdate,
+			EntityType:     models.EntityTask,
+			EntityID:       task.ID,
+			Description:    fmt.Sprintf("removed link to %s", other.Key),
+		})
+	})
+}
+
+// blockingPathExists 沿 blocks 关联从 fromID 出发能否到达 toID
+func (s *TaskService) blockingPathExists(tx *gorm.DB, fromID, toID uint) (bool, error) {
+	visited := map[uint]bool{fromID: true}
+	frontier := []uint{fromID}
+	for len(frontier) > 0 {
+		var next []uint
+		if err := tx.Model(&models.TaskLink{}).
+			Where("link_type = ? AND source_task_id IN ?", models.TaskLinkBlocks, frontier).
+			Pluck("target_task_id", &next).Error; err != nil {
+			return false, fmt.Errorf("查询阻塞关系失败: %v", err)
+		}
+		frontier = frontier[:0]
+		for _, id := range next {
+			if id == toID {
+				return true, nil
+			}
+			if !visited[id] {
+				visited[id] = true
+				frontier = append(frontier, id)
+			}
+		}
+	}
+	return false, nil
+}
+
+// openBlockers 查询阻塞该任务且尚未完成的任务（状态未关闭且不在完成列）
+func (s *TaskService) openBlockers(tx *gorm.DB, taskID uint) ([]dto.TaskLinkResponse, error) {
+	var links []models.TaskLink
+	if err := tx.Where("target_task_id = ? AND link_type = ?", taskID, models.TaskLinkBlocks).
+		Preload("SourceTask.Column").
+		Find(&links).Error; err != nil {
+		return nil, fmt.Errorf("查询阻塞任务失败: %v", err)
+	}
+
+	sources := make([]*models.Task, len(links))
+	for i := range links {
+		sources[i] = &links[i].SourceTask
+	}
+	if err := fillTaskKeys(tx, sources); err != nil {
+		return nil, err
+	}
+
+	var blockers []dto.TaskLinkResponse
+	for _, link := range links {
+		if link.SourceTask.ID == 0 {
+			continue
+		}
+		if response := taskLinkResponse(&link, taskID); response.Open {
+			blockers = append(blockers, response)
+		}
+	}
+	return blockers, nil
+}
+
+// taskLinkResponse 从 taskID 一侧描述关联
+func taskLinkResponse(link *models.TaskLink, taskID uint) dto.TaskLinkResponse {
+	linkType := link.LinkType
+	other := &link.TargetTask
+	if link.SourceTaskID != taskID {
+		other = &link.SourceTask
+		switch link.LinkType {
+		case models.TaskLinkBlocks:
+			linkType = models.TaskLinkBlockedBy
+		case models.TaskLinkDuplicates:
+			linkType = models.TaskLinkDuplicatedBy
+		}
+	}
+	return dto.TaskLinkResponse{
+		ID:         link.ID,
+		Type:       linkType,
+		TaskID:     other.ID,
+		TaskKey:    other.Key,
+		TaskTitle:  other.Title,
+		TaskStatus: int(other.Status),
+		ColumnID:   other.ColumnID,
+		Open:       !taskClosed(other.Status) && !other.Column.IsDone,
+		CreatedAt:  link.CreatedAt,
+	}
+}
+
+// AddAssignee 添加任务负责人，已是负责人时不做处理
+func (s *TaskService) AddAssignee(taskID, userID uint, actor Actor) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		task, err := s.getTaskSummary(tx, taskID)
+		if err != nil {
+			return err
+		}
+		return s.addTaskMember(tx, task, userID, actor, &models.TaskAssignee{TaskID: taskID, UserID: userID}, models.ActionAssign, "assigned %s")
+	})
+}
+
+// RemoveAssignee 移除任务负责人
+func (s *TaskService) RemoveAssignee(taskID, userID uint, actor Actor) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		task, err := s.getTaskSummary(tx, taskID)
+		if err != nil {
+			return err
+		}
+		return s.removeTaskMember(tx, task, userID, actor, &models.TaskAssignee{}, models.ActionAssign, "unassigned %s")
+	})
+}
+
+// setAssignees 用给定的用户替换任务的全部负责人
+func (s *TaskService) setAssignees(tx *gorm.DB, taskID uint, userIDs []uint, actor Actor) error {
+	userIDs = uniqueIDs(userIDs)
+	task, err := s.getTaskSummary(tx, taskID)
+	if err != nil {
+		return err
+	}
+	var current []uint
+	if err := tx.Model(&models.TaskAssignee{}).Where("task_id = ?", taskID).Pluck("user_id", &current).Error; err != nil {
+		return fmt.Errorf("查询任务负责人失败: %v", err)
+	}
+
+	keep := make(map[uint]bool, len(userIDs))
+	for _, userID := range userIDs {
+		keep[userID] = true
+	}
+	for _, userID := range current {
+		if keep[userID] {
+			continue
+		}
+		if err := s.removeTaskMember(tx, task, userID, actor, &models.TaskAssignee{}, models.ActionAssign, "unassigned %s"); err != nil {
+			return err
+		}
+	}
+	for _, userID := range userIDs {
+		if err := s.addTaskMember(tx, task, userID, actor, &models.TaskAssignee{TaskID: taskID, UserID: userID}, models.ActionAssign, "assigned %s"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddWatcher 添加任务关注者，已关注时不做处理
+func (s *TaskService) AddWatcher(taskID, userID uint, actor Actor) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		task, err := s.getTaskSummary(tx, taskID)
+		if err != nil {
+			return err
+		}
+		return s.addTaskMember(tx, task, userID, actor, &models.TaskWatcher{TaskID: taskID, UserID: userID}, models.ActionUpdate, "added watcher %s")
+	})
+}
+
+// RemoveWatcher 移除任务关注者
+func (s *TaskService) RemoveWatcher(taskID, userID uint, actor Actor) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		task, err := s.getTaskSummary(tx, taskID)
+		if err != nil {
+			return err
+		}
+		return s.removeTaskMember(tx, task, userID, actor, &models.TaskWatcher{}, models.ActionUpdate, "removed watcher %s")
+	})
+}
+
+// addTaskMember 写入负责人或关注者记录（row 为 TaskAssignee 或 TaskWatcher）并记录活动日志
+func (s *TaskService) addTaskMember(tx *gorm.DB, task *models.Task, userID uint, actor Actor, row interface{}, action, description string) error {
+	if err := s.checkProjectMember(userID, task.ProjectID); err != nil {
+		return err
+	}
+
+	var count int64
+	if err := tx.Model(row).Where("task_id = ? AND user_id = ?", task.ID, userID).Count(&count).Error; err != nil {
+		return fmt.Errorf("查询任务成员失败: %v", err)
+	}
+	if count > 0 {
+		return nil
+	}
+	if err := tx.Create(row).Error; err != nil {
+		return fmt.Errorf("添加任务成员失败: %v", err)
+	}
+	return s.recordMemberActivity(tx, task, userID, actor, action, description)
+}
+
+// removeTaskMember 删除负责人或关注者记录并记录活动日志
+func (s *TaskService) removeTaskMember(tx *gorm.DB, task *models.Task, userID uint, actor Actor, row interface{}, action, description string) error {
+	result := tx.Where("task_id = ? AND user_id = ?", task.ID, userID).Delete(row)
+	if result.Error != nil {
+		return fmt.Errorf("移除任务成员失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+	return s.recordMemberActivity(tx, task, userID, actor, action, description)
+}
+
+// recordMemberActivity 记录负责人或关注者变更
+func (s *TaskService) recordMemberActivity(tx *gorm.DB, task *models.Task, userID uint, actor Actor, action, description string) error {
+	var user models.User
+	if err := tx.Unscoped().Select("id", "username").First(&user, userID).Error; err != nil {
+		return fmt.Errorf("查询用户失败: %v", err)
+	}
+	return recordTaskActivity(tx, task, &models.ActivityLog{
+		UserID:         actor.ID,
+		Username:       actor.Name,
+		ImpersonatorID: actor.ImpersonatorID,
+		ActionType:     action,
+		EntityType:     models.EntityTask,
+		EntityID:       task.ID,
+		Description:    fmt.Sprintf(description, user.Username),
+	})
+}
+
+// uniqueIDs 去除重复和为0的ID，保持原有顺序
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if id != 0 && !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// setCustomFields 设置任务的自定义字段值，values 中未出现的字段保持不变，值为 null 时清空
+func (s *TaskService) setCustomFields(tx *gorm.DB, taskID uint, values map[uint]json.RawMessage, actor Actor) error {
+	task, err := s.getTaskSummary(tx, taskID)
+	if err != nil {
+		return err
+	}
+	changed, err := setTaskCustomFields(tx, task, values, false)
+	if err != nil || len(changed) == 0 {
+		return err
+	}
+	return recordTaskActivity(tx, task, &models.ActivityLog{
+		UserID:         actor.ID,
+		Username:       actor.Name,
+		ImpersonatorID: actor.ImpersonatorID,
+		ActionType:     models.ActionUpdate,
+		EntityType:     models.EntityTask,
+		EntityID:       task.ID,
+		Description:    fmt.Sprintf("updated custom fields: %s", truncate(strings.Join(changed, ", "), 200)),
+	})
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"progress-wall-backend/models"
 
@@ -10,24 +11,32 @@ import (
 )
 
 var (
-	ErrTeamNotFound      = errors.New("team not found")
-	ErrUserAlreadyMember = errors.New("user is already a member of the team")
+	ErrTeamNotFound       = errors.New("team not found")
+	ErrUserAlreadyMember  = errors.New("user is already a member of the team")
+	ErrTeamMemberNotFound = errors.New("user is not a member of the team")
+	ErrTeamOwner          = errors.New("the team owner cannot be removed or demoted; transfer ownership first")
+	ErrInvalidTeamRole    = errors.New("invalid team role")
+	ErrNotTeamOwner       = errors.New("only the team owner can transfer ownership")
 )
 
 type TeamService struct {
-	db *gorm.DB
+	db          *gorm.DB
+	permService *PermissionService
 }
 
 func NewTeamService(db *gorm.DB) *TeamService {
-	return &TeamService{db: db}
+	return &TeamService{
+		db:          db,
+		permService: NewPermissionService(db),
+	}
 }
 
 // CreateTeam 创建团队并自动将创建者设为管理员
-func (s *TeamService) CreateTeam(name, description string, creatorID uint) (*models.Team, error) {
+func (s *TeamService) CreateTeam(name, description string, creator Actor) (*models.Team, error) {
 	team := &models.Team{
 		Name:        name,
 		Description: description,
-		CreatorID:   creatorID,
+		CreatorID:   creator.ID,
 	}
 
 	err := s.db.Transaction(func(tx *gorm.DB) error {
@@ -37,14 +46,15 @@ func (s *TeamService) CreateTeam(name, description string, creatorID uint) (*mod
 
 		// Add creator as admin
 		member := &models.TeamMember{
-			TeamID: team.ID,
-			UserID: creatorID,
-			Role:   models.TeamRoleAdmin,
+			TeamID:   team.ID,
+			UserID:   creator.ID,
+			Role:     models.TeamRoleAdmin,
+			JoinedAt: time.Now(),
 		}
 		if err := tx.Create(member).Error; err != nil {
 			return err
 		}
-		return nil
+		return recordTeamActivity(tx, team.ID, creator, "created the team")
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create team: %v", err)
@@ -90,19 +100,218 @@ func (s *TeamService) GetMembers(teamID uint) ([]models.TeamMember, error) {
 }
 
 // AddTeamMember 添加成员到团队
-func (s *TeamService) AddTeamMember(teamID, userID uint, role models.TeamRole) error {
-	// Check if already exists
-	var count int64
-	s.db.Model(&models.TeamMember{}).Where("team_id = ? AND user_id = ?", teamID, userID).Count(&count)
-	if count > 0 {
-		return ErrUserAlreadyMember
+func (s *TeamService) AddTeamMember(teamID, userID uint, role models.TeamRole, actor Actor) error {
+	if !validTeamRole(role) {
+		return ErrInvalidTeamRole
 	}
 
-	member := models.TeamMember{
-		TeamID: teamID,
-		UserID: userID,
-		Role:   role,
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// Check if already exists
+		var count int64
+		if err := tx.Model(&models.TeamMember{}).Where("team_id = ? AND user_id = ?", teamID, userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrUserAlreadyMember
+		}
+
+		var user models.User
+		if err := tx.Select("id", "username").First(&user, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+
+		member := models.TeamMember{
+			TeamID:   teamID,
+			UserID:   userID,
+			Role:     role,
+			JoinedAt: time.Now(),
+		}
+		if err := tx.Create(&member).Error; err != nil {
+			return err
+		}
+
+		return recordTeamActivity(tx, teamID, actor, fmt.Sprintf("added %s to the team", user.Username))
+	})
+}
+
+// RemoveTeamMember 将成员移出团队，同时移除其在该团队所有项目中的成员身份
+// 团队所有者不能被移除，需先转让所有权；也不能移除团队项目中的最后一位管理员
+func (s *TeamService) RemoveTeamMember(teamID, userID uint, actor Actor) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		member, err := s.getTeamMember(tx, teamID, userID)
+		if err != nil {
+			return err
+		}
+		if err := s.ensureNotOwner(tx, teamID, userID); err != nil {
+			return err
+		}
+		if err := removeTeamMembership(tx, member); err != nil {
+			return err
+		}
+
+		description := fmt.Sprintf("removed %s from the team", member.User.Username)
+		if actor.ID == userID {
+			description = "left the team"
+		}
+		return recordTeamActivity(tx, teamID, actor, description)
+	})
+}
+
+// LeaveTeam 当前用户主动退出团队
+func (s *TeamService) LeaveTeam(teamID uint, actor Actor) error {
+	return s.RemoveTeamMember(teamID, actor.ID, actor)
+}
+
+// UpdateTeamMemberRole 修改团队成员角色，团队所有者不能被降级
+func (s *TeamService) UpdateTeamMemberRole(teamID, userID uint, role models.TeamRole, actor Actor) error {
+	if !validTeamRole(role) {
+		return ErrInvalidTeamRole
 	}
 
-	return s.db.Create(&member).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		member, err := s.getTeamMember(tx, teamID, userID)
+		if err != nil {
+			return err
+		}
+		if member.Role == role {
+			return nil
+		}
+		if role != models.TeamRoleAdmin {
+			if err := s.ensureNotOwner(tx, teamID, userID); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(member).Update("role", role).Error; err != nil {
+			return err
+		}
+
+		return recordTeamActivity(tx, teamID, actor,
+			fmt.Sprintf("changed the role of %s to %s", member.User.Username, teamRoleName(role)))
+	})
+}
+
+// TransferOwnership 将团队所有权转让给另一位成员
+// 只有当前所有者或系统管理员可以转让，新所有者会被提升为团队管理员
+func (s *TeamService) TransferOwnership(teamID, newOwnerID uint, actor Actor) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var team models.Team
+		if err := tx.First(&team, teamID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTeamNotFound
+			}
+			return err
+		}
+
+		if team.CreatorID != actor.ID {
+			isAdmin, err := s.permService.IsSysAdmin(actor.ID)
+			if err != nil {
+				return err
+			}
+			if !isAdmin {
+				return ErrNotTeamOwner
+			}
+		}
+		if team.CreatorID == newOwnerID {
+			return nil
+		}
+
+		member, err := s.getTeamMember(tx, teamID, newOwnerID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&team).Update("creator_id", newOwnerID).Error; err != nil {
+			return err
+		}
+		if member.Role != models.TeamRoleAdmin {
+			if err := tx.Model(member).Update("role", models.TeamRoleAdmin).Error; err != nil {
+				return err
+			}
+		}
+
+		return recordTeamActivity(tx, teamID, actor,
+			fmt.Sprintf("transferred team ownership to %s", member.User.Username))
+	})
+}
+
+// getTeamMember 查询团队成员记录（包含用户信息）
+func (s *TeamService) getTeamMember(tx *gorm.DB, teamID, userID uint) (*models.TeamMember, error) {
+	var member models.TeamMember
+	err := tx.Where("team_id = ? AND user_id = ?", teamID, userID).Preload("User").First(&member).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTeamMemberNotFound
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+// ensureNotOwner 若用户是团队所有者则返回 ErrTeamOwner
+func (s *TeamService) ensureNotOwner(tx *gorm.DB, teamID, userID uint) error {
+	var team models.Team
+	if err := tx.Select("creator_id").First(&team, teamID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTeamNotFound
+		}
+		return err
+	}
+	if team.CreatorID == userID {
+		return ErrTeamOwner
+	}
+	return nil
+}
+
+// removeTeamMembership 删除团队成员记录及其在团队项目中的成员记录
+// 若用户是某个团队项目的最后一位管理员则返回 ErrLastProjectAdmin
+// team_members 和 project_members 同时作为 many2many 关联表使用，因此直接物理删除
+func removeTeamMembership(tx *gorm.DB, member *models.TeamMember) error {
+	projectIDs := tx.Model(&models.Project{}).Select("id").Where("team_id = ?", member.TeamID)
+
+	var adminProjectIDs []uint
+	if err := tx.Model(&models.ProjectMember{}).
+		Where("user_id = ? AND role = ? AND project_id IN (?)", member.UserID, models.ProjectRoleAdmin, projectIDs).
+		Pluck("project_id", &adminProjectIDs).Error; err != nil {
+		return err
+	}
+	for _, projectID := range adminProjectIDs {
+		if err := ensureAnotherAdmin(tx, projectID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Unscoped().
+		Where("user_id = ? AND project_id IN (?)", member.UserID, projectIDs).
+		Delete(&models.ProjectMember{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(member).Error
+}
+
+// recordTeamActivity 记录团队成员变更活动日志
+func recordTeamActivity(tx *gorm.DB, teamID uint, actor Actor, description string) error {
+	return tx.Create(&models.ActivityLog{
+		UserID:         actor.ID,
+		Username:       actor.Name,
+		ImpersonatorID: actor.ImpersonatorID,
+		ActionType:     models.ActionUpdate,
+		EntityType:     models.EntityTeam,
+		EntityID:       teamID,
+		Description:    description,
+	}).Error
+}
+
+func validTeamRole(role models.TeamRole) bool {
+	return role == models.TeamRoleMember || role == models.TeamRoleAdmin
+}
+
+func teamRoleName(role models.TeamRole) string {
+	if role == models.TeamRoleAdmin {
+		return "admin"
+	}
+	return "member"
 }
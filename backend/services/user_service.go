@@ -3,6 +3,7 @@ package services
 import (
 	"errors"
 	"progress-wall-backend/models"
+	"progress-wall-backend/utils"
 
 	"gorm.io/gorm"
 )
@@ -48,9 +49,38 @@ func (s *UserService) UpdateUser(user *models.User) error {
 		return errors.New("用户ID不能为空")
 	}
 	
-	result := s.db.Save(user)
+	// 密码只能通过 SetPassword 修改，避免用清除了密码的对象覆盖密码哈希
+	result := s.db.Omit("password").Save(user)
 	if result.Error != nil {
 		return errors.New("更新用户失败")
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// SetPassword 设置用户新密码并吊销该用户的所有登录会话
+func (s *UserService) SetPassword(userID uint, newPassword string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return setPassword(tx, userID, newPassword)
+	})
+}
+
+// setPassword 在事务中更新密码、清除强制改密标记并吊销所有会话
+// 所有修改密码的入口都应通过此函数，保证旧会话随密码变更失效
+func setPassword(tx *gorm.DB, userID uint, newPassword string) error {
+	hashedPassword, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return errors.New("加密密码失败")
+	}
+
+	result := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"password":             hashedPassword,
+		"must_change_password": false,
+	})
+	if result.Error != nil {
+		return errors.New("更新密码失败")
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return revokeUserSessions(tx, userID, "")
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage 本地文件系统存储
+type LocalStorage struct {
+	root string
+}
+
+// NewLocalStorage 创建本地文件系统存储，root 目录不存在时自动创建
+func NewLocalStorage(root string) (*LocalStorage, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("创建存储目录失败: %w", err)
+	}
+	return &LocalStorage{root: root}, nil
+}
+
+// Put 写入文件
+func (s *LocalStorage) Put(key string, r io.Reader, size int64, contentType string) error {
+	path, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %w", err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(path)
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	return f.Close()
+}
+
+// Get 读取文件
+func (s *LocalStorage) Get(key string) (io.ReadCloser, error) {
+	path, err := s.resolve(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	return f, nil
+}
+
+// Delete 删除文件
+func (s *LocalStorage) Delete(key string) error {
+	path, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("删除文件失败: %w", err)
+	}
+	return nil
+}
+
+// resolve 将 key 转换为 root 下的绝对路径，拒绝越出 root 的路径
+func (s *LocalStorage) resolve(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	path := filepath.Join(s.root, cleaned)
+	if !strings.HasPrefix(path, filepath.Clean(s.root)+string(filepath.Separator)) {
+		return "", fmt.Errorf("非法的文件路径: %s", key)
+	}
+	return path, nil
+}
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"progress-wall-backend/config"
+)
+
+// ErrObjectNotFound 存储中不存在指定对象
+var ErrObjectNotFound = errors.New("文件不存在")
+
+// Storage 文件存储后端抽象
+// key 为存储内的相对路径（如 attachments/1/xxx.png），由调用方生成
+type Storage interface {
+	// Put 写入对象，size 为内容长度（字节）
+	Put(key string, r io.Reader, size int64, contentType string) error
+
+	// Get 读取对象，调用方负责关闭返回的 ReadCloser
+	Get(key string) (io.ReadCloser, error)
+
+	// Delete 删除对象，对象不存在时不返回错误
+	Delete(key string) error
+}
+
+// New 根据配置创建存储后端
+func New(cfg config.StorageConfig) (Storage, error) {
+	switch cfg.Driver {
+	case "local":
+		return NewLocalStorage(cfg.LocalDir)
+	case "s3":
+		return NewS3Storage(cfg)
+	default:
+		return nil, fmt.Errorf("不支持的存储类型: %s", cfg.Driver)
+	}
+}
@@ -1,8 +1,10 @@
 package utils
 
 import (
+	"crypto"
 	"errors"
 	"progress-wall-backend/config"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -10,39 +12,128 @@ import (
 
 // Claims JWT声明结构
 type Claims struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
+	UserID    uint   `json:"user_id"`
+	Username  string `json:"username"`
+	SessionID string `json:"sid"`
+	// Purpose 非空表示受限用途的令牌（如两步验证待完成），不能用于访问接口
+	Purpose string `json:"purpose,omitempty"`
+	// ImpersonatorID 非零表示管理员模拟登录令牌，值为实际操作的管理员ID
+	ImpersonatorID uint `json:"impersonator_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken 生成JWT token
-func GenerateToken(userID uint, username string, cfg *config.Config) (string, error) {
+// JWTKey 非对称签名密钥，Kid 写入令牌头用于选择验证公钥
+type JWTKey struct {
+	Kid        string
+	Algorithm  string        // RS256 或 EdDSA
+	PrivateKey crypto.Signer // 仅签名密钥需要
+	PublicKey  crypto.PublicKey
+}
+
+// keyRing 当前签名密钥和所有可用于验证的公钥，由 KeyService 定期从数据库加载
+var keyRing struct {
+	sync.RWMutex
+	signing *JWTKey
+	verify  map[string]*JWTKey
+	keys    []*JWTKey
+}
+
+// SetKeyRing 替换签名密钥和验证密钥
+func SetKeyRing(signing *JWTKey, verify []*JWTKey) {
+	m := make(map[string]*JWTKey, len(verify))
+	for _, key := range verify {
+		m[key.Kid] = key
+	}
+	keyRing.Lock()
+	defer keyRing.Unlock()
+	keyRing.signing = signing
+	keyRing.verify = m
+	keyRing.keys = verify
+}
+
+// VerificationKeys 返回当前所有可用于验证的公钥
+func VerificationKeys() []*JWTKey {
+	keyRing.RLock()
+	defer keyRing.RUnlock()
+	return keyRing.keys
+}
+
+// GenerateToken 生成JWT访问令牌，sessionID 为所属登录会话
+func GenerateToken(userID uint, username, sessionID string, cfg *config.Config) (string, error) {
 	claims := &Claims{
-		UserID:   userID,
-		Username: username,
+		UserID:    userID,
+		Username:  username,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(cfg.JWT.ExpireHours) * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(cfg.JWT.AccessExpireMinutes) * time.Minute)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		},
 	}
+	return signToken(claims, cfg)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(cfg.JWT.Secret))
-	if err != nil {
-		return "", err
+// GenerateImpersonationToken 生成管理员模拟登录的访问令牌
+// 令牌以被模拟用户的身份访问接口，有效期为 ttl 且不签发刷新令牌
+func GenerateImpersonationToken(userID uint, username, sessionID string, impersonatorID uint, ttl time.Duration, cfg *config.Config) (string, error) {
+	claims := &Claims{
+		UserID:         userID,
+		Username:       username,
+		SessionID:      sessionID,
+		ImpersonatorID: impersonatorID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+		},
 	}
+	return signToken(claims, cfg)
+}
 
-	return tokenString, nil
+// MFATokenPurpose 两步验证待完成令牌的用途标识
+const MFATokenPurpose = "mfa"
+
+// mfaTokenTTL 两步验证待完成令牌有效期
+const mfaTokenTTL = 5 * time.Minute
+
+// GenerateMFAToken 生成两步验证待完成令牌
+// 密码校验通过后签发，只能用于提交第二步验证码
+func GenerateMFAToken(userID uint, username string, cfg *config.Config) (string, error) {
+	claims := &Claims{
+		UserID:   userID,
+		Username: username,
+		Purpose:  MFATokenPurpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(mfaTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+		},
+	}
+	return signToken(claims, cfg)
 }
 
 // ValidateToken 验证JWT token
+// HS256 模式下使用 JWT_SECRET 验证；非对称模式下按令牌头中的 kid 选择公钥，且签名算法必须与密钥一致
 func ValidateToken(tokenString string, cfg *config.Config) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		if cfg.JWT.Algorithm == config.JWTAlgorithmHS256 {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, errors.New("无效的签名方法")
+			}
+			return []byte(cfg.JWT.Secret), nil
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		keyRing.RLock()
+		key := keyRing.verify[kid]
+		keyRing.RUnlock()
+		if key == nil {
+			return nil, errors.New("未知的签名密钥")
+		}
+		if token.Method.Alg() != key.Algorithm {
 			return nil, errors.New("无效的签名方法")
 		}
-		return []byte(cfg.JWT.Secret), nil
+		return key.PublicKey, nil
 	})
 
 	if err != nil {
@@ -55,3 +146,21 @@ func ValidateToken(tokenString string, cfg *config.Config) (*Claims, error) {
 
 	return nil, errors.New("无效的token")
 }
+
+// signToken 使用当前签名密钥签发令牌
+func signToken(claims *Claims, cfg *config.Config) (string, error) {
+	if cfg.JWT.Algorithm == config.JWTAlgorithmHS256 {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(cfg.JWT.Secret))
+	}
+
+	keyRing.RLock()
+	key := keyRing.signing
+	keyRing.RUnlock()
+	if key == nil {
+		return "", errors.New("签名密钥未初始化")
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.Kid
+	return token.SignedString(key.PrivateKey)
+}
@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// JWK JSON Web Key 公钥表示，用于 /.well-known/jwks.json
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// GenerateKeyPair 按签名算法生成密钥对，支持 RS256 和 EdDSA（Ed25519）
+func GenerateKeyPair(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case "RS256":
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case "EdDSA":
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		return priv, err
+	default:
+		return nil, fmt.Errorf("不支持的签名算法: %s", algorithm)
+	}
+}
+
+// EncryptPrivateKey 使用由 secret 派生的密钥加密私钥（AES-GCM），返回 base64 文本
+func EncryptPrivateKey(secret string, key crypto.Signer) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newKeyCipher(secret)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, der, nil)), nil
+}
+
+// DecryptPrivateKey 解密 EncryptPrivateKey 生成的私钥，secret 不一致时返回错误
+func DecryptPrivateKey(secret, data string) (crypto.Signer, error) {
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newKeyCipher(secret)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) < gcm.NonceSize() {
+		return nil, errors.New("私钥数据损坏")
+	}
+	der, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.New("私钥解密失败，JWT_SECRET 可能已变更")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("不支持的私钥类型")
+	}
+	return signer, nil
+}
+
+// MarshalPublicKey 将公钥编码为 base64 的 PKIX DER
+func MarshalPublicKey(pub crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(der), nil
+}
+
+// ParsePublicKey 解析 MarshalPublicKey 编码的公钥
+func ParsePublicKey(data string) (crypto.PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParsePKIXPublicKey(der)
+}
+
+// PublicJWK 将验证密钥转换为 JWK
+func PublicJWK(key *JWTKey) JWK {
+	jwk := JWK{Kid: key.Kid, Use: "sig", Alg: key.Algorithm}
+	switch pub := key.PublicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return jwk
+}
+
+// newKeyCipher 由 secret 派生 AES-256-GCM 加密器
+func newKeyCipher(secret string) (cipher.AEAD, error) {
+	sum := sha256.Sum256([]byte("progress-wall-signing-key:" + secret))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
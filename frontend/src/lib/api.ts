@@ -27,9 +27,16 @@ api.interceptors.request.use(
 
 api.interceptors.response.use(
   response => response,
-  error => {
-    if (error.response?.status === 401) {
+  async error => {
+    const original = error.config
+    const isAuthRequest = original?.url?.startsWith('/auth/')
+    if (error.response?.status === 401 && !isAuthRequest) {
       const userStore = useUserStore()
+      // 访问令牌过期时先尝试刷新，成功后重放原请求
+      if (!original._retried && (await userStore.refresh())) {
+        original._retried = true
+        return api(original)
+      }
       userStore.logout()
       // 使用 Vue Router 导航，避免页面刷新
       if (router.currentRoute.value.path !== '/login') {
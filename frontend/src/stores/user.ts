@@ -15,6 +15,7 @@ export interface User {
 
 export const useUserStore = defineStore('user', () => {
   const token = ref<string | null>(localStorage.getItem('accessToken') || null)
+  const refreshToken = ref<string | null>(localStorage.getItem('refreshToken') || null)
   const currentUser = ref<User | null>(null)
   const isLoggedIn = ref(!!token.value)
 
@@ -24,14 +25,40 @@ export const useUserStore = defineStore('user', () => {
     else localStorage.removeItem('accessToken')
     isLoggedIn.value = !!t
   }
+  function setRefreshToken(t: string | null) {
+    refreshToken.value = t
+    if (t) localStorage.setItem('refreshToken', t)
+    else localStorage.removeItem('refreshToken')
+  }
   function getToken() {
     return token.value
   }
+
+  // 使用刷新令牌换取新的访问令牌，失败时返回 false
+  let refreshing: Promise<boolean> | null = null
+  function refresh() {
+    if (!refreshToken.value) return Promise.resolve(false)
+    if (!refreshing) {
+      refreshing = api
+        .post('/auth/refresh', { refreshToken: refreshToken.value })
+        .then(res => {
+          setToken(res.data.accessToken)
+          setRefreshToken(res.data.refreshToken)
+          return true
+        })
+        .catch(() => false)
+        .finally(() => {
+          refreshing = null
+        })
+    }
+    return refreshing
+  }
   async function login(username: string, password: string) {
     try {
       const res = await api.post('/auth/login', { username, password })
       if (res.data?.accessToken) {
         setToken(res.data.accessToken)
+        setRefreshToken(res.data.refreshToken)
         currentUser.value = res.data.user
         isLoggedIn.value = true
         return true
@@ -72,6 +99,11 @@ export const useUserStore = defineStore('user', () => {
   }
 
   function logout() {
+    // 通知后端吊销会话，失败不影响本地退出
+    if (refreshToken.value) {
+      api.post('/auth/logout', { refreshToken: refreshToken.value }).catch(() => {})
+    }
+    setRefreshToken(null)
     setToken(null)
     currentUser.value = null
     isLoggedIn.value = false
@@ -128,6 +160,7 @@ export const useUserStore = defineStore('user', () => {
     isLoggedIn, 
     setToken, 
     getToken, 
+    refresh,
     login, 
     register, 
     logout, 